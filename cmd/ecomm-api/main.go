@@ -1,14 +1,40 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/m21power/ecomm/db"
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/cache"
 	"github.com/m21power/ecomm/ecomm-api/handler"
+	"github.com/m21power/ecomm/ecomm-api/payments"
 	"github.com/m21power/ecomm/ecomm-api/server"
 	"github.com/m21power/ecomm/ecomm-api/storer"
 )
 
+const (
+	productCacheSize = 10000
+	productCacheTTL  = 5 * time.Minute
+	// with a shared cache each replica keeps a short-lived near copy; the
+	// TTL bounds staleness should an invalidation message be lost
+	nearCacheSize = 1000
+	nearCacheTTL  = 30 * time.Second
+
+	tokenTTL = 24 * time.Hour
+
+	guestCartSweepInterval      = time.Hour
+	idempotencyKeySweepInterval = time.Hour
+	reservationSweepInterval    = time.Minute
+)
+
 func main() {
 	db, err := db.NewDatabase()
 	if err != nil {
@@ -17,8 +43,29 @@ func main() {
 	defer db.Close()
 	log.Printf("Connected to database")
 	st := storer.NewMySQLStorer(db.GetDB())
-	server := server.NewServer(st)
-	h := handler.NewHandler(server)
+	metrics := &cache.Metrics{}
+	var backend cache.Backend = cache.NewMemory(productCacheSize, productCacheTTL, metrics)
+	var bus cache.Bus
+	if addr := os.Getenv("REDIS_ADDR"); addr != "" {
+		rc := cache.NewRedis(addr, productCacheTTL)
+		defer rc.Close()
+		backend = cache.NewTiered(cache.NewMemory(nearCacheSize, nearCacheTTL, metrics), rc)
+		bus = rc
+		log.Printf("Using redis product cache at %s", addr)
+	}
+	cs := storer.NewCachedStorer(st, backend, bus, metrics)
+	go cs.Listen(context.Background())
+	server := server.NewServer(cs, serverOptions(cs)...)
+	if path := os.Getenv("EXCHANGE_RATES_FILE"); path != "" {
+		if err := loadExchangeRates(server, path); err != nil {
+			log.Fatalf("error loading exchange rates: %v", err)
+		}
+		log.Printf("Loaded exchange rates from %s", path)
+	}
+	go server.SweepGuestCarts(context.Background(), guestCartSweepInterval)
+	go server.SweepIdempotencyKeys(context.Background(), idempotencyKeySweepInterval)
+	go server.SweepReservations(context.Background(), reservationSweepInterval)
+	h := handler.NewHandler(server, metrics, auth.NewTokenMaker(tokenSecret(), tokenTTL))
 	handler.RegisterRoutes(h)
 	log.Printf("Starting server on :8080")
 	err = handler.Start(":8080")
@@ -26,3 +73,137 @@ func main() {
 		log.Fatalf("error starting server: %v", err)
 	}
 }
+
+// tokenSecret signs login tokens. Replicas must share it, so it comes from
+// TOKEN_SECRET; without it a random secret is used and tokens do not survive
+// a restart.
+func tokenSecret() []byte {
+	if s := os.Getenv("TOKEN_SECRET"); s != "" {
+		return []byte(s)
+	}
+	log.Printf("TOKEN_SECRET is not set, using a random secret")
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+// serverOptions reads the optional server settings from the environment:
+// REVIEW_BLOCKLIST is a comma-separated list of words or phrases that flag a
+// review, REVIEW_REPORT_THRESHOLD the number of reports that flag one,
+// GUEST_CART_TTL how long an untouched guest cart lives, CART_MERGE_RULE
+// how quantities combine when a guest cart is merged at login,
+// IDEMPOTENCY_KEY_TTL how long responses are kept for requests retried with
+// the same Idempotency-Key, RESERVATION_TTL how long checkout holds an
+// order's stock for its payment, PRICES_INCLUDE_TAX whether catalog prices
+// already contain the tax of the store's tax table and BASE_CURRENCY the
+// currency catalog prices are in. Orders are paid through the fake payment
+// gateway, whose test tokens FAKE_PAYMENT_SCENARIOS extends as
+// comma-separated token=scenario pairs. The fake signs its webhooks with
+// FAKE_PAYMENT_WEBHOOK_SECRET and posts them to FAKE_PAYMENT_WEBHOOK_URL
+// when that is set.
+func serverOptions(st storer.Storer) []server.Option {
+	var opts []server.Option
+	scenarios, err := parseFakeScenarios(os.Getenv("FAKE_PAYMENT_SCENARIOS"))
+	if err != nil {
+		log.Fatalf("error parsing FAKE_PAYMENT_SCENARIOS: %v", err)
+	}
+	log.Printf("Using the fake payment gateway, no real money is charged")
+	fake := payments.NewFake(fakeWebhookSecret(), scenarios)
+	if v := os.Getenv("FAKE_PAYMENT_WEBHOOK_URL"); v != "" {
+		fake.DeliverTo(v)
+	}
+	opts = append(opts, server.WithPaymentProvider(fake))
+	if v := os.Getenv("REVIEW_BLOCKLIST"); v != "" {
+		opts = append(opts, server.WithReviewBlocklist(strings.Split(v, ",")))
+	}
+	if v := os.Getenv("REVIEW_REPORT_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			log.Fatalf("error parsing REVIEW_REPORT_THRESHOLD: %v", err)
+		}
+		opts = append(opts, server.WithReviewReportThreshold(n))
+	}
+	if v := os.Getenv("GUEST_CART_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("error parsing GUEST_CART_TTL: %v", err)
+		}
+		opts = append(opts, server.WithGuestCartTTL(ttl))
+	}
+	if v := os.Getenv("CART_MERGE_RULE"); v != "" {
+		rule, err := server.ParseCartMergeRule(v)
+		if err != nil {
+			log.Fatalf("error parsing CART_MERGE_RULE: %v", err)
+		}
+		opts = append(opts, server.WithCartMergeRule(rule))
+	}
+	if v := os.Getenv("IDEMPOTENCY_KEY_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("error parsing IDEMPOTENCY_KEY_TTL: %v", err)
+		}
+		opts = append(opts, server.WithIdempotencyKeyTTL(ttl))
+	}
+	if v := os.Getenv("RESERVATION_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("error parsing RESERVATION_TTL: %v", err)
+		}
+		opts = append(opts, server.WithReservationTTL(ttl))
+	}
+	if v := os.Getenv("PRICES_INCLUDE_TAX"); v != "" {
+		inclusive, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("error parsing PRICES_INCLUDE_TAX: %v", err)
+		}
+		opts = append(opts, server.WithTaxCalculator(server.NewTableTaxCalculator(st, inclusive)))
+	}
+	if v := os.Getenv("BASE_CURRENCY"); v != "" {
+		opts = append(opts, server.WithBaseCurrency(v))
+	}
+	return opts
+}
+
+// loadExchangeRates replaces the exchange rate table with the rates in the
+// JSON file at path, a list of {"currency", "rate"} objects as taken by
+// PUT /exchange-rates.
+func loadExchangeRates(s *server.Server, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var rates []storer.ExchangeRate
+	if err := json.Unmarshal(b, &rates); err != nil {
+		return fmt.Errorf("error decoding %s: %w", path, err)
+	}
+	_, err = s.SetExchangeRates(context.Background(), rates)
+	return err
+}
+
+func fakeWebhookSecret() []byte {
+	if s := os.Getenv("FAKE_PAYMENT_WEBHOOK_SECRET"); s != "" {
+		return []byte(s)
+	}
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+func parseFakeScenarios(v string) (map[string]payments.FakeScenario, error) {
+	scenarios := map[string]payments.FakeScenario{}
+	if v == "" {
+		return scenarios, nil
+	}
+	for _, pair := range strings.Split(v, ",") {
+		token, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a token=scenario pair", pair)
+		}
+		sc, err := payments.ParseFakeScenario(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		scenarios[strings.TrimSpace(token)] = sc
+	}
+	return scenarios, nil
+}
@@ -0,0 +1,6 @@
+-- products.category still holds the category names, so nothing is lost
+ALTER TABLE `products`
+    DROP FOREIGN KEY `products_category_id_fk`,
+    DROP COLUMN `category_id`;
+
+DROP TABLE IF EXISTS `categories`;
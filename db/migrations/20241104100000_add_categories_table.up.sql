@@ -0,0 +1,33 @@
+-- Create the categories table; parent_id makes it a tree
+CREATE TABLE `categories` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `slug` varchar(255) NOT NULL,
+  `parent_id` int,
+  `created_at` datetime,
+  `updated_at` datetime,
+  UNIQUE KEY `categories_slug_uq` (`slug`),
+  CONSTRAINT `categories_parent_id_fk` FOREIGN KEY (`parent_id`) REFERENCES `categories` (`id`)
+);
+
+-- Backfill one category per distinct free-text category. Spellings that only
+-- differ in case, spacing or punctuation ("Shoes", " shoes") share a slug and
+-- become a single category.
+INSERT INTO `categories` (`name`, `slug`, `created_at`)
+SELECT MIN(TRIM(`category`)), `slug`, NOW()
+FROM (
+  SELECT `category`, TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(TRIM(`category`)), '[^a-z0-9]+', '-')) AS `slug`
+  FROM `products`
+) AS `p`
+WHERE `slug` <> ''
+GROUP BY `slug`;
+
+-- Link products to their category
+ALTER TABLE `products`
+    ADD COLUMN `category_id` int,
+    ADD CONSTRAINT `products_category_id_fk` FOREIGN KEY (`category_id`) REFERENCES `categories` (`id`);
+
+UPDATE `products` AS `p`
+JOIN `categories` AS `c`
+  ON `c`.`slug` = TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(TRIM(`p`.`category`)), '[^a-z0-9]+', '-'))
+SET `p`.`category_id` = `c`.`id`, `p`.`category` = `c`.`name`;
@@ -0,0 +1,5 @@
+ALTER TABLE `order_items`
+    DROP FOREIGN KEY `order_items_variant_id_fk`,
+    DROP COLUMN `variant_id`;
+
+DROP TABLE IF EXISTS `product_variants`;
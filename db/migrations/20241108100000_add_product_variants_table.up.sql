@@ -0,0 +1,19 @@
+-- Create the product_variants table; a variant's price overrides the
+-- product's price when set
+CREATE TABLE `product_variants` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `product_id` int NOT NULL,
+  `sku` varchar(64) NOT NULL,
+  `options` json NOT NULL,
+  `price` decimal(10,2),
+  `count_in_stock` int NOT NULL DEFAULT 0,
+  `created_at` datetime,
+  `updated_at` datetime,
+  UNIQUE KEY `product_variants_sku_uq` (`sku`),
+  CONSTRAINT `product_variants_product_id_fk` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
+
+-- Let order items reference the variant that was bought
+ALTER TABLE `order_items`
+    ADD COLUMN `variant_id` int,
+    ADD CONSTRAINT `order_items_variant_id_fk` FOREIGN KEY (`variant_id`) REFERENCES `product_variants` (`id`);
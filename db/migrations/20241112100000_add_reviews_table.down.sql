@@ -0,0 +1,5 @@
+ALTER TABLE `products` MODIFY `rating` int NOT NULL;
+
+DROP TABLE IF EXISTS `reviews`;
+
+ALTER TABLE `users` DROP INDEX `users_email_uq`;
@@ -0,0 +1,25 @@
+-- Emails identify users at login
+ALTER TABLE `users` ADD UNIQUE KEY `users_email_uq` (`email`);
+
+-- Create the reviews table; a user reviews a product at most once
+CREATE TABLE `reviews` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `product_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `stars` tinyint NOT NULL,
+  `title` varchar(255) NOT NULL,
+  `body` text NOT NULL,
+  `verified_purchase` boolean NOT NULL DEFAULT false,
+  `created_at` datetime,
+  `updated_at` datetime,
+  UNIQUE KEY `reviews_user_product_uq` (`user_id`, `product_id`),
+  CONSTRAINT `reviews_product_id_fk` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `reviews_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+-- rating is now the average of the product's reviews, computed by the
+-- server. The client-supplied figures are not backed by any review, so they
+-- are reset.
+ALTER TABLE `products` MODIFY `rating` decimal(3,2) NOT NULL DEFAULT 0;
+ALTER TABLE `products` MODIFY `num_reviews` int NOT NULL DEFAULT 0;
+UPDATE `products` SET `rating` = 0, `num_reviews` = 0;
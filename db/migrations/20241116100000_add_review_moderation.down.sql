@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `review_reports`;
+
+ALTER TABLE `reviews` DROP FOREIGN KEY `reviews_moderated_by_fk`;
+DROP INDEX `reviews_status_idx` ON `reviews`;
+ALTER TABLE `reviews` DROP COLUMN `moderated_at`;
+ALTER TABLE `reviews` DROP COLUMN `moderated_by`;
+ALTER TABLE `reviews` DROP COLUMN `report_count`;
+ALTER TABLE `reviews` DROP COLUMN `status`;
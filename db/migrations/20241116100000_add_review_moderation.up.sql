@@ -0,0 +1,21 @@
+-- Reviews are moderated before they are shown or counted. Existing reviews
+-- were published without moderation, so they start out approved.
+ALTER TABLE `reviews` ADD `status` varchar(16) NOT NULL DEFAULT 'approved' AFTER `verified_purchase`;
+ALTER TABLE `reviews` ALTER `status` SET DEFAULT 'pending';
+ALTER TABLE `reviews` ADD `report_count` int NOT NULL DEFAULT 0 AFTER `status`;
+ALTER TABLE `reviews` ADD `moderated_by` int AFTER `report_count`;
+ALTER TABLE `reviews` ADD `moderated_at` datetime AFTER `moderated_by`;
+ALTER TABLE `reviews` ADD CONSTRAINT `reviews_moderated_by_fk` FOREIGN KEY (`moderated_by`) REFERENCES `users` (`id`) ON DELETE SET NULL;
+CREATE INDEX `reviews_status_idx` ON `reviews` (`status`);
+
+-- A customer reports a review at most once
+CREATE TABLE `review_reports` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `review_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `reason` varchar(255) NOT NULL,
+  `created_at` datetime,
+  UNIQUE KEY `review_reports_review_user_uq` (`review_id`, `user_id`),
+  CONSTRAINT `review_reports_review_id_fk` FOREIGN KEY (`review_id`) REFERENCES `reviews` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `review_reports_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+SET FOREIGN_KEY_CHECKS = 0;
+ALTER TABLE `order_items` MODIFY `id` int NOT NULL;
+ALTER TABLE `orders` MODIFY `id` int NOT NULL;
+SET FOREIGN_KEY_CHECKS = 1;
@@ -0,0 +1,7 @@
+-- Order ids are generated by the database; the initial schema forgot to
+-- make them AUTO_INCREMENT. The foreign key from order_items to orders
+-- would otherwise block changing the column.
+SET FOREIGN_KEY_CHECKS = 0;
+ALTER TABLE `orders` MODIFY `id` int NOT NULL AUTO_INCREMENT;
+ALTER TABLE `order_items` MODIFY `id` int NOT NULL AUTO_INCREMENT;
+SET FOREIGN_KEY_CHECKS = 1;
@@ -0,0 +1,24 @@
+-- Each user has at most one cart
+CREATE TABLE `carts` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  UNIQUE KEY `carts_user_id_uq` (`user_id`),
+  CONSTRAINT `carts_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+-- Cart items hold no prices; they are read from the catalog whenever the
+-- cart is shown or checked out
+CREATE TABLE `cart_items` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `cart_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `variant_id` int,
+  `quantity` int NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `cart_items_cart_id_fk` FOREIGN KEY (`cart_id`) REFERENCES `carts` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `cart_items_product_id_fk` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `cart_items_variant_id_fk` FOREIGN KEY (`variant_id`) REFERENCES `product_variants` (`id`) ON DELETE CASCADE
+);
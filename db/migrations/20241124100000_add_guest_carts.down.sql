@@ -0,0 +1,6 @@
+DELETE FROM `carts` WHERE `user_id` IS NULL;
+DROP INDEX `carts_expires_at_idx` ON `carts`;
+ALTER TABLE `carts` DROP INDEX `carts_guest_token_uq`;
+ALTER TABLE `carts` DROP COLUMN `expires_at`;
+ALTER TABLE `carts` DROP COLUMN `guest_token`;
+ALTER TABLE `carts` MODIFY `user_id` int NOT NULL;
@@ -0,0 +1,7 @@
+-- Guest carts belong to no user; they are found by the hash of the token
+-- handed to the browser and are deleted once they expire
+ALTER TABLE `carts` MODIFY `user_id` int NULL;
+ALTER TABLE `carts` ADD `guest_token` char(64) NULL AFTER `user_id`;
+ALTER TABLE `carts` ADD `expires_at` datetime NULL AFTER `guest_token`;
+ALTER TABLE `carts` ADD UNIQUE KEY `carts_guest_token_uq` (`guest_token`);
+CREATE INDEX `carts_expires_at_idx` ON `carts` (`expires_at`);
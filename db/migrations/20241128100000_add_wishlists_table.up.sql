@@ -0,0 +1,27 @@
+-- A user keeps any number of named wishlists. share_token holds the hash of
+-- the token in the list's public link; NULL when the list is private.
+CREATE TABLE `wishlists` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `share_token` char(64),
+  `created_at` datetime,
+  `updated_at` datetime,
+  UNIQUE KEY `wishlists_share_token_uq` (`share_token`),
+  CONSTRAINT `wishlists_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+-- Price and stock are recorded when an item is saved so that price drops
+-- and restocks can be pointed out later
+CREATE TABLE `wishlist_items` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `wishlist_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `variant_id` int,
+  `saved_price` decimal(10,2) NOT NULL,
+  `saved_in_stock` boolean NOT NULL,
+  `created_at` datetime,
+  CONSTRAINT `wishlist_items_wishlist_id_fk` FOREIGN KEY (`wishlist_id`) REFERENCES `wishlists` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `wishlist_items_product_id_fk` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `wishlist_items_variant_id_fk` FOREIGN KEY (`variant_id`) REFERENCES `product_variants` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,50 @@
+-- Promotions without a code apply automatically to every qualifying order.
+-- scope lists the product and category ids a promotion is limited to; an
+-- empty scope covers the whole catalog.
+CREATE TABLE `promotions` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `code` varchar(64),
+  `name` varchar(255) NOT NULL,
+  `kind` varchar(32) NOT NULL,
+  `value` decimal(10,2) NOT NULL DEFAULT 0,
+  `buy_quantity` int NOT NULL DEFAULT 0,
+  `get_quantity` int NOT NULL DEFAULT 0,
+  `min_order_value` decimal(10,2) NOT NULL DEFAULT 0,
+  `scope` json NOT NULL,
+  `usage_limit` int,
+  `per_user_limit` int,
+  `times_used` int NOT NULL DEFAULT 0,
+  `stackable` boolean NOT NULL DEFAULT false,
+  `active` boolean NOT NULL DEFAULT true,
+  `starts_at` datetime,
+  `ends_at` datetime,
+  `created_at` datetime,
+  `updated_at` datetime,
+  UNIQUE KEY `promotions_code_uq` (`code`)
+);
+
+CREATE TABLE `promotion_redemptions` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `promotion_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `order_id` int NOT NULL,
+  `created_at` datetime,
+  KEY `promotion_redemptions_promotion_user_idx` (`promotion_id`, `user_id`),
+  CONSTRAINT `promotion_redemptions_promotion_id_fk` FOREIGN KEY (`promotion_id`) REFERENCES `promotions` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `promotion_redemptions_order_id_fk` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE
+);
+
+-- The discounts applied to an order, kept even if the promotion is later
+-- changed or deleted
+CREATE TABLE `order_discounts` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `promotion_id` int,
+  `code` varchar(64),
+  `description` varchar(255) NOT NULL,
+  `amount` decimal(10,2) NOT NULL,
+  CONSTRAINT `order_discounts_order_id_fk` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `order_discounts_promotion_id_fk` FOREIGN KEY (`promotion_id`) REFERENCES `promotions` (`id`) ON DELETE SET NULL
+);
+
+ALTER TABLE `orders` ADD `discount_price` decimal(10,2) NOT NULL DEFAULT 0 AFTER `shipping_price`;
@@ -0,0 +1,4 @@
+ALTER TABLE `categories` DROP COLUMN `tax_class`;
+
+DROP TABLE IF EXISTS `order_item_taxes`;
+DROP TABLE IF EXISTS `tax_rates`;
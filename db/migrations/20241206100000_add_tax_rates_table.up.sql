@@ -0,0 +1,36 @@
+-- A rate applies to orders delivered to its country and, when set, region
+-- and postal code prefix, for items of its tax class. Of the rates sharing a
+-- priority the most specific one applies; rates of different priorities
+-- compound, e.g. a state rate and a county rate.
+CREATE TABLE `tax_rates` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `country` char(2) NOT NULL,
+  `region` varchar(64) NOT NULL DEFAULT '',
+  `postal_prefix` varchar(16) NOT NULL DEFAULT '',
+  `tax_class` varchar(64) NOT NULL DEFAULT 'standard',
+  `rate` decimal(7,4) NOT NULL,
+  `priority` int NOT NULL DEFAULT 0,
+  `created_at` datetime,
+  `updated_at` datetime,
+  KEY `tax_rates_country_idx` (`country`)
+);
+
+-- The tax charged on each order item, kept even if the rate is later
+-- changed or deleted. included is set when the item's price already
+-- contained the tax.
+CREATE TABLE `order_item_taxes` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `order_item_id` int NOT NULL,
+  `tax_rate_id` int,
+  `name` varchar(255) NOT NULL,
+  `rate` decimal(7,4) NOT NULL,
+  `amount` decimal(10,2) NOT NULL,
+  `included` boolean NOT NULL DEFAULT false,
+  CONSTRAINT `order_item_taxes_order_id_fk` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `order_item_taxes_order_item_id_fk` FOREIGN KEY (`order_item_id`) REFERENCES `order_items` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `order_item_taxes_tax_rate_id_fk` FOREIGN KEY (`tax_rate_id`) REFERENCES `tax_rates` (`id`) ON DELETE SET NULL
+);
+
+ALTER TABLE `categories` ADD `tax_class` varchar(64) NOT NULL DEFAULT 'standard' AFTER `parent_id`;
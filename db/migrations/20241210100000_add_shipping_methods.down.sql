@@ -0,0 +1,11 @@
+ALTER TABLE `orders` DROP FOREIGN KEY `orders_shipping_method_id_fk`;
+ALTER TABLE `orders` DROP COLUMN `shipping_method`;
+ALTER TABLE `orders` DROP COLUMN `shipping_method_id`;
+
+DROP TABLE IF EXISTS `shipping_methods`;
+DROP TABLE IF EXISTS `shipping_zones`;
+
+ALTER TABLE `products` DROP COLUMN `height`;
+ALTER TABLE `products` DROP COLUMN `width`;
+ALTER TABLE `products` DROP COLUMN `length`;
+ALTER TABLE `products` DROP COLUMN `weight`;
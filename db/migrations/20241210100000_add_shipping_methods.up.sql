@@ -0,0 +1,35 @@
+-- Weight is in kilograms and the dimensions in centimetres.
+ALTER TABLE `products` ADD `weight` decimal(10,3) NOT NULL DEFAULT 0 AFTER `count_in_stock`;
+ALTER TABLE `products` ADD `length` decimal(10,2) NOT NULL DEFAULT 0 AFTER `weight`;
+ALTER TABLE `products` ADD `width` decimal(10,2) NOT NULL DEFAULT 0 AFTER `length`;
+ALTER TABLE `products` ADD `height` decimal(10,2) NOT NULL DEFAULT 0 AFTER `width`;
+
+-- locations lists the countries, optionally narrowed to a region or postal
+-- code prefix, that the zone covers.
+CREATE TABLE `shipping_zones` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `locations` json NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+
+-- price is the cost of flat and free-over-threshold methods; tiers lists
+-- {"from", "price"} steps by weight or by order value for the tiered ones.
+CREATE TABLE `shipping_methods` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `zone_id` int NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `kind` varchar(32) NOT NULL,
+  `price` decimal(10,2) NOT NULL DEFAULT 0,
+  `free_over` decimal(10,2) NOT NULL DEFAULT 0,
+  `tiers` json NOT NULL,
+  `active` boolean NOT NULL DEFAULT true,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `shipping_methods_zone_id_fk` FOREIGN KEY (`zone_id`) REFERENCES `shipping_zones` (`id`) ON DELETE CASCADE
+);
+
+ALTER TABLE `orders` ADD `shipping_method_id` int AFTER `payment_method`;
+ALTER TABLE `orders` ADD `shipping_method` varchar(255) NOT NULL DEFAULT '' AFTER `shipping_method_id`;
+ALTER TABLE `orders` ADD CONSTRAINT `orders_shipping_method_id_fk` FOREIGN KEY (`shipping_method_id`) REFERENCES `shipping_methods` (`id`) ON DELETE SET NULL;
@@ -0,0 +1,36 @@
+CREATE TABLE `addresses` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `line1` varchar(255) NOT NULL,
+  `line2` varchar(255) NOT NULL DEFAULT '',
+  `city` varchar(255) NOT NULL,
+  `region` varchar(64) NOT NULL DEFAULT '',
+  `postal_code` varchar(16) NOT NULL DEFAULT '',
+  `country` char(2) NOT NULL,
+  `phone` varchar(32) NOT NULL DEFAULT '',
+  `is_default` boolean NOT NULL DEFAULT false,
+  `created_at` datetime,
+  `updated_at` datetime,
+  KEY `addresses_user_id_idx` (`user_id`),
+  CONSTRAINT `addresses_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
+
+-- Copies of the addresses an order was placed with, so editing or deleting
+-- an address book entry does not rewrite past orders. kind is 'shipping' or
+-- 'billing'.
+CREATE TABLE `order_addresses` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `kind` varchar(16) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `line1` varchar(255) NOT NULL,
+  `line2` varchar(255) NOT NULL DEFAULT '',
+  `city` varchar(255) NOT NULL,
+  `region` varchar(64) NOT NULL DEFAULT '',
+  `postal_code` varchar(16) NOT NULL DEFAULT '',
+  `country` char(2) NOT NULL,
+  `phone` varchar(32) NOT NULL DEFAULT '',
+  UNIQUE KEY `order_addresses_order_kind_uq` (`order_id`, `kind`),
+  CONSTRAINT `order_addresses_order_id_fk` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,16 @@
+-- Every attempt to pay for an order. provider_ref is the payment's id at the
+-- gateway; status is 'pending', 'authorized', 'captured', 'voided' or
+-- 'failed'.
+CREATE TABLE `payments` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `provider` varchar(64) NOT NULL,
+  `provider_ref` varchar(255) NOT NULL DEFAULT '',
+  `amount` int NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `failure_reason` varchar(255) NOT NULL DEFAULT '',
+  `created_at` datetime,
+  `updated_at` datetime,
+  KEY `payments_order_id_idx` (`order_id`),
+  CONSTRAINT `payments_order_id_fk` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE
+);
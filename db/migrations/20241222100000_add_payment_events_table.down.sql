@@ -0,0 +1,2 @@
+DROP INDEX `payments_provider_ref_idx` ON `payments`;
+DROP TABLE IF EXISTS `payment_events`;
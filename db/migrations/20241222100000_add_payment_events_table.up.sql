@@ -0,0 +1,17 @@
+-- Webhook deliveries from payment providers, stored verbatim so they can be
+-- replayed. An event is handled once: processed_at is set when it has been
+-- applied and error keeps why the last attempt failed.
+CREATE TABLE `payment_events` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `provider` varchar(64) NOT NULL,
+  `event_id` varchar(255) NOT NULL,
+  `type` varchar(64) NOT NULL,
+  `payment_ref` varchar(255) NOT NULL DEFAULT '',
+  `payload` mediumblob NOT NULL,
+  `error` varchar(255) NOT NULL DEFAULT '',
+  `received_at` datetime NOT NULL,
+  `processed_at` datetime,
+  UNIQUE KEY `payment_events_provider_event_id_uq` (`provider`, `event_id`)
+);
+
+CREATE INDEX `payments_provider_ref_idx` ON `payments` (`provider`, `provider_ref`);
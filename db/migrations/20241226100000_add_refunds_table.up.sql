@@ -0,0 +1,30 @@
+-- Money given back on a captured payment. A refund is 'pending' while the
+-- provider handles it and counts against the captured amount unless it
+-- 'failed'. refund_items records the order items it covers.
+CREATE TABLE `refunds` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `payment_id` int NOT NULL,
+  `amount` int NOT NULL,
+  `reason` varchar(255) NOT NULL DEFAULT '',
+  `restock` boolean NOT NULL DEFAULT false,
+  `status` varchar(16) NOT NULL,
+  `provider_ref` varchar(255) NOT NULL DEFAULT '',
+  `failure_reason` varchar(255) NOT NULL DEFAULT '',
+  `created_at` datetime,
+  `updated_at` datetime,
+  KEY `refunds_order_id_idx` (`order_id`),
+  CONSTRAINT `refunds_order_id_fk` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `refunds_payment_id_fk` FOREIGN KEY (`payment_id`) REFERENCES `payments` (`id`)
+);
+
+CREATE TABLE `refund_items` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `refund_id` int NOT NULL,
+  `order_item_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `variant_id` int,
+  `quantity` int NOT NULL,
+  KEY `refund_items_order_item_id_idx` (`order_item_id`),
+  CONSTRAINT `refund_items_refund_id_fk` FOREIGN KEY (`refund_id`) REFERENCES `refunds` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,30 @@
+-- Return merchandise authorizations. A return moves from 'requested' to
+-- 'approved' or 'rejected', then 'received', optionally 'inspected', and
+-- finally 'accepted', when refund_id points at the refund it created, or
+-- 'rejected'.
+CREATE TABLE `returns` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `user_id` int NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `note` text NOT NULL,
+  `admin_note` text NOT NULL,
+  `restock` boolean NOT NULL DEFAULT true,
+  `refund_id` int,
+  `created_at` datetime,
+  `updated_at` datetime,
+  KEY `returns_order_id_idx` (`order_id`),
+  KEY `returns_user_id_idx` (`user_id`),
+  CONSTRAINT `returns_order_id_fk` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `returns_refund_id_fk` FOREIGN KEY (`refund_id`) REFERENCES `refunds` (`id`)
+);
+
+CREATE TABLE `return_items` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `return_id` int NOT NULL,
+  `order_item_id` int NOT NULL,
+  `quantity` int NOT NULL,
+  `reason_code` varchar(32) NOT NULL,
+  KEY `return_items_order_item_id_idx` (`order_item_id`),
+  CONSTRAINT `return_items_return_id_fk` FOREIGN KEY (`return_id`) REFERENCES `returns` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Responses to requests sent with an Idempotency-Key header, per user, so a
+-- retried request gets the original response instead of running again.
+-- status_code is 0 while the first request is still being handled.
+CREATE TABLE `idempotency_keys` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `user_id` int NOT NULL,
+  `idempotency_key` varchar(255) NOT NULL,
+  `request_hash` char(64) NOT NULL,
+  `status_code` int NOT NULL DEFAULT 0,
+  `content_type` varchar(255) NOT NULL DEFAULT '',
+  `response_body` mediumblob,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  UNIQUE KEY `idempotency_keys_user_key_uq` (`user_id`, `idempotency_key`),
+  KEY `idempotency_keys_expires_at_idx` (`expires_at`),
+  CONSTRAINT `idempotency_keys_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE `refunds` MODIFY `amount` int NOT NULL;
+ALTER TABLE `payments` MODIFY `amount` int NOT NULL;
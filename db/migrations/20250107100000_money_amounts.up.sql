@@ -0,0 +1,4 @@
+-- Payment and refund amounts were whole units; store them like every other
+-- price so cents survive the round trip.
+ALTER TABLE `payments` MODIFY `amount` decimal(10,2) NOT NULL;
+ALTER TABLE `refunds` MODIFY `amount` decimal(10,2) NOT NULL;
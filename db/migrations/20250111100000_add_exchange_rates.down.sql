@@ -0,0 +1,4 @@
+ALTER TABLE `payments` DROP COLUMN `currency`;
+ALTER TABLE `orders` DROP COLUMN `exchange_rate`;
+ALTER TABLE `orders` DROP COLUMN `currency`;
+DROP TABLE IF EXISTS `exchange_rates`;
@@ -0,0 +1,13 @@
+-- Exchange rates from the base currency. rate is how many units of currency
+-- one unit of the base currency buys; the table is replaced as a whole.
+CREATE TABLE `exchange_rates` (
+  `currency` char(3) PRIMARY KEY NOT NULL,
+  `rate` decimal(18,8) NOT NULL,
+  `updated_at` datetime NOT NULL
+);
+
+-- An order's amounts are in its currency, converted at the rate of the time
+-- it was placed. Existing orders were in the base currency.
+ALTER TABLE `orders` ADD `currency` char(3) NOT NULL DEFAULT 'USD' AFTER `total_price`;
+ALTER TABLE `orders` ADD `exchange_rate` decimal(18,8) NOT NULL DEFAULT 1 AFTER `currency`;
+ALTER TABLE `payments` ADD `currency` char(3) NOT NULL DEFAULT 'USD' AFTER `amount`;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `price_lists`;
+ALTER TABLE `users` DROP FOREIGN KEY `users_customer_group_id_fk`;
+ALTER TABLE `users` DROP COLUMN `customer_group_id`;
+DROP TABLE IF EXISTS `customer_groups`;
@@ -0,0 +1,26 @@
+-- Customer groups, e.g. wholesale buyers, get the prices of their group's
+-- active price lists instead of catalog prices.
+CREATE TABLE `customer_groups` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  UNIQUE KEY `customer_groups_name_idx` (`name`)
+);
+
+ALTER TABLE `users` ADD `customer_group_id` int;
+ALTER TABLE `users` ADD CONSTRAINT `users_customer_group_id_fk` FOREIGN KEY (`customer_group_id`) REFERENCES `customer_groups` (`id`) ON DELETE SET NULL;
+
+-- entries lists {"product_id", "category_id", "min_quantity", "price",
+-- "percent_off"} overrides; entries of one product or category with
+-- different min_quantity are quantity tiers.
+CREATE TABLE `price_lists` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `customer_group_id` int NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `active` boolean NOT NULL DEFAULT true,
+  `entries` json NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `price_lists_customer_group_id_fk` FOREIGN KEY (`customer_group_id`) REFERENCES `customer_groups` (`id`) ON DELETE CASCADE
+);
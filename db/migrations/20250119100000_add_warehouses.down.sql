@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `order_item_allocations`;
+DROP TABLE IF EXISTS `stock_adjustments`;
+DROP TABLE IF EXISTS `stock_levels`;
+DROP TABLE IF EXISTS `warehouses`;
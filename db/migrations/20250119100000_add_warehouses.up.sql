@@ -0,0 +1,66 @@
+-- Stock is held at warehouses. Lower priority numbers fulfil orders first.
+CREATE TABLE `warehouses` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `code` varchar(32) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `country` char(2) NOT NULL,
+  `region` varchar(255) NOT NULL DEFAULT '',
+  `priority` int NOT NULL DEFAULT 0,
+  `active` boolean NOT NULL DEFAULT true,
+  `created_at` datetime,
+  `updated_at` datetime,
+  UNIQUE KEY `warehouses_code_uq` (`code`)
+);
+
+-- stock_levels holds what each warehouse has of a product, or of one of its
+-- variants. products.count_in_stock and product_variants.count_in_stock
+-- stay the sum over all warehouses.
+CREATE TABLE `stock_levels` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `warehouse_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `variant_id` int,
+  `variant_key` int AS (COALESCE(`variant_id`, 0)) STORED,
+  `quantity` int NOT NULL DEFAULT 0,
+  `updated_at` datetime,
+  UNIQUE KEY `stock_levels_item_uq` (`warehouse_id`, `product_id`, `variant_key`),
+  KEY `stock_levels_product_id_idx` (`product_id`),
+  CONSTRAINT `stock_levels_warehouse_id_fk` FOREIGN KEY (`warehouse_id`) REFERENCES `warehouses` (`id`),
+  CONSTRAINT `stock_levels_product_id_fk` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `stock_levels_variant_id_fk` FOREIGN KEY (`variant_id`) REFERENCES `product_variants` (`id`) ON DELETE CASCADE
+);
+
+-- reason is one of received, damaged, lost, found, correction or transfer;
+-- a transfer is recorded as a pair of adjustments.
+CREATE TABLE `stock_adjustments` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `warehouse_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `variant_id` int,
+  `quantity` int NOT NULL,
+  `reason` varchar(32) NOT NULL,
+  `note` varchar(1024) NOT NULL DEFAULT '',
+  `user_id` int,
+  `created_at` datetime,
+  KEY `stock_adjustments_product_id_idx` (`product_id`),
+  CONSTRAINT `stock_adjustments_warehouse_id_fk` FOREIGN KEY (`warehouse_id`) REFERENCES `warehouses` (`id`)
+);
+
+-- order_item_allocations records which warehouses fulfil each order item.
+CREATE TABLE `order_item_allocations` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `order_id` int NOT NULL,
+  `order_item_id` int NOT NULL,
+  `warehouse_id` int NOT NULL,
+  `quantity` int NOT NULL,
+  KEY `order_item_allocations_order_id_idx` (`order_id`),
+  KEY `order_item_allocations_order_item_id_idx` (`order_item_id`),
+  CONSTRAINT `order_item_allocations_warehouse_id_fk` FOREIGN KEY (`warehouse_id`) REFERENCES `warehouses` (`id`)
+);
+
+-- existing stock moves into a default warehouse
+INSERT INTO `warehouses` (`code`, `name`, `country`, `created_at`) VALUES ('MAIN', 'Main warehouse', 'US', NOW());
+INSERT INTO `stock_levels` (`warehouse_id`, `product_id`, `quantity`, `updated_at`)
+  SELECT w.`id`, p.`id`, p.`count_in_stock`, NOW() FROM `products` p JOIN `warehouses` w ON w.`code` = 'MAIN' WHERE p.`count_in_stock` > 0;
+INSERT INTO `stock_levels` (`warehouse_id`, `product_id`, `variant_id`, `quantity`, `updated_at`)
+  SELECT w.`id`, v.`product_id`, v.`id`, v.`count_in_stock`, NOW() FROM `product_variants` v JOIN `warehouses` w ON w.`code` = 'MAIN' WHERE v.`count_in_stock` > 0;
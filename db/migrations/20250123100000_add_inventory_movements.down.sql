@@ -0,0 +1,19 @@
+CREATE TABLE `stock_adjustments` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `warehouse_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `variant_id` int,
+  `quantity` int NOT NULL,
+  `reason` varchar(32) NOT NULL,
+  `note` varchar(1024) NOT NULL DEFAULT '',
+  `user_id` int,
+  `created_at` datetime,
+  KEY `stock_adjustments_product_id_idx` (`product_id`),
+  CONSTRAINT `stock_adjustments_warehouse_id_fk` FOREIGN KEY (`warehouse_id`) REFERENCES `warehouses` (`id`)
+);
+
+INSERT INTO `stock_adjustments` (`id`, `warehouse_id`, `product_id`, `variant_id`, `quantity`, `reason`, `note`, `user_id`, `created_at`)
+  SELECT `id`, `warehouse_id`, `product_id`, `variant_id`, `quantity`, IF(`kind` = 'transfer', 'transfer', `reason`), `note`, `user_id`, `created_at`
+  FROM `inventory_movements` WHERE `kind` IN ('adjustment', 'transfer');
+
+DROP TABLE IF EXISTS `inventory_movements`;
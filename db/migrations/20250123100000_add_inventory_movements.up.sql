@@ -0,0 +1,35 @@
+-- inventory_movements is the append-only ledger of every stock change. kind
+-- is one of order, cancellation, return, adjustment, transfer or import;
+-- reason says why an adjustment was made. The quantities of a warehouse's
+-- movements add up to its stock level.
+CREATE TABLE `inventory_movements` (
+  `id` int PRIMARY KEY NOT NULL AUTO_INCREMENT,
+  `warehouse_id` int NOT NULL,
+  `product_id` int NOT NULL,
+  `variant_id` int,
+  `quantity` int NOT NULL,
+  `kind` varchar(32) NOT NULL,
+  `reason` varchar(32) NOT NULL DEFAULT '',
+  `note` varchar(1024) NOT NULL DEFAULT '',
+  `order_id` int,
+  `user_id` int,
+  `created_at` datetime,
+  KEY `inventory_movements_product_id_idx` (`product_id`),
+  KEY `inventory_movements_order_id_idx` (`order_id`),
+  CONSTRAINT `inventory_movements_warehouse_id_fk` FOREIGN KEY (`warehouse_id`) REFERENCES `warehouses` (`id`)
+);
+
+INSERT INTO `inventory_movements` (`id`, `warehouse_id`, `product_id`, `variant_id`, `quantity`, `kind`, `reason`, `note`, `user_id`, `created_at`)
+  SELECT `id`, `warehouse_id`, `product_id`, `variant_id`, `quantity`,
+    IF(`reason` = 'transfer', 'transfer', 'adjustment'), IF(`reason` = 'transfer', '', `reason`), `note`, `user_id`, `created_at`
+  FROM `stock_adjustments`;
+
+-- stock that moved before the ledger is opened with an import
+INSERT INTO `inventory_movements` (`warehouse_id`, `product_id`, `variant_id`, `quantity`, `kind`, `note`, `created_at`)
+  SELECT s.`warehouse_id`, s.`product_id`, s.`variant_id`, s.`quantity` - COALESCE(SUM(m.`quantity`), 0), 'import', 'opening balance', NOW()
+  FROM `stock_levels` s
+  LEFT JOIN `inventory_movements` m ON m.`warehouse_id` = s.`warehouse_id` AND m.`product_id` = s.`product_id` AND m.`variant_id` <=> s.`variant_id`
+  GROUP BY s.`id`, s.`warehouse_id`, s.`product_id`, s.`variant_id`, s.`quantity`
+  HAVING s.`quantity` - COALESCE(SUM(m.`quantity`), 0) <> 0;
+
+DROP TABLE `stock_adjustments`;
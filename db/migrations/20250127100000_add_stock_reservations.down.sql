@@ -0,0 +1,5 @@
+ALTER TABLE `order_item_allocations`
+  DROP KEY `order_item_allocations_status_expires_at_idx`,
+  DROP COLUMN `expires_at`,
+  DROP COLUMN `status`;
+ALTER TABLE `stock_levels` DROP COLUMN `reserved`;
@@ -0,0 +1,13 @@
+-- Checkout reserves an order's units until it is paid. reserved counts the
+-- units of a stock level held for unpaid orders; quantity - reserved is
+-- what can still be allocated.
+ALTER TABLE `stock_levels` ADD COLUMN `reserved` int NOT NULL DEFAULT 0 AFTER `quantity`;
+
+-- An allocation is reserved until expires_at, taken once the order is paid
+-- or released when the reservation expires, the payment fails or the order
+-- is cancelled. Allocations made before reservations took their units at
+-- once.
+ALTER TABLE `order_item_allocations`
+  ADD COLUMN `status` varchar(16) NOT NULL DEFAULT 'taken',
+  ADD COLUMN `expires_at` datetime,
+  ADD KEY `order_item_allocations_status_expires_at_idx` (`status`, `expires_at`);
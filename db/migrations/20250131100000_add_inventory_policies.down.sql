@@ -0,0 +1,5 @@
+ALTER TABLE `order_items` DROP COLUMN `backordered`;
+ALTER TABLE `products`
+  DROP COLUMN `release_date`,
+  DROP COLUMN `backorder_limit`,
+  DROP COLUMN `inventory_policy`;
@@ -0,0 +1,11 @@
+-- A product's inventory policy says whether it can be ordered once it is out
+-- of stock: deny, backorder up to backorder_limit units (no limit when
+-- NULL), or preorder until release_date.
+ALTER TABLE `products`
+  ADD COLUMN `inventory_policy` varchar(16) NOT NULL DEFAULT 'deny' AFTER `height`,
+  ADD COLUMN `backorder_limit` int AFTER `inventory_policy`,
+  ADD COLUMN `release_date` datetime AFTER `backorder_limit`;
+
+-- backordered counts the units of an item that were out of stock when it
+-- was ordered and have no warehouse allocation.
+ALTER TABLE `order_items` ADD COLUMN `backordered` int NOT NULL DEFAULT 0 AFTER `order_id`;
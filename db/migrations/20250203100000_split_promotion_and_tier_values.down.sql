@@ -0,0 +1,7 @@
+UPDATE `shipping_methods` SET `tiers`=CAST(REPLACE(CAST(`tiers` AS CHAR), '"from_value":', '"from":') AS JSON) WHERE `kind`='price_tiered';
+UPDATE `shipping_methods` SET `tiers`=CAST(REPLACE(CAST(`tiers` AS CHAR), '"from_weight":', '"from":') AS JSON) WHERE `kind`='weight';
+
+ALTER TABLE `promotions` ADD COLUMN `value` decimal(10,2) NOT NULL DEFAULT 0 AFTER `kind`;
+UPDATE `promotions` SET `value`=`percent` WHERE `kind`='percentage';
+UPDATE `promotions` SET `value`=`amount` WHERE `kind`='fixed_amount';
+ALTER TABLE `promotions` DROP COLUMN `amount`, DROP COLUMN `percent`;
@@ -0,0 +1,13 @@
+-- A promotion's value was a percentage or an amount of money depending on
+-- its kind; keep them apart so that money is always a decimal(10,2) amount.
+ALTER TABLE `promotions`
+  ADD COLUMN `percent` decimal(5,2) NOT NULL DEFAULT 0 AFTER `kind`,
+  ADD COLUMN `amount` decimal(10,2) NOT NULL DEFAULT 0 AFTER `percent`;
+UPDATE `promotions` SET `percent`=`value` WHERE `kind`='percentage';
+UPDATE `promotions` SET `amount`=`value` WHERE `kind`='fixed_amount';
+ALTER TABLE `promotions` DROP COLUMN `value`;
+
+-- Shipping tiers start at from_weight kilograms for weight methods and at
+-- an order value of from_value for price-tiered ones.
+UPDATE `shipping_methods` SET `tiers`=CAST(REPLACE(CAST(`tiers` AS CHAR), '"from":', '"from_weight":') AS JSON) WHERE `kind`='weight';
+UPDATE `shipping_methods` SET `tiers`=CAST(REPLACE(CAST(`tiers` AS CHAR), '"from":', '"from_value":') AS JSON) WHERE `kind`='price_tiered';
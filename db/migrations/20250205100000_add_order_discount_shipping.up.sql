@@ -0,0 +1,4 @@
+-- Free-shipping discounts come off the shipping price, so refunds and taxes
+-- must leave them out of what the items were discounted by.
+ALTER TABLE `order_discounts` ADD COLUMN `shipping` boolean NOT NULL DEFAULT false AFTER `amount`;
+UPDATE `order_discounts` `od` JOIN `promotions` `p` ON `p`.`id`=`od`.`promotion_id` SET `od`.`shipping`=true WHERE `p`.`kind`='free_shipping';
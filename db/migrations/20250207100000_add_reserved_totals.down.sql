@@ -0,0 +1,2 @@
+ALTER TABLE `product_variants` DROP COLUMN `reserved`;
+ALTER TABLE `products` DROP COLUMN `reserved`;
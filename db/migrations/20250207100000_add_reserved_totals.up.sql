@@ -0,0 +1,7 @@
+-- Like count_in_stock, the units held for unpaid orders are kept as a total
+-- on the product or variant, so that what can still be ordered is known
+-- without summing stock_levels.
+ALTER TABLE `products` ADD COLUMN `reserved` int NOT NULL DEFAULT 0 AFTER `count_in_stock`;
+ALTER TABLE `product_variants` ADD COLUMN `reserved` int NOT NULL DEFAULT 0 AFTER `count_in_stock`;
+UPDATE `products` p SET `reserved`=(SELECT COALESCE(SUM(s.`reserved`), 0) FROM `stock_levels` s WHERE s.`product_id`=p.`id` AND s.`variant_id` IS NULL);
+UPDATE `product_variants` v SET `reserved`=(SELECT COALESCE(SUM(s.`reserved`), 0) FROM `stock_levels` s WHERE s.`variant_id`=v.`id`);
@@ -0,0 +1,108 @@
+// Package auth hashes user passwords and issues the bearer tokens clients
+// send on authenticated requests.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return string(hash), nil
+}
+
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// Claims identify the user a token was issued to.
+type Claims struct {
+	UserID    int64 `json:"uid"`
+	IsAdmin   bool  `json:"adm"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// TokenMaker issues and verifies stateless tokens of the form
+// base64(claims).base64(HMAC-SHA256(claims)).
+type TokenMaker struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewTokenMaker(secret []byte, ttl time.Duration) *TokenMaker {
+	return &TokenMaker{secret: secret, ttl: ttl, now: time.Now}
+}
+
+func (tm *TokenMaker) NewToken(userID int64, isAdmin bool) (string, error) {
+	payload, err := json.Marshal(Claims{UserID: userID, IsAdmin: isAdmin, ExpiresAt: tm.now().Add(tm.ttl).Unix()})
+	if err != nil {
+		return "", fmt.Errorf("error encoding claims: %w", err)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(tm.sign(payload)), nil
+}
+
+func (tm *TokenMaker) Verify(token string) (*Claims, error) {
+	enc := base64.RawURLEncoding
+	payloadPart, sigPart, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	payload, err := enc.DecodeString(payloadPart)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	sig, err := enc.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, tm.sign(payload)) {
+		return nil, ErrInvalidToken
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if tm.now().Unix() >= c.ExpiresAt {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	return &c, nil
+}
+
+func (tm *TokenMaker) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, tm.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// NewOpaqueToken returns a random token to hand to a client together with
+// the hash to store in its place, so that a leaked database does not leak
+// usable tokens.
+func NewOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("error generating token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashOpaqueToken(token), nil
+}
+
+// HashOpaqueToken returns the stored form of a token from NewOpaqueToken.
+func HashOpaqueToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
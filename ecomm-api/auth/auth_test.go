@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenMaker(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *TokenMaker)
+	}{
+		{
+			name: "round trip",
+			test: func(t *testing.T, tm *TokenMaker) {
+				tok, err := tm.NewToken(42, true)
+				require.NoError(t, err)
+				c, err := tm.Verify(tok)
+				require.NoError(t, err)
+				require.Equal(t, int64(42), c.UserID)
+				require.True(t, c.IsAdmin)
+			},
+		},
+		{
+			name: "tampered claims",
+			test: func(t *testing.T, tm *TokenMaker) {
+				tok, err := tm.NewToken(42, false)
+				require.NoError(t, err)
+				other, err := tm.NewToken(1, true)
+				require.NoError(t, err)
+				// admin claims with the signature of a non-admin token
+				forged := strings.Split(other, ".")[0] + "." + strings.Split(tok, ".")[1]
+				_, err = tm.Verify(forged)
+				require.True(t, errors.Is(err, ErrInvalidToken))
+			},
+		},
+		{
+			name: "other secret",
+			test: func(t *testing.T, tm *TokenMaker) {
+				tok, err := NewTokenMaker([]byte("other"), time.Hour).NewToken(42, false)
+				require.NoError(t, err)
+				_, err = tm.Verify(tok)
+				require.True(t, errors.Is(err, ErrInvalidToken))
+			},
+		},
+		{
+			name: "expired",
+			test: func(t *testing.T, tm *TokenMaker) {
+				tok, err := tm.NewToken(42, false)
+				require.NoError(t, err)
+				tm.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+				_, err = tm.Verify(tok)
+				require.True(t, errors.Is(err, ErrInvalidToken))
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, NewTokenMaker([]byte("secret"), time.Hour))
+		})
+	}
+}
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("hunter2")
+	require.NoError(t, err)
+	require.True(t, CheckPassword(hash, "hunter2"))
+	require.False(t, CheckPassword(hash, "hunter3"))
+}
+
+func TestOpaqueToken(t *testing.T) {
+	tok, hash, err := NewOpaqueToken()
+	require.NoError(t, err)
+	require.NotEqual(t, tok, hash)
+	require.Equal(t, hash, HashOpaqueToken(tok))
+	other, _, err := NewOpaqueToken()
+	require.NoError(t, err)
+	require.NotEqual(t, tok, other)
+}
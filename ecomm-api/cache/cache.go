@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnavailable is returned by remote backends while they cannot be reached.
+// Callers are expected to fall back to the source of truth.
+var ErrUnavailable = errors.New("cache unavailable")
+
+// Backend is a byte-oriented key/value cache. Entries expire after a
+// backend-specific TTL. Implementations must be safe for concurrent use.
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, keys ...string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Bus carries invalidation messages between API replicas so that entries
+// held in process memory on other nodes are dropped when the data changes.
+type Bus interface {
+	Publish(ctx context.Context, channel string, msg []byte) error
+	// Subscribe calls fn for every message published on channel until ctx
+	// is cancelled.
+	Subscribe(ctx context.Context, channel string, fn func(msg []byte)) error
+}
+
+var (
+	_ Backend = (*Memory)(nil)
+	_ Backend = (*Tiered)(nil)
+	_ Backend = (*Redis)(nil)
+	_ Bus     = (*Redis)(nil)
+)
+
+// Tiered keeps a small per-process cache in front of a shared one. Reads
+// fill the near tier from the far tier; writes and deletes go to both.
+type Tiered struct {
+	near Backend
+	far  Backend
+}
+
+func NewTiered(near, far Backend) *Tiered {
+	return &Tiered{near: near, far: far}
+}
+
+// Near returns the per-process tier.
+func (t *Tiered) Near() Backend {
+	return t.near
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if v, ok, err := t.near.Get(ctx, key); err == nil && ok {
+		return v, true, nil
+	}
+	v, ok, err := t.far.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return v, true, t.near.Set(ctx, key, v)
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte) error {
+	if err := t.near.Set(ctx, key, value); err != nil {
+		return err
+	}
+	return t.far.Set(ctx, key, value)
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	return errors.Join(t.near.Delete(ctx, keys...), t.far.Delete(ctx, keys...))
+}
+
+func (t *Tiered) DeletePrefix(ctx context.Context, prefix string) error {
+	return errors.Join(t.near.DeletePrefix(ctx, prefix), t.far.DeletePrefix(ctx, prefix))
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process LRU cache with a fixed time-to-live per entry.
+// Values are stored as encoded bytes so callers never share mutable state
+// with the cache.
+type Memory struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	ll         *list.List
+	items      map[string]*list.Element
+	metrics    *Metrics
+	now        func() time.Time
+}
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory returns a cache holding at most maxEntries values, each of which
+// expires ttl after it was stored.
+func NewMemory(maxEntries int, ttl time.Duration, metrics *Metrics) *Memory {
+	if metrics == nil {
+		metrics = &Metrics{}
+	}
+	return &Memory{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		metrics:    metrics,
+		now:        time.Now,
+	}
+}
+
+func (m *Memory) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*entry)
+	if !m.now().Before(e.expiresAt) {
+		m.removeElement(el)
+		m.metrics.Expirations.Add(1)
+		return nil, false, nil
+	}
+	m.ll.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expiresAt := m.now().Add(m.ttl)
+	if el, ok := m.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		m.ll.MoveToFront(el)
+		return nil
+	}
+	m.items[key] = m.ll.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for m.maxEntries > 0 && m.ll.Len() > m.maxEntries {
+		m.removeElement(m.ll.Back())
+		m.metrics.Evictions.Add(1)
+	}
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		if el, ok := m.items[k]; ok {
+			m.removeElement(el)
+		}
+	}
+	return nil
+}
+
+// DeletePrefix removes every entry whose key starts with prefix.
+func (m *Memory) DeletePrefix(_ context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, el := range m.items {
+		if strings.HasPrefix(k, prefix) {
+			m.removeElement(el)
+		}
+	}
+	return nil
+}
+
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
+
+func (m *Memory) removeElement(el *list.Element) {
+	m.ll.Remove(el)
+	delete(m.items, el.Value.(*entry).key)
+}
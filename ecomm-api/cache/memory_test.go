@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	tcs := []struct {
+		name string
+		test func(*testing.T, *Memory, *Metrics, *time.Time)
+	}{
+		{
+			name: "get returns what was set",
+			test: func(t *testing.T, m *Memory, _ *Metrics, _ *time.Time) {
+				m.Set(ctx, "a", []byte("1"))
+				v, ok, _ := m.Get(ctx, "a")
+				require.True(t, ok)
+				require.Equal(t, []byte("1"), v)
+				_, ok, _ = m.Get(ctx, "b")
+				require.False(t, ok)
+			},
+		},
+		{
+			name: "entries expire after the ttl",
+			test: func(t *testing.T, m *Memory, metrics *Metrics, now *time.Time) {
+				m.Set(ctx, "a", []byte("1"))
+				*now = now.Add(time.Minute)
+				_, ok, _ := m.Get(ctx, "a")
+				require.False(t, ok)
+				require.Equal(t, int64(1), metrics.Expirations.Load())
+				require.Equal(t, 0, m.Len())
+			},
+		},
+		{
+			name: "least recently used entry is evicted",
+			test: func(t *testing.T, m *Memory, metrics *Metrics, _ *time.Time) {
+				m.Set(ctx, "a", []byte("1"))
+				m.Set(ctx, "b", []byte("2"))
+				m.Get(ctx, "a")
+				m.Set(ctx, "c", []byte("3"))
+				_, ok, _ := m.Get(ctx, "b")
+				require.False(t, ok)
+				_, ok, _ = m.Get(ctx, "a")
+				require.True(t, ok)
+				require.Equal(t, int64(1), metrics.Evictions.Load())
+			},
+		},
+		{
+			name: "delete by key and prefix",
+			test: func(t *testing.T, m *Memory, _ *Metrics, _ *time.Time) {
+				m.Set(ctx, "product:1", []byte("1"))
+				m.Set(ctx, "products:a", []byte("2"))
+				m.DeletePrefix(ctx, "products:")
+				require.Equal(t, 1, m.Len())
+				m.Delete(ctx, "product:1")
+				require.Equal(t, 0, m.Len())
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := &Metrics{}
+			now := time.Now()
+			m := NewMemory(2, time.Minute, metrics)
+			m.now = func() time.Time { return now }
+			tc.test(t, m, metrics, &now)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Metrics counts cache activity. The zero value is ready to use and safe for
+// concurrent updates.
+type Metrics struct {
+	Hits        atomic.Int64
+	Misses      atomic.Int64
+	Evictions   atomic.Int64
+	Expirations atomic.Int64
+	// Shared counts misses that were served by another in-flight load of
+	// the same key instead of hitting the database again.
+	Shared atomic.Int64
+	// Errors counts backend failures; the request was served from the
+	// database instead.
+	Errors atomic.Int64
+}
+
+// WritePrometheus writes the counters in the Prometheus text exposition
+// format, labelled with the cache name.
+func (m *Metrics) WritePrometheus(w io.Writer, name string) {
+	counters := []struct {
+		metric string
+		help   string
+		value  int64
+	}{
+		{"ecomm_cache_hits_total", "Cache lookups served from the cache.", m.Hits.Load()},
+		{"ecomm_cache_misses_total", "Cache lookups that fell through to the database.", m.Misses.Load()},
+		{"ecomm_cache_shared_loads_total", "Misses served by a concurrent load of the same key.", m.Shared.Load()},
+		{"ecomm_cache_evictions_total", "Entries evicted to respect the size bound.", m.Evictions.Load()},
+		{"ecomm_cache_expirations_total", "Entries dropped because their TTL elapsed.", m.Expirations.Load()},
+		{"ecomm_cache_errors_total", "Cache backend failures that fell back to the database.", m.Errors.Load()},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s{cache=%q} %d\n", c.metric, c.help, c.metric, c.metric, name, c.value)
+	}
+}
@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redisPoolSize   = 16
+	redisTimeout    = 250 * time.Millisecond
+	redisRetryAfter = 5 * time.Second
+	redisScanCount  = "500"
+)
+
+// Redis is a Backend and Bus speaking the Redis protocol (RESP2), so it works
+// against Redis, Valkey, KeyDB and similar servers. After a connection
+// failure it reports ErrUnavailable without dialling again for a few
+// seconds, so that an outage costs the caller one timeout rather than one per
+// request.
+type Redis struct {
+	addr    string
+	ttl     time.Duration
+	timeout time.Duration
+	pool    chan *respConn
+	now     func() time.Time
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func NewRedis(addr string, ttl time.Duration) *Redis {
+	return &Redis{
+		addr:    addr,
+		ttl:     ttl,
+		timeout: redisTimeout,
+		pool:    make(chan *respConn, redisPoolSize),
+		now:     time.Now,
+	}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("unexpected GET reply %T", reply)
+	}
+	return b, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte) error {
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(r.ttl.Milliseconds(), 10))
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	_, err := r.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// DeletePrefix walks the keyspace with SCAN and deletes matching keys batch
+// by batch, so it never blocks the server the way KEYS would.
+func (r *Redis) DeletePrefix(ctx context.Context, prefix string) error {
+	cursor := "0"
+	for {
+		reply, err := r.do(ctx, "SCAN", cursor, "MATCH", globEscape(prefix)+"*", "COUNT", redisScanCount)
+		if err != nil {
+			return err
+		}
+		arr, ok := reply.([]interface{})
+		if !ok || len(arr) != 2 {
+			return fmt.Errorf("unexpected SCAN reply %v", reply)
+		}
+		next, _ := arr[0].([]byte)
+		keys, _ := arr[1].([]interface{})
+		batch := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if b, ok := k.([]byte); ok {
+				batch = append(batch, string(b))
+			}
+		}
+		if err := r.Delete(ctx, batch...); err != nil {
+			return err
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return nil
+		}
+	}
+}
+
+func (r *Redis) Publish(ctx context.Context, channel string, msg []byte) error {
+	_, err := r.do(ctx, "PUBLISH", channel, string(msg))
+	return err
+}
+
+// Subscribe holds a dedicated connection subscribed to channel, reconnecting
+// after failures, until ctx is cancelled.
+func (r *Redis) Subscribe(ctx context.Context, channel string, fn func(msg []byte)) error {
+	for {
+		err := r.subscribeOnce(ctx, channel, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("cache: subscription to %s lost, retrying in %s: %v", channel, redisRetryAfter, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(redisRetryAfter):
+		}
+	}
+}
+
+func (r *Redis) subscribeOnce(ctx context.Context, channel string, fn func(msg []byte)) error {
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return err
+	}
+	c := newRespConn(nc)
+	defer c.Close()
+	// a blocked read only returns once the connection is closed
+	stop := context.AfterFunc(ctx, func() { c.Close() })
+	defer stop()
+
+	nc.SetDeadline(time.Now().Add(r.timeout))
+	if _, err := c.do("SUBSCRIBE", channel); err != nil {
+		return err
+	}
+	nc.SetDeadline(time.Time{})
+	for {
+		reply, err := c.read()
+		if err != nil {
+			return err
+		}
+		arr, ok := reply.([]interface{})
+		if !ok || len(arr) != 3 {
+			continue
+		}
+		if kind, _ := arr[0].([]byte); string(kind) != "message" {
+			continue
+		}
+		if msg, ok := arr[2].([]byte); ok {
+			fn(msg)
+		}
+	}
+}
+
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	if r.isDown() {
+		return nil, ErrUnavailable
+	}
+	c, err := r.conn(ctx)
+	if err != nil {
+		r.markDown()
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	deadline := time.Now().Add(r.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.nc.SetDeadline(deadline)
+	reply, err := c.do(args...)
+	var rerr redisError
+	if err != nil && !errors.As(err, &rerr) {
+		c.Close()
+		r.markDown()
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	r.release(c)
+	return reply, err
+}
+
+func (r *Redis) conn(ctx context.Context) (*respConn, error) {
+	select {
+	case c := <-r.pool:
+		return c, nil
+	default:
+	}
+	d := net.Dialer{Timeout: r.timeout}
+	nc, err := d.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, err
+	}
+	return newRespConn(nc), nil
+}
+
+func (r *Redis) release(c *respConn) {
+	select {
+	case r.pool <- c:
+	default:
+		c.Close()
+	}
+}
+
+func (r *Redis) isDown() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now().Before(r.downUntil)
+}
+
+func (r *Redis) markDown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downUntil = r.now().Add(redisRetryAfter)
+}
+
+// Close drops every pooled connection.
+func (r *Redis) Close() error {
+	for {
+		select {
+		case c := <-r.pool:
+			c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+type respConn struct {
+	nc net.Conn
+	br *bufio.Reader
+	bw *bufio.Writer
+}
+
+func newRespConn(nc net.Conn) *respConn {
+	return &respConn{nc: nc, br: bufio.NewReader(nc), bw: bufio.NewWriter(nc)}
+}
+
+func (c *respConn) Close() error {
+	return c.nc.Close()
+}
+
+func (c *respConn) do(args ...string) (interface{}, error) {
+	fmt.Fprintf(c.bw, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(c.bw, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if err := c.bw.Flush(); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read decodes one RESP2 reply. Bulk strings come back as []byte, nil bulk
+// strings and arrays as nil, and server errors as redisError.
+func (c *respConn) read() (interface{}, error) {
+	line, err := c.br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("malformed reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.br, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		arr := make([]interface{}, n)
+		for i := range arr {
+			if arr[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", line[0])
+}
+
+func globEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/cache"
+	"github.com/m21power/ecomm/ecomm-api/cache/resptest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	tcs := []struct {
+		name string
+		test func(*testing.T, *resptest.Server, *cache.Redis)
+	}{
+		{
+			name: "set get and delete",
+			test: func(t *testing.T, srv *resptest.Server, rc *cache.Redis) {
+				require.NoError(t, rc.Set(ctx, "product:1", []byte(`{"id":1}`)))
+				v, ok, err := rc.Get(ctx, "product:1")
+				require.NoError(t, err)
+				require.True(t, ok)
+				require.Equal(t, `{"id":1}`, string(v))
+
+				require.NoError(t, rc.Delete(ctx, "product:1"))
+				_, ok, err = rc.Get(ctx, "product:1")
+				require.NoError(t, err)
+				require.False(t, ok)
+			},
+		},
+		{
+			name: "delete by prefix",
+			test: func(t *testing.T, srv *resptest.Server, rc *cache.Redis) {
+				require.NoError(t, rc.Set(ctx, "product:1", []byte("a")))
+				require.NoError(t, rc.Set(ctx, "products:x", []byte("b")))
+				require.NoError(t, rc.Set(ctx, "products:y", []byte("c")))
+				require.NoError(t, rc.DeletePrefix(ctx, "products:"))
+				require.Equal(t, []string{"product:1"}, srv.Keys())
+			},
+		},
+		{
+			name: "publish reaches subscribers",
+			test: func(t *testing.T, srv *resptest.Server, rc *cache.Redis) {
+				sctx, cancel := context.WithCancel(ctx)
+				defer cancel()
+				got := make(chan string, 1)
+				go rc.Subscribe(sctx, "ch", func(msg []byte) { got <- string(msg) })
+				require.Eventually(t, func() bool {
+					return rc.Publish(ctx, "ch", []byte("hello")) == nil && len(got) > 0
+				}, time.Second, 10*time.Millisecond)
+				require.Equal(t, "hello", <-got)
+			},
+		},
+		{
+			name: "unavailable server reports ErrUnavailable",
+			test: func(t *testing.T, srv *resptest.Server, rc *cache.Redis) {
+				require.NoError(t, rc.Set(ctx, "product:1", []byte("a")))
+				srv.Close()
+				_, _, err := rc.Get(ctx, "product:1")
+				require.True(t, errors.Is(err, cache.ErrUnavailable))
+				// later calls fail fast without dialling
+				err = rc.Set(ctx, "product:1", []byte("a"))
+				require.True(t, errors.Is(err, cache.ErrUnavailable))
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := resptest.NewServer(t)
+			rc := cache.NewRedis(srv.Addr(), time.Minute)
+			defer rc.Close()
+			tc.test(t, srv, rc)
+		})
+	}
+}
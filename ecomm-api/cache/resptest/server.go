@@ -0,0 +1,289 @@
+// Package resptest provides an in-process stand-in for a Redis server, for
+// tests of code using the cache package's Redis backend. It implements just
+// the commands that backend issues: PING, GET, SET (with PX), DEL, SCAN,
+// PUBLISH and SUBSCRIBE.
+package resptest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type Server struct {
+	ln net.Listener
+
+	mu          sync.Mutex
+	data        map[string]item
+	subscribers map[string][]*client
+	conns       map[net.Conn]struct{}
+	closed      bool
+}
+
+type item struct {
+	value     string
+	expiresAt time.Time
+}
+
+type client struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+}
+
+// NewServer starts a server on a random local port. It is stopped when the
+// test finishes.
+func NewServer(t testing.TB) *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting resp server: %v", err)
+	}
+	s := &Server{
+		ln:          ln,
+		data:        make(map[string]item),
+		subscribers: make(map[string][]*client),
+		conns:       make(map[net.Conn]struct{}),
+	}
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops accepting connections and drops the open ones, which lets
+// tests simulate the cache going away.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.ln.Close()
+	for c := range s.conns {
+		c.Close()
+	}
+}
+
+// Keys returns the live keys currently stored.
+func (s *Server) Keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for k, it := range s.data {
+		if it.live() {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Subscribers returns how many connections are subscribed to channel.
+func (s *Server) Subscribers(channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers[channel])
+}
+
+func (it item) live() bool {
+	return it.expiresAt.IsZero() || time.Now().Before(it.expiresAt)
+}
+
+func (s *Server) serve() {
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[nc] = struct{}{}
+		s.mu.Unlock()
+		go s.handle(nc)
+	}
+}
+
+func (s *Server) handle(nc net.Conn) {
+	br := bufio.NewReader(nc)
+	c := &client{w: bufio.NewWriter(nc)}
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, nc)
+		for ch, subs := range s.subscribers {
+			for i, sub := range subs {
+				if sub == c {
+					s.subscribers[ch] = append(subs[:i:i], subs[i+1:]...)
+					break
+				}
+			}
+		}
+		s.mu.Unlock()
+		nc.Close()
+	}()
+	for {
+		args, err := readCommand(br)
+		if err != nil {
+			return
+		}
+		var reply bytes.Buffer
+		s.exec(c, bufio.NewWriter(&reply), args)
+		c.send(reply.Bytes())
+	}
+}
+
+func (c *client) send(b []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.w.Write(b)
+	c.w.Flush()
+}
+
+// exec runs one command and writes its reply to w. Pushed messages to other
+// subscribers are sent after the server lock is released.
+func (s *Server) exec(c *client, w *bufio.Writer, args []string) {
+	defer w.Flush()
+	if len(args) == 0 {
+		writeError(w, "ERR empty command")
+		return
+	}
+	s.mu.Lock()
+	var push func()
+	defer func() {
+		s.mu.Unlock()
+		if push != nil {
+			push()
+		}
+	}()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		fmt.Fprint(w, "+PONG\r\n")
+	case "GET":
+		it, ok := s.data[args[1]]
+		if !ok || !it.live() {
+			fmt.Fprint(w, "$-1\r\n")
+			return
+		}
+		writeBulk(w, it.value)
+	case "SET":
+		it := item{value: args[2]}
+		if len(args) == 5 && strings.EqualFold(args[3], "PX") {
+			ms, err := strconv.Atoi(args[4])
+			if err != nil {
+				writeError(w, "ERR value is not an integer")
+				return
+			}
+			it.expiresAt = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		s.data[args[1]] = it
+		fmt.Fprint(w, "+OK\r\n")
+	case "DEL":
+		n := 0
+		for _, k := range args[1:] {
+			if _, ok := s.data[k]; ok {
+				delete(s.data, k)
+				n++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", n)
+	case "SCAN":
+		// the whole keyspace is returned in one page
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.EqualFold(args[i], "MATCH") {
+				pattern = args[i+1]
+			}
+		}
+		var keys []string
+		for k, it := range s.data {
+			if ok, _ := path.Match(pattern, k); ok && it.live() {
+				keys = append(keys, k)
+			}
+		}
+		fmt.Fprint(w, "*2\r\n")
+		writeBulk(w, "0")
+		fmt.Fprintf(w, "*%d\r\n", len(keys))
+		for _, k := range keys {
+			writeBulk(w, k)
+		}
+	case "PUBLISH":
+		subs := append([]*client(nil), s.subscribers[args[1]]...)
+		var msg bytes.Buffer
+		mw := bufio.NewWriter(&msg)
+		fmt.Fprint(mw, "*3\r\n")
+		writeBulk(mw, "message")
+		writeBulk(mw, args[1])
+		writeBulk(mw, args[2])
+		mw.Flush()
+		push = func() {
+			for _, sub := range subs {
+				sub.send(msg.Bytes())
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", len(subs))
+	case "SUBSCRIBE":
+		// confirm before releasing the lock so that no published message
+		// can overtake the confirmation
+		var confirm bytes.Buffer
+		cw := bufio.NewWriter(&confirm)
+		for i, ch := range args[1:] {
+			s.subscribers[ch] = append(s.subscribers[ch], c)
+			fmt.Fprint(cw, "*3\r\n")
+			writeBulk(cw, "subscribe")
+			writeBulk(cw, ch)
+			fmt.Fprintf(cw, ":%d\r\n", i+1)
+		}
+		cw.Flush()
+		c.send(confirm.Bytes())
+	default:
+		writeError(w, "ERR unknown command '"+args[0]+"'")
+	}
+}
+
+func readCommand(br *bufio.Reader) ([]string, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func writeBulk(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func writeError(w *bufio.Writer, msg string) {
+	fmt.Fprintf(w, "-%s\r\n", msg)
+}
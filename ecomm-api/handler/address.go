@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	var a AddressReq
+	err := json.NewDecoder(r.Body).Decode(&a)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	address := &storer.Address{UserID: claimsFrom(r).UserID}
+	toPatchAddress(address, a)
+	created, err := h.server.CreateAddress(h.ctx, address)
+	if err != nil {
+		writeError(w, err, "error creating address")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toAddressRes(created))
+}
+
+func (h *handler) ListAddresses(w http.ResponseWriter, r *http.Request) {
+	addresses, err := h.server.ListAddresses(h.ctx, claimsFrom(r).UserID)
+	if err != nil {
+		http.Error(w, "error listing addresses", http.StatusInternalServerError)
+		return
+	}
+	res := []*AddressRes{}
+	for _, a := range addresses {
+		res = append(res, toAddressRes(&a))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	a, err := h.server.GetAddress(h.ctx, claimsFrom(r).UserID, id)
+	if err != nil {
+		writeError(w, err, "error getting address")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toAddressRes(a))
+}
+
+func (h *handler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var a AddressReq
+	err = json.NewDecoder(r.Body).Decode(&a)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	address, err := h.server.GetAddress(h.ctx, claimsFrom(r).UserID, id)
+	if err != nil {
+		writeError(w, err, "error getting address")
+		return
+	}
+	toPatchAddress(address, a)
+	updated, err := h.server.UpdateAddress(h.ctx, address)
+	if err != nil {
+		writeError(w, err, "error updating address")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toAddressRes(updated))
+}
+
+func (h *handler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteAddress(h.ctx, claimsFrom(r).UserID, id)
+	if err != nil {
+		writeError(w, err, "error deleting address")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toPatchAddress(address *storer.Address, a AddressReq) {
+	if a.Name != "" {
+		address.Name = a.Name
+	}
+	if a.Line1 != "" {
+		address.Line1 = a.Line1
+	}
+	if a.Line2 != "" {
+		address.Line2 = a.Line2
+	}
+	if a.City != "" {
+		address.City = a.City
+	}
+	if a.Region != "" {
+		address.Region = a.Region
+	}
+	if a.PostalCode != "" {
+		address.PostalCode = a.PostalCode
+	}
+	if a.Country != "" {
+		address.Country = a.Country
+	}
+	if a.Phone != "" {
+		address.Phone = a.Phone
+	}
+	if a.IsDefault != nil {
+		address.IsDefault = *a.IsDefault
+	}
+}
+
+func toAddressRes(a *storer.Address) *AddressRes {
+	return &AddressRes{
+		ID:         a.ID,
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+		IsDefault:  a.IsDefault,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+	}
+}
+
+// toOrderAddress turns an address typed in at checkout into the order's
+// copy; the server sets its kind.
+func toOrderAddress(a *AddressReq) *storer.OrderAddress {
+	if a == nil {
+		return nil
+	}
+	return &storer.OrderAddress{PostalAddress: storer.PostalAddress{
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+	}}
+}
+
+func toOrderAddressRes(a *storer.OrderAddress) *OrderAddressRes {
+	if a == nil {
+		return nil
+	}
+	return &OrderAddressRes{
+		Name:       a.Name,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		Phone:      a.Phone,
+	}
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+)
+
+type contextKey int
+
+const claimsKey contextKey = iota
+
+// authenticate resolves the bearer token, if any, and stores its claims on
+// the request context. Requests without a token continue anonymously; routes
+// that need a user are wrapped in requireUser.
+func (h *handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			http.Error(w, "error parsing authorization header", http.StatusUnauthorized)
+			return
+		}
+		claims, err := h.tokens.Verify(token)
+		if err != nil {
+			http.Error(w, "invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey, claims)))
+	})
+}
+
+func requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claimsFrom(r) == nil {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := claimsFrom(r)
+		if c == nil {
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		if !c.IsAdmin {
+			http.Error(w, "admin access required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// claimsFrom returns the authenticated user's claims, or nil for anonymous
+// requests.
+func claimsFrom(r *http.Request) *auth.Claims {
+	c, _ := r.Context().Value(claimsKey).(*auth.Claims)
+	return c
+}
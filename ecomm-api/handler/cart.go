@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/server"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+const (
+	cartTokenHeader = "X-Cart-Token"
+	cartTokenCookie = "cart_token"
+)
+
+// cartOwner identifies the cart a request acts on: the logged-in user's, or
+// the guest cart whose token came in the X-Cart-Token header or the
+// cart_token cookie.
+func cartOwner(r *http.Request) server.CartOwner {
+	if c := claimsFrom(r); c != nil {
+		return server.CartOwner{UserID: c.UserID}
+	}
+	return server.CartOwner{GuestToken: guestCartToken(r)}
+}
+
+func guestCartToken(r *http.Request) string {
+	if t := r.Header.Get(cartTokenHeader); t != "" {
+		return t
+	}
+	if c, err := r.Cookie(cartTokenCookie); err == nil {
+		return c.Value
+	}
+	return ""
+}
+
+// setCartToken hands a new guest cart's token to the client, as a cookie for
+// browsers and a header for other clients.
+func setCartToken(w http.ResponseWriter, token string, expires time.Time) {
+	w.Header().Set(cartTokenHeader, token)
+	http.SetCookie(w, &http.Cookie{
+		Name:     cartTokenCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearCartToken(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: cartTokenCookie, Path: "/", MaxAge: -1})
+}
+
+func (h *handler) GetCart(w http.ResponseWriter, r *http.Request) {
+	c, err := h.server.GetCart(h.ctx, cartOwner(r))
+	if err != nil {
+		writeError(w, err, "error getting cart")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCartRes(c))
+}
+
+func (h *handler) AddCartItem(w http.ResponseWriter, r *http.Request) {
+	var ci CartItemReq
+	err := json.NewDecoder(r.Body).Decode(&ci)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	c, err := h.server.AddCartItem(h.ctx, cartOwner(r), &storer.CartItem{
+		ProductID: ci.ProductID,
+		VariantID: ci.VariantID,
+		Quantity:  ci.Quantity,
+	})
+	if err != nil {
+		writeError(w, err, "error adding cart item")
+		return
+	}
+	if c.Token != "" {
+		setCartToken(w, c.Token, *c.ExpiresAt)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCartRes(c))
+}
+
+func (h *handler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "itemID"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var ci CartItemReq
+	err = json.NewDecoder(r.Body).Decode(&ci)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	c, err := h.server.UpdateCartItem(h.ctx, cartOwner(r), id, ci.Quantity)
+	if err != nil {
+		writeError(w, err, "error updating cart item")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCartRes(c))
+}
+
+func (h *handler) DeleteCartItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "itemID"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	c, err := h.server.DeleteCartItem(h.ctx, cartOwner(r), id)
+	if err != nil {
+		writeError(w, err, "error deleting cart item")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCartRes(c))
+}
+
+func (h *handler) Checkout(w http.ResponseWriter, r *http.Request) {
+	var co CheckoutReq
+	err := json.NewDecoder(r.Body).Decode(&co)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	o, err := h.server.Checkout(h.ctx, claimsFrom(r).UserID, &storer.Order{
+		PaymentMethod:   co.PaymentMethod,
+		ShippingAddress: toOrderAddress(co.ShippingAddress),
+		BillingAddress:  toOrderAddress(co.BillingAddress),
+	}, toOrderOptions(co))
+	if err != nil {
+		writeError(w, err, "error checking out")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toOrderRes(o))
+}
+
+func toCartRes(c *server.PricedCart) *CartRes {
+	res := &CartRes{Items: []CartItemRes{}, Subtotal: c.Subtotal}
+	for _, l := range c.Lines {
+		res.Items = append(res.Items, CartItemRes{
+			ID:            l.ID,
+			ProductID:     l.ProductID,
+			VariantID:     l.VariantID,
+			Name:          l.Name,
+			Image:         l.Image,
+			Price:         l.Price,
+			Quantity:      l.Quantity,
+			Available:     l.Available,
+			InStock:       l.Available >= l.Quantity,
+			Backorderable: l.Backorderable,
+		})
+	}
+	return res
+}
+
+func toOrderRes(o *storer.Order) *OrderRes {
+	res := &OrderRes{
+		ID:               o.ID,
+		UserID:           o.UserID,
+		Status:           o.Status,
+		PaymentMethod:    o.PaymentMethod,
+		ShippingMethodID: o.ShippingMethodID,
+		ShippingMethod:   o.ShippingMethod,
+		TaxPrice:         o.TaxPrice,
+		ShippingPrice:    o.ShippingPrice,
+		DiscountPrice:    o.DiscountPrice,
+		TotalPrice:       o.TotalPrice,
+		Currency:         o.Currency,
+		ExchangeRate:     o.ExchangeRate,
+		Items:            []OrderItemRes{},
+		Discounts:        []OrderDiscountRes{},
+		ShippingAddress:  toOrderAddressRes(o.ShippingAddress),
+		BillingAddress:   toOrderAddressRes(o.BillingAddress),
+		CreatedAt:        o.CreatedAt,
+		UpdatedAt:        o.UpdatedAt,
+	}
+	for _, oi := range o.Items {
+		item := OrderItemRes{
+			ID:          oi.ID,
+			ProductID:   oi.ProductID,
+			VariantID:   oi.VariantID,
+			Name:        oi.Name,
+			Image:       oi.Image,
+			Price:       oi.Price,
+			Quantity:    oi.Quantity,
+			Backordered: oi.Backordered,
+			Taxes:       []OrderItemTaxRes{},
+		}
+		for _, a := range oi.Allocations {
+			item.Allocations = append(item.Allocations, OrderItemAllocationRes{
+				WarehouseID: a.WarehouseID,
+				Quantity:    a.Quantity,
+				Status:      a.Status,
+				ExpiresAt:   a.ExpiresAt,
+			})
+		}
+		for _, t := range oi.Taxes {
+			item.Taxes = append(item.Taxes, OrderItemTaxRes{
+				TaxRateID: t.TaxRateID,
+				Name:      t.Name,
+				Rate:      t.Rate,
+				Amount:    t.Amount,
+				Included:  t.Included,
+			})
+		}
+		res.Items = append(res.Items, item)
+	}
+	for _, d := range o.Discounts {
+		res.Discounts = append(res.Discounts, OrderDiscountRes{
+			PromotionID: d.PromotionID,
+			Code:        d.Code,
+			Description: d.Description,
+			Amount:      d.Amount,
+		})
+	}
+	return res
+}
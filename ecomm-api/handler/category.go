@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var c CategoryReq
+	err := json.NewDecoder(r.Body).Decode(&c)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	created, err := h.server.CreateCategory(h.ctx, toStorerCategory(c))
+	if err != nil {
+		writeError(w, err, "error creating category")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toCategoryRes(created))
+}
+
+func (h *handler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	c, err := h.server.GetCategory(h.ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		writeError(w, err, "error getting category")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCategoryRes(c))
+}
+
+func (h *handler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.server.ListCategories(h.ctx)
+	if err != nil {
+		http.Error(w, "error listing categories", http.StatusInternalServerError)
+		return
+	}
+	res := []*CategoryRes{}
+	for _, c := range categories {
+		res = append(res, toCategoryRes(&c))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var c CategoryReq
+	err := json.NewDecoder(r.Body).Decode(&c)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	category, err := h.server.GetCategory(h.ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		writeError(w, err, "error getting category")
+		return
+	}
+	toPatchCategory(category, c)
+	updated, err := h.server.UpdateCategory(h.ctx, category)
+	if err != nil {
+		writeError(w, err, "error updating category")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCategoryRes(updated))
+}
+
+func (h *handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	err := h.server.DeleteCategory(h.ctx, chi.URLParam(r, "slug"))
+	if err != nil {
+		writeError(w, err, "error deleting category")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListCategoryProducts lists the products in the category and all of its
+// subcategories. It accepts the same filters as ListProducts.
+func (h *handler) ListCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	conv, err := h.server.Conversion(h.ctx, requestCurrency(r))
+	if err != nil {
+		writeError(w, err, "error listing products")
+		return
+	}
+	f, err := toProductFilter(r, conv)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	book, err := h.server.PriceBook(h.ctx, claimsFrom(r))
+	if err != nil {
+		writeError(w, err, "error listing products")
+		return
+	}
+	products, err := h.server.ListCategoryProducts(h.ctx, chi.URLParam(r, "slug"), f)
+	if err != nil {
+		writeError(w, err, "error listing products")
+		return
+	}
+	res := []*ProductRes{}
+	for _, p := range products {
+		res = append(res, toCustomerProductRes(&p, conv, book))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func toStorerCategory(c CategoryReq) *storer.Category {
+	return &storer.Category{
+		Name:     c.Name,
+		Slug:     c.Slug,
+		ParentID: toParentID(c.ParentID),
+		TaxClass: c.TaxClass,
+	}
+}
+
+func toCategoryRes(c *storer.Category) *CategoryRes {
+	return &CategoryRes{
+		ID:        c.ID,
+		Name:      c.Name,
+		Slug:      c.Slug,
+		ParentID:  c.ParentID,
+		TaxClass:  c.TaxClass,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func toPatchCategory(category *storer.Category, c CategoryReq) {
+	if c.Name != "" {
+		category.Name = c.Name
+	}
+	if c.Slug != "" {
+		category.Slug = c.Slug
+	}
+	if c.ParentID != nil {
+		category.ParentID = toParentID(c.ParentID)
+	}
+	if c.TaxClass != "" {
+		category.TaxClass = c.TaxClass
+	}
+}
+
+// a parent_id of 0 moves the category to the top level
+func toParentID(id *int64) *int64 {
+	if id == nil || *id == 0 {
+		return nil
+	}
+	return id
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+const currencyHeader = "X-Currency"
+
+// requestCurrency is the currency a catalog read asks for, from the currency
+// query parameter or the X-Currency header. Empty means the base currency.
+func requestCurrency(r *http.Request) string {
+	if c := r.URL.Query().Get("currency"); c != "" {
+		return c
+	}
+	return r.Header.Get(currencyHeader)
+}
+
+func (h *handler) ListExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.server.ListExchangeRates(h.ctx)
+	if err != nil {
+		writeError(w, err, "error listing exchange rates")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.toExchangeRatesRes(rates))
+}
+
+// SetExchangeRates replaces the whole exchange rate table with the rates in
+// the body.
+func (h *handler) SetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	var req []ExchangeRateReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	rates := make([]storer.ExchangeRate, 0, len(req))
+	for _, er := range req {
+		rates = append(rates, storer.ExchangeRate{Currency: er.Currency, Rate: er.Rate})
+	}
+	rates, err = h.server.SetExchangeRates(h.ctx, rates)
+	if err != nil {
+		writeError(w, err, "error setting exchange rates")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.toExchangeRatesRes(rates))
+}
+
+func (h *handler) toExchangeRatesRes(rates []storer.ExchangeRate) *ExchangeRatesRes {
+	res := &ExchangeRatesRes{BaseCurrency: h.server.BaseConversion().Currency, Rates: []ExchangeRateRes{}}
+	for _, er := range rates {
+		res.Rates = append(res.Rates, ExchangeRateRes{Currency: er.Currency, Rate: er.Rate, UpdatedAt: er.UpdatedAt})
+	}
+	return res
+}
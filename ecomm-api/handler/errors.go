@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/m21power/ecomm/ecomm-api/payments"
+	"github.com/m21power/ecomm/ecomm-api/server"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// writeError answers with the status matching err. Validation failures are
+// reported with their own message; anything else uses msg so internal
+// details are not leaked.
+func writeError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, server.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, server.ErrUnauthorized):
+		http.Error(w, msg+": unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, server.ErrIdempotencyKeyReused):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, server.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, storer.ErrNotFound):
+		http.Error(w, msg+": not found", http.StatusNotFound)
+	case errors.Is(err, storer.ErrInsufficientStock):
+		http.Error(w, msg+": insufficient stock", http.StatusConflict)
+	case errors.Is(err, storer.ErrUsageLimitReached):
+		http.Error(w, msg+": promotion usage limit reached", http.StatusConflict)
+	case errors.Is(err, storer.ErrOverRefund):
+		http.Error(w, msg+": refund exceeds what was paid", http.StatusConflict)
+	case errors.Is(err, storer.ErrConflict):
+		http.Error(w, msg+": conflict", http.StatusConflict)
+	case errors.Is(err, payments.ErrInvalidSignature):
+		http.Error(w, msg+": invalid signature", http.StatusBadRequest)
+	case errors.Is(err, payments.ErrDeclined):
+		http.Error(w, msg+": payment declined", http.StatusPaymentRequired)
+	case errors.Is(err, payments.ErrUnavailable):
+		http.Error(w, msg+": payment provider unavailable", http.StatusBadGateway)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
@@ -3,22 +3,28 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/cache"
+	"github.com/m21power/ecomm/ecomm-api/money"
 	"github.com/m21power/ecomm/ecomm-api/server"
 	"github.com/m21power/ecomm/ecomm-api/storer"
 )
 
 type handler struct {
-	ctx    context.Context
-	server *server.Server
+	ctx          context.Context
+	server       *server.Server
+	cacheMetrics *cache.Metrics
+	tokens       *auth.TokenMaker
 }
 
-func NewHandler(server *server.Server) *handler {
-	return &handler{ctx: context.Background(), server: server}
+func NewHandler(server *server.Server, cacheMetrics *cache.Metrics, tokens *auth.TokenMaker) *handler {
+	return &handler{ctx: context.Background(), server: server, cacheMetrics: cacheMetrics, tokens: tokens}
 }
 
 func (h *handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
@@ -28,12 +34,12 @@ func (h *handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "error decoding request body", http.StatusBadRequest)
 		return
 	}
-	createdProduct, err := h.server.CreateProduct(h.ctx, toStorerProduct(p))
+	createdProduct, err := h.server.CreateProduct(h.ctx, claimsFrom(r), toStorerProduct(p))
 	if err != nil {
-		http.Error(w, "error creating product", http.StatusInternalServerError)
+		writeError(w, err, "error creating product")
 		return
 	}
-	res := toProductRes(createdProduct)
+	res := toProductRes(createdProduct, h.server.BaseConversion())
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(res)
@@ -47,26 +53,56 @@ func (h *handler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "error parsing id", http.StatusBadRequest)
 		return
 	}
+	conv, err := h.server.Conversion(h.ctx, requestCurrency(r))
+	if err != nil {
+		writeError(w, err, "error getting product")
+		return
+	}
+	book, err := h.server.PriceBook(h.ctx, claimsFrom(r))
+	if err != nil {
+		writeError(w, err, "error getting product")
+		return
+	}
 	product, err := h.server.GetProduct(h.ctx, i)
 	if err != nil {
-		http.Error(w, "error getting product", http.StatusInternalServerError)
+		writeError(w, err, "error getting product")
 		return
 	}
-	res := toProductRes(product)
+	res := toCustomerProductRes(product, conv, book)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(res)
 }
 
 func (h *handler) ListProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := h.server.ListProducts(h.ctx)
+	conv, err := h.server.Conversion(h.ctx, requestCurrency(r))
+	if err != nil {
+		writeError(w, err, "error listing products")
+		return
+	}
+	f, err := toProductFilter(r, conv)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	book, err := h.server.PriceBook(h.ctx, claimsFrom(r))
+	if err != nil {
+		writeError(w, err, "error listing products")
+		return
+	}
+	var products []storer.Product
+	if slug := r.URL.Query().Get("category"); slug != "" {
+		products, err = h.server.ListCategoryProducts(h.ctx, slug, f)
+	} else {
+		products, err = h.server.ListProducts(h.ctx, f)
+	}
 	if err != nil {
-		http.Error(w, "error listing products", http.StatusInternalServerError)
+		writeError(w, err, "error listing products")
 		return
 	}
 	var res []*ProductRes
 	for _, p := range products {
-		res = append(res, toProductRes(&p))
+		res = append(res, toCustomerProductRes(&p, conv, book))
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -86,19 +122,23 @@ func (h *handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "error decoding request body", http.StatusBadRequest)
 		return
 	}
+	if p.CountInStock != nil {
+		http.Error(w, "count_in_stock is changed through stock adjustments", http.StatusBadRequest)
+		return
+	}
 	product, err := h.server.GetProduct(h.ctx, i)
 	if err != nil {
-		http.Error(w, "error getting product", http.StatusInternalServerError)
+		writeError(w, err, "error getting product")
 		return
 	}
 	// now it is a time to update the product
 	toPatchProduct(product, p)
 	updatedProduct, err := h.server.UpdateProduct(h.ctx, product)
 	if err != nil {
-		http.Error(w, "error updating product", http.StatusInternalServerError)
+		writeError(w, err, "error updating product")
 		return
 	}
-	res := toProductRes(updatedProduct)
+	res := toProductRes(updatedProduct, h.server.BaseConversion())
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(res)
@@ -120,33 +160,88 @@ func (h *handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// Metrics exposes the product cache counters in the Prometheus text format.
+func (h *handler) Metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	h.cacheMetrics.WritePrometheus(w, "products")
+}
+
+// toProductFilter reads the filters of a product listing. Prices are in the
+// requested currency and are converted back to the catalog's.
+func toProductFilter(r *http.Request, conv server.Conversion) (storer.ProductFilter, error) {
+	q := r.URL.Query()
+	var f storer.ProductFilter
+	var err error
+	if v := q.Get("min_price"); v != "" {
+		if f.MinPrice, err = money.Parse(v); err != nil {
+			return f, fmt.Errorf("error parsing min_price")
+		}
+		f.MinPrice = conv.ToBase(f.MinPrice)
+	}
+	if v := q.Get("max_price"); v != "" {
+		if f.MaxPrice, err = money.Parse(v); err != nil {
+			return f, fmt.Errorf("error parsing max_price")
+		}
+		f.MaxPrice = conv.ToBase(f.MaxPrice)
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+			return f, fmt.Errorf("error parsing limit")
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if f.Offset, err = strconv.Atoi(v); err != nil || f.Offset < 0 {
+			return f, fmt.Errorf("error parsing offset")
+		}
+	}
+	return f, nil
+}
+
 func toStorerProduct(p ProductReq) *storer.Product {
 	return &storer.Product{
-		Name:         p.Name,
-		Image:        p.Image,
-		Category:     p.Category,
-		Description:  p.Description,
-		Rating:       p.Rating,
-		NumReviews:   p.NumReviews,
-		Price:        p.Price,
-		CountInStock: p.CountInStock,
-		CreatedAt:    time.Now(),
+		Name:            p.Name,
+		Image:           p.Image,
+		Category:        p.Category,
+		CategoryID:      p.CategoryID,
+		Description:     p.Description,
+		Price:           p.Price,
+		CountInStock:    derefInt64(p.CountInStock),
+		Weight:          derefFloat(p.Weight),
+		Length:          derefFloat(p.Length),
+		Width:           derefFloat(p.Width),
+		Height:          derefFloat(p.Height),
+		InventoryPolicy: p.InventoryPolicy,
+		BackorderLimit:  p.BackorderLimit,
+		ReleaseDate:     p.ReleaseDate,
+		CreatedAt:       time.Now(),
 	}
 }
 
-func toProductRes(p *storer.Product) *ProductRes {
+// toProductRes shows the product with its price in conv's currency.
+func toProductRes(p *storer.Product, conv server.Conversion) *ProductRes {
 	return &ProductRes{
-		ID:           p.ID,
-		Name:         p.Name,
-		Image:        p.Image,
-		Category:     p.Category,
-		Description:  p.Description,
-		Rating:       p.Rating,
-		NumReviews:   p.NumReviews,
-		Price:        p.Price,
-		CountInStock: p.CountInStock,
-		CreatedAt:    p.CreatedAt,
-		UpdatedAt:    p.UpdatedAt,
+		ID:              p.ID,
+		Name:            p.Name,
+		Image:           p.Image,
+		Category:        p.Category,
+		CategoryID:      p.CategoryID,
+		Description:     p.Description,
+		Rating:          p.Rating,
+		NumReviews:      p.NumReviews,
+		Price:           conv.Convert(p.Price),
+		Currency:        conv.Currency,
+		CountInStock:    p.CountInStock,
+		Available:       p.Available(),
+		Weight:          p.Weight,
+		Length:          p.Length,
+		Width:           p.Width,
+		Height:          p.Height,
+		InventoryPolicy: p.InventoryPolicy,
+		BackorderLimit:  p.BackorderLimit,
+		ReleaseDate:     p.ReleaseDate,
+		CreatedAt:       p.CreatedAt,
+		UpdatedAt:       p.UpdatedAt,
 	}
 }
 
@@ -157,27 +252,53 @@ func toPatchProduct(product *storer.Product, p ProductReq) {
 	if p.Image != "" {
 		product.Image = p.Image
 	}
-	if p.Category != "" {
+	if p.CategoryID != nil {
+		product.CategoryID = p.CategoryID
+	} else if p.Category != "" {
+		// resolved by slug on update
 		product.Category = p.Category
+		product.CategoryID = nil
 	}
 	if p.Description != "" {
 		product.Description = p.Description
 	}
-	if p.Rating != 0 {
-		product.Rating = p.Rating
-	}
-	if p.NumReviews != 0 {
-		product.NumReviews = p.NumReviews
-	}
 	if p.Price != 0 {
 		product.Price = p.Price
 	}
-	if p.CountInStock != 0 {
-		product.CountInStock = p.CountInStock
+	if p.Weight != nil {
+		product.Weight = *p.Weight
+	}
+	if p.Length != nil {
+		product.Length = *p.Length
+	}
+	if p.Width != nil {
+		product.Width = *p.Width
+	}
+	if p.Height != nil {
+		product.Height = *p.Height
+	}
+	if p.InventoryPolicy != "" {
+		product.InventoryPolicy = p.InventoryPolicy
+		product.BackorderLimit = p.BackorderLimit
+		product.ReleaseDate = p.ReleaseDate
 	}
 	product.UpdatedAt = toTimePtr(time.Now())
 }
 
+func derefFloat(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
+
+func derefInt64(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
 func toTimePtr(t time.Time) *time.Time {
 	return &t
 }
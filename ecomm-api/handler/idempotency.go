@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLen      = 255
+	maxIdempotentBodyBytes    = 1 << 20
+)
+
+// idempotent makes a request sent with an Idempotency-Key header safe to
+// retry: the first response for the key is stored and sent again for
+// retries of the same request, while reusing the key for a different
+// request is refused. Keys belong to the user, so the route must require
+// one.
+func (h *handler) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			http.Error(w, "idempotency key is too long", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			http.Error(w, "error reading request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.New()
+		io.WriteString(sum, r.Method+" "+r.URL.Path+"\n")
+		sum.Write(body)
+		k, replay, err := h.server.BeginIdempotentRequest(h.ctx, claimsFrom(r).UserID, key, hex.EncodeToString(sum.Sum(nil)))
+		if err != nil {
+			writeError(w, err, "error using idempotency key")
+			return
+		}
+		if replay {
+			if k.ContentType != "" {
+				w.Header().Set("Content-Type", k.ContentType)
+			}
+			w.Header().Set(idempotencyReplayedHeader, "true")
+			w.WriteHeader(k.StatusCode)
+			w.Write(k.ResponseBody)
+			return
+		}
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		err = h.server.FinishIdempotentRequest(h.ctx, k, rec.status, w.Header().Get("Content-Type"), rec.body.Bytes())
+		if err != nil {
+			log.Printf("error storing response for idempotency key: %v", err)
+		}
+	})
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ListReviewQueue lists reviews awaiting moderation. ?status=pending,flagged
+// narrows the queue to the given states.
+func (h *handler) ListReviewQueue(w http.ResponseWriter, r *http.Request) {
+	var statuses []string
+	if v := r.URL.Query().Get("status"); v != "" {
+		statuses = strings.Split(v, ",")
+	}
+	reviews, err := h.server.ListReviewQueue(h.ctx, statuses)
+	if err != nil {
+		writeError(w, err, "error listing reviews")
+		return
+	}
+	res := []*ReviewRes{}
+	for _, rv := range reviews {
+		res = append(res, toReviewRes(&rv))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) ModerateReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "reviewID"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var m ModerateReviewReq
+	err = json.NewDecoder(r.Body).Decode(&m)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	updated, err := h.server.ModerateReview(h.ctx, claimsFrom(r), id, m.Status)
+	if err != nil {
+		writeError(w, err, "error moderating review")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toReviewRes(updated))
+}
+
+func (h *handler) ReportReview(w http.ResponseWriter, r *http.Request) {
+	productID, reviewID, err := parseReviewPath(r)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var rr ReviewReportReq
+	err = json.NewDecoder(r.Body).Decode(&rr)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	rep, err := h.server.ReportReview(h.ctx, claimsFrom(r), productID, reviewID, rr.Reason)
+	if err != nil {
+		writeError(w, err, "error reporting review")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ReviewReportRes{
+		ID:        rep.ID,
+		ReviewID:  rep.ReviewID,
+		Reason:    rep.Reason,
+		CreatedAt: rep.CreatedAt,
+	})
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/server"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var co CreateOrderReq
+	err := json.NewDecoder(r.Body).Decode(&co)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	o := &storer.Order{
+		UserID:          claimsFrom(r).UserID,
+		PaymentMethod:   co.PaymentMethod,
+		ShippingAddress: toOrderAddress(co.ShippingAddress),
+		BillingAddress:  toOrderAddress(co.BillingAddress),
+	}
+	for _, it := range co.Items {
+		o.Items = append(o.Items, storer.OrderItem{ProductID: it.ProductID, VariantID: it.VariantID, Quantity: it.Quantity})
+	}
+	created, err := h.server.CreateOrder(h.ctx, o, toOrderOptions(co.CheckoutReq))
+	if err != nil {
+		writeError(w, err, "error creating order")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toOrderRes(created))
+}
+
+func (h *handler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	o, err := h.server.GetOrder(h.ctx, claimsFrom(r), id)
+	if err != nil {
+		writeError(w, err, "error getting order")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toOrderRes(o))
+}
+
+// CancelOrder cancels a pending order and puts its stock back.
+func (h *handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	o, err := h.server.CancelOrder(h.ctx, claimsFrom(r), id)
+	if err != nil {
+		writeError(w, err, "error cancelling order")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toOrderRes(o))
+}
+
+func toOrderOptions(co CheckoutReq) server.OrderOptions {
+	return server.OrderOptions{
+		PromotionCodes:    co.PromotionCodes,
+		ShippingMethodID:  co.ShippingMethodID,
+		ShippingAddressID: co.ShippingAddressID,
+		BillingAddressID:  co.BillingAddressID,
+		Currency:          co.Currency,
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) PayOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var p PaymentReq
+	err = json.NewDecoder(r.Body).Decode(&p)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	payment, err := h.server.PayOrder(h.ctx, claimsFrom(r), id, p.Provider, p.PaymentToken)
+	if err != nil {
+		writeError(w, err, "error paying order")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toPaymentRes(payment))
+}
+
+func (h *handler) ListPayments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	payments, err := h.server.ListPayments(h.ctx, claimsFrom(r), id)
+	if err != nil {
+		writeError(w, err, "error listing payments")
+		return
+	}
+	res := []*PaymentRes{}
+	for i := range payments {
+		res = append(res, toPaymentRes(&payments[i]))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func toPaymentRes(p *storer.Payment) *PaymentRes {
+	return &PaymentRes{
+		ID:            p.ID,
+		OrderID:       p.OrderID,
+		Provider:      p.Provider,
+		ProviderRef:   p.ProviderRef,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+		Status:        p.Status,
+		FailureReason: p.FailureReason,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
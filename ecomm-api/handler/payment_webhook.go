@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+const maxWebhookBytes = 1 << 20
+
+// PaymentWebhook receives a provider's callback. The body is read verbatim
+// since the signature covers its exact bytes.
+func (h *handler) PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		http.Error(w, "error reading request body", http.StatusBadRequest)
+		return
+	}
+	err = h.server.HandlePaymentWebhook(h.ctx, chi.URLParam(r, "provider"), r.Header, payload)
+	if err != nil {
+		writeError(w, err, "error handling payment webhook")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) ListPaymentEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := h.server.ListPaymentEvents(h.ctx)
+	if err != nil {
+		http.Error(w, "error listing payment events", http.StatusInternalServerError)
+		return
+	}
+	res := []*PaymentEventRes{}
+	for i := range events {
+		res = append(res, toPaymentEventRes(&events[i]))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) ReplayPaymentEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	e, err := h.server.ReplayPaymentEvent(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error replaying payment event")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toPaymentEventRes(e))
+}
+
+func toPaymentEventRes(e *storer.PaymentEvent) *PaymentEventRes {
+	return &PaymentEventRes{
+		ID:          e.ID,
+		Provider:    e.Provider,
+		EventID:     e.EventID,
+		Type:        e.Type,
+		PaymentRef:  e.PaymentRef,
+		Payload:     string(e.Payload),
+		Error:       e.Error,
+		ReceivedAt:  e.ReceivedAt,
+		ProcessedAt: e.ProcessedAt,
+	}
+}
@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/server"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	var g CustomerGroupReq
+	err := json.NewDecoder(r.Body).Decode(&g)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	created, err := h.server.CreateCustomerGroup(h.ctx, &storer.CustomerGroup{Name: g.Name})
+	if err != nil {
+		writeError(w, err, "error creating customer group")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toCustomerGroupRes(created))
+}
+
+func (h *handler) ListCustomerGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.server.ListCustomerGroups(h.ctx)
+	if err != nil {
+		http.Error(w, "error listing customer groups", http.StatusInternalServerError)
+		return
+	}
+	res := []*CustomerGroupRes{}
+	for _, g := range groups {
+		res = append(res, toCustomerGroupRes(&g))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	g, err := h.server.GetCustomerGroup(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting customer group")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCustomerGroupRes(g))
+}
+
+func (h *handler) UpdateCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req CustomerGroupReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	g, err := h.server.GetCustomerGroup(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting customer group")
+		return
+	}
+	if req.Name != "" {
+		g.Name = req.Name
+	}
+	updated, err := h.server.UpdateCustomerGroup(h.ctx, g)
+	if err != nil {
+		writeError(w, err, "error updating customer group")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCustomerGroupRes(updated))
+}
+
+func (h *handler) DeleteCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteCustomerGroup(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error deleting customer group")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetUserCustomerGroup moves a user into a customer group, or out of any
+// group when customer_group_id is null.
+func (h *handler) SetUserCustomerGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req UserCustomerGroupReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	u, err := h.server.SetUserCustomerGroup(h.ctx, id, req.CustomerGroupID)
+	if err != nil {
+		writeError(w, err, "error setting customer group")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toUserRes(u))
+}
+
+func (h *handler) CreatePriceList(w http.ResponseWriter, r *http.Request) {
+	var req PriceListReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	// lists apply unless created otherwise
+	pl := &storer.PriceList{Active: true}
+	toPatchPriceList(pl, req)
+	created, err := h.server.CreatePriceList(h.ctx, pl)
+	if err != nil {
+		writeError(w, err, "error creating price list")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toPriceListRes(created))
+}
+
+// ListPriceLists lists every price list, or those of the group given by the
+// customer_group_id query parameter.
+func (h *handler) ListPriceLists(w http.ResponseWriter, r *http.Request) {
+	var groupID int64
+	if v := r.URL.Query().Get("customer_group_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "error parsing customer_group_id", http.StatusBadRequest)
+			return
+		}
+		groupID = id
+	}
+	lists, err := h.server.ListPriceLists(h.ctx, groupID)
+	if err != nil {
+		http.Error(w, "error listing price lists", http.StatusInternalServerError)
+		return
+	}
+	res := []*PriceListRes{}
+	for _, pl := range lists {
+		res = append(res, toPriceListRes(&pl))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetPriceList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	pl, err := h.server.GetPriceList(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting price list")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toPriceListRes(pl))
+}
+
+func (h *handler) UpdatePriceList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req PriceListReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	pl, err := h.server.GetPriceList(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting price list")
+		return
+	}
+	toPatchPriceList(pl, req)
+	updated, err := h.server.UpdatePriceList(h.ctx, pl)
+	if err != nil {
+		writeError(w, err, "error updating price list")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toPriceListRes(updated))
+}
+
+func (h *handler) DeletePriceList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeletePriceList(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error deleting price list")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toCustomerGroupRes(g *storer.CustomerGroup) *CustomerGroupRes {
+	return &CustomerGroupRes{
+		ID:        g.ID,
+		Name:      g.Name,
+		CreatedAt: g.CreatedAt,
+		UpdatedAt: g.UpdatedAt,
+	}
+}
+
+func toPatchPriceList(pl *storer.PriceList, req PriceListReq) {
+	if req.CustomerGroupID != 0 {
+		pl.CustomerGroupID = req.CustomerGroupID
+	}
+	if req.Name != "" {
+		pl.Name = req.Name
+	}
+	if req.Active != nil {
+		pl.Active = *req.Active
+	}
+	if req.Entries != nil {
+		pl.Entries = storer.PriceListEntries{}
+		for _, e := range req.Entries {
+			pl.Entries = append(pl.Entries, storer.PriceListEntry(e))
+		}
+	}
+}
+
+func toPriceListRes(pl *storer.PriceList) *PriceListRes {
+	res := &PriceListRes{
+		ID:              pl.ID,
+		CustomerGroupID: pl.CustomerGroupID,
+		Name:            pl.Name,
+		Active:          pl.Active,
+		Entries:         []PriceListEntryRes{},
+		CreatedAt:       pl.CreatedAt,
+		UpdatedAt:       pl.UpdatedAt,
+	}
+	for _, e := range pl.Entries {
+		res.Entries = append(res.Entries, PriceListEntryRes(e))
+	}
+	return res
+}
+
+// toCustomerProductRes shows the product at the price the customer of
+// book pays for one unit, with the cheaper prices for larger quantities.
+func toCustomerProductRes(p *storer.Product, conv server.Conversion, book *server.PriceBook) *ProductRes {
+	res := toProductRes(p, conv)
+	res.Price = conv.Convert(book.Price(p, p.Price, 1))
+	for _, t := range book.Tiers(p, p.Price) {
+		res.PriceTiers = append(res.PriceTiers, PriceTierRes{
+			MinQuantity: t.MinQuantity,
+			Price:       conv.Convert(t.Price),
+		})
+	}
+	return res
+}
+
+// variantPricing returns the price book of the requesting customer and, when
+// it has one, the product whose variants are priced.
+func (h *handler) variantPricing(r *http.Request, productID int64) (*storer.Product, *server.PriceBook, error) {
+	book, err := h.server.PriceBook(h.ctx, claimsFrom(r))
+	if err != nil || book == nil {
+		return nil, nil, err
+	}
+	p, err := h.server.GetProduct(h.ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, book, nil
+}
+
+// toCustomerVariantRes shows the variant with its price override, if any,
+// at the price the customer of book pays for one unit of product p.
+func toCustomerVariantRes(v *storer.Variant, p *storer.Product, conv server.Conversion, book *server.PriceBook) *VariantRes {
+	res := toVariantRes(v, conv)
+	if v.Price != nil && p != nil {
+		price := conv.Convert(book.Price(p, *v.Price, 1))
+		res.Price = &price
+	}
+	return res
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var p PromotionReq
+	err := json.NewDecoder(r.Body).Decode(&p)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	// promotions are active unless created otherwise
+	promotion := &storer.Promotion{Active: true}
+	toPatchPromotion(promotion, p)
+	created, err := h.server.CreatePromotion(h.ctx, promotion)
+	if err != nil {
+		writeError(w, err, "error creating promotion")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toPromotionRes(created))
+}
+
+func (h *handler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.server.ListPromotions(h.ctx)
+	if err != nil {
+		http.Error(w, "error listing promotions", http.StatusInternalServerError)
+		return
+	}
+	res := []*PromotionRes{}
+	for _, p := range promotions {
+		res = append(res, toPromotionRes(&p))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetPromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	p, err := h.server.GetPromotion(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting promotion")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toPromotionRes(p))
+}
+
+func (h *handler) UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var p PromotionReq
+	err = json.NewDecoder(r.Body).Decode(&p)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	promotion, err := h.server.GetPromotion(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting promotion")
+		return
+	}
+	toPatchPromotion(promotion, p)
+	updated, err := h.server.UpdatePromotion(h.ctx, promotion)
+	if err != nil {
+		writeError(w, err, "error updating promotion")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toPromotionRes(updated))
+}
+
+func (h *handler) DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeletePromotion(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error deleting promotion")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toPatchPromotion(promotion *storer.Promotion, p PromotionReq) {
+	if p.Code != nil {
+		promotion.Code = p.Code
+	}
+	if p.Name != "" {
+		promotion.Name = p.Name
+	}
+	if p.Kind != "" {
+		promotion.Kind = p.Kind
+	}
+	if p.Percent != nil {
+		promotion.Percent = *p.Percent
+	}
+	if p.Amount != nil {
+		promotion.Amount = *p.Amount
+	}
+	if p.BuyQuantity != nil {
+		promotion.BuyQuantity = *p.BuyQuantity
+	}
+	if p.GetQuantity != nil {
+		promotion.GetQuantity = *p.GetQuantity
+	}
+	if p.MinOrderValue != nil {
+		promotion.MinOrderValue = *p.MinOrderValue
+	}
+	if p.Scope != nil {
+		promotion.Scope = storer.PromotionScope{ProductIDs: p.Scope.ProductIDs, CategoryIDs: p.Scope.CategoryIDs}
+	}
+	if p.UsageLimit != nil {
+		promotion.UsageLimit = p.UsageLimit
+	}
+	if p.PerUserLimit != nil {
+		promotion.PerUserLimit = p.PerUserLimit
+	}
+	if p.Stackable != nil {
+		promotion.Stackable = *p.Stackable
+	}
+	if p.Active != nil {
+		promotion.Active = *p.Active
+	}
+	if p.StartsAt != nil {
+		promotion.StartsAt = p.StartsAt
+	}
+	if p.EndsAt != nil {
+		promotion.EndsAt = p.EndsAt
+	}
+}
+
+func toPromotionRes(p *storer.Promotion) *PromotionRes {
+	return &PromotionRes{
+		ID:            p.ID,
+		Code:          p.Code,
+		Name:          p.Name,
+		Kind:          p.Kind,
+		Percent:       p.Percent,
+		Amount:        p.Amount,
+		BuyQuantity:   p.BuyQuantity,
+		GetQuantity:   p.GetQuantity,
+		MinOrderValue: p.MinOrderValue,
+		Scope:         PromotionScopeReq{ProductIDs: p.Scope.ProductIDs, CategoryIDs: p.Scope.CategoryIDs},
+		UsageLimit:    p.UsageLimit,
+		PerUserLimit:  p.PerUserLimit,
+		TimesUsed:     p.TimesUsed,
+		Stackable:     p.Stackable,
+		Active:        p.Active,
+		StartsAt:      p.StartsAt,
+		EndsAt:        p.EndsAt,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
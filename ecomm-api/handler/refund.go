@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var rf RefundReq
+	err = json.NewDecoder(r.Body).Decode(&rf)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	refund, err := h.server.RefundOrder(h.ctx, toStorerRefund(id, rf))
+	if err != nil {
+		writeError(w, err, "error refunding order")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toRefundRes(refund))
+}
+
+func (h *handler) ListRefunds(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	refunds, err := h.server.ListRefunds(h.ctx, claimsFrom(r), id)
+	if err != nil {
+		writeError(w, err, "error listing refunds")
+		return
+	}
+	res := []*RefundRes{}
+	for i := range refunds {
+		res = append(res, toRefundRes(&refunds[i]))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func toStorerRefund(orderID int64, r RefundReq) *storer.Refund {
+	refund := &storer.Refund{
+		OrderID: orderID,
+		Amount:  r.Amount,
+		Reason:  r.Reason,
+		Restock: r.Restock == nil || *r.Restock,
+	}
+	for _, ri := range r.Items {
+		refund.Items = append(refund.Items, storer.RefundItem{OrderItemID: ri.OrderItemID, Quantity: ri.Quantity})
+	}
+	return refund
+}
+
+func toRefundRes(r *storer.Refund) *RefundRes {
+	res := &RefundRes{
+		ID:            r.ID,
+		OrderID:       r.OrderID,
+		PaymentID:     r.PaymentID,
+		Amount:        r.Amount,
+		Reason:        r.Reason,
+		Restock:       r.Restock,
+		Status:        r.Status,
+		ProviderRef:   r.ProviderRef,
+		FailureReason: r.FailureReason,
+		Items:         []RefundItemRes{},
+		CreatedAt:     r.CreatedAt,
+		UpdatedAt:     r.UpdatedAt,
+	}
+	for _, ri := range r.Items {
+		res.Items = append(res.Items, RefundItemRes{OrderItemID: ri.OrderItemID, Quantity: ri.Quantity})
+	}
+	return res
+}
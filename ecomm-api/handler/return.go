@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateReturn(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var rr ReturnReq
+	err = json.NewDecoder(r.Body).Decode(&rr)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	created, err := h.server.CreateReturn(h.ctx, claimsFrom(r), toStorerReturn(id, rr))
+	if err != nil {
+		writeError(w, err, "error creating return")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toReturnRes(created))
+}
+
+func (h *handler) ListOrderReturns(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	returns, err := h.server.ListOrderReturns(h.ctx, claimsFrom(r), id)
+	if err != nil {
+		writeError(w, err, "error listing returns")
+		return
+	}
+	writeReturns(w, returns)
+}
+
+// ListReturns lists the user's returns, or all returns for admins.
+// ?status=requested,received narrows the list to the given states.
+func (h *handler) ListReturns(w http.ResponseWriter, r *http.Request) {
+	var f storer.ReturnFilter
+	if v := r.URL.Query().Get("status"); v != "" {
+		f.Statuses = strings.Split(v, ",")
+	}
+	returns, err := h.server.ListReturns(h.ctx, claimsFrom(r), f)
+	if err != nil {
+		writeError(w, err, "error listing returns")
+		return
+	}
+	writeReturns(w, returns)
+}
+
+func (h *handler) GetReturn(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	rt, err := h.server.GetReturn(h.ctx, claimsFrom(r), id)
+	if err != nil {
+		writeError(w, err, "error getting return")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toReturnRes(rt))
+}
+
+func (h *handler) UpdateReturn(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var u UpdateReturnReq
+	err = json.NewDecoder(r.Body).Decode(&u)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	rt, err := h.server.GetReturn(h.ctx, claimsFrom(r), id)
+	if err != nil {
+		writeError(w, err, "error getting return")
+		return
+	}
+	updated, err := h.server.UpdateReturn(h.ctx, claimsFrom(r), toPatchReturn(rt, u))
+	if err != nil {
+		writeError(w, err, "error updating return")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toReturnRes(updated))
+}
+
+func writeReturns(w http.ResponseWriter, returns []storer.Return) {
+	res := []*ReturnRes{}
+	for i := range returns {
+		res = append(res, toReturnRes(&returns[i]))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func toStorerReturn(orderID int64, r ReturnReq) *storer.Return {
+	rt := &storer.Return{OrderID: orderID, Note: r.Note, Restock: true}
+	for _, ri := range r.Items {
+		rt.Items = append(rt.Items, storer.ReturnItem{OrderItemID: ri.OrderItemID, Quantity: ri.Quantity, ReasonCode: ri.ReasonCode})
+	}
+	return rt
+}
+
+func toPatchReturn(r *storer.Return, u UpdateReturnReq) *storer.Return {
+	if u.Status != "" {
+		r.Status = u.Status
+	}
+	if u.AdminNote != nil {
+		r.AdminNote = *u.AdminNote
+	}
+	if u.Restock != nil {
+		r.Restock = *u.Restock
+	}
+	return r
+}
+
+func toReturnRes(r *storer.Return) *ReturnRes {
+	res := &ReturnRes{
+		ID:        r.ID,
+		OrderID:   r.OrderID,
+		Status:    r.Status,
+		Note:      r.Note,
+		AdminNote: r.AdminNote,
+		Restock:   r.Restock,
+		RefundID:  r.RefundID,
+		Items:     []ReturnItemRes{},
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+	for _, ri := range r.Items {
+		res.Items = append(res.Items, ReturnItemRes{OrderItemID: ri.OrderItemID, Quantity: ri.Quantity, ReasonCode: ri.ReasonCode})
+	}
+	return res
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var rv ReviewReq
+	err = json.NewDecoder(r.Body).Decode(&rv)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	created, err := h.server.CreateReview(h.ctx, &storer.Review{
+		ProductID: productID,
+		UserID:    claimsFrom(r).UserID,
+		Stars:     rv.Stars,
+		Title:     rv.Title,
+		Body:      rv.Body,
+	})
+	if err != nil {
+		writeError(w, err, "error creating review")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toReviewRes(created))
+}
+
+func (h *handler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	reviews, err := h.server.ListReviews(h.ctx, productID)
+	if err != nil {
+		http.Error(w, "error listing reviews", http.StatusInternalServerError)
+		return
+	}
+	res := []*ReviewRes{}
+	for _, rv := range reviews {
+		res = append(res, toReviewRes(&rv))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	productID, reviewID, err := parseReviewPath(r)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var rv ReviewReq
+	err = json.NewDecoder(r.Body).Decode(&rv)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	review, err := h.server.GetReview(h.ctx, productID, reviewID)
+	if err != nil {
+		writeError(w, err, "error getting review")
+		return
+	}
+	toPatchReview(review, rv)
+	updated, err := h.server.UpdateReview(h.ctx, claimsFrom(r), review)
+	if err != nil {
+		writeError(w, err, "error updating review")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toReviewRes(updated))
+}
+
+func (h *handler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	productID, reviewID, err := parseReviewPath(r)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteReview(h.ctx, claimsFrom(r), productID, reviewID)
+	if err != nil {
+		writeError(w, err, "error deleting review")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseReviewPath(r *http.Request) (int64, int64, error) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	reviewID, err := strconv.ParseInt(chi.URLParam(r, "reviewID"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return productID, reviewID, nil
+}
+
+func toReviewRes(r *storer.Review) *ReviewRes {
+	return &ReviewRes{
+		ID:               r.ID,
+		ProductID:        r.ProductID,
+		UserID:           r.UserID,
+		Stars:            r.Stars,
+		Title:            r.Title,
+		Body:             r.Body,
+		VerifiedPurchase: r.VerifiedPurchase,
+		Status:           r.Status,
+		ReportCount:      r.ReportCount,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+	}
+}
+
+func toPatchReview(review *storer.Review, rv ReviewReq) {
+	if rv.Stars != 0 {
+		review.Stars = rv.Stars
+	}
+	if rv.Title != "" {
+		review.Title = rv.Title
+	}
+	if rv.Body != "" {
+		review.Body = rv.Body
+	}
+}
@@ -10,15 +10,205 @@ var r *chi.Mux
 
 func RegisterRoutes(handler *handler) *chi.Mux {
 	r = chi.NewRouter()
+	r.Use(handler.authenticate)
+	r.Route("/users", func(r chi.Router) {
+		r.Post("/", handler.RegisterUser)
+		r.Post("/login", handler.Login)
+		r.With(requireUser).Get("/me", handler.GetCurrentUser)
+		r.With(requireAdmin).Put("/{id}/customer-group", handler.SetUserCustomerGroup)
+	})
 	r.Route("/products", func(r chi.Router) {
-		r.Post("/", handler.CreateProduct)
+		r.With(requireAdmin).Post("/", handler.CreateProduct)
 		r.Get("/", handler.ListProducts)
 		r.Route("/{id}", func(r chi.Router) {
 			r.Get("/", handler.GetProduct)
-			r.Patch("/", handler.UpdateProduct)
-			r.Delete("/", handler.DeleteProduct)
+			r.With(requireAdmin).Patch("/", handler.UpdateProduct)
+			r.With(requireAdmin).Delete("/", handler.DeleteProduct)
+			r.Route("/reviews", func(r chi.Router) {
+				r.Get("/", handler.ListReviews)
+				r.With(requireUser).Post("/", handler.CreateReview)
+				r.Route("/{reviewID}", func(r chi.Router) {
+					r.Use(requireUser)
+					r.Patch("/", handler.UpdateReview)
+					r.Delete("/", handler.DeleteReview)
+					r.Post("/report", handler.ReportReview)
+				})
+			})
+			r.Route("/stock", func(r chi.Router) {
+				r.Use(requireAdmin)
+				r.Get("/", handler.ListStockLevels)
+				r.Post("/adjustments", handler.AdjustStock)
+				r.Post("/transfers", handler.TransferStock)
+			})
+			r.With(requireAdmin).Get("/inventory/history", handler.ListInventoryHistory)
+			r.Route("/variants", func(r chi.Router) {
+				r.With(requireAdmin).Post("/", handler.CreateVariant)
+				r.Get("/", handler.ListVariants)
+				r.Route("/{variantID}", func(r chi.Router) {
+					r.Get("/", handler.GetVariant)
+					r.With(requireAdmin).Patch("/", handler.UpdateVariant)
+					r.With(requireAdmin).Delete("/", handler.DeleteVariant)
+				})
+			})
+		})
+	})
+	r.Route("/cart", func(r chi.Router) {
+		r.Get("/", handler.GetCart)
+		r.Post("/items", handler.AddCartItem)
+		r.Patch("/items/{itemID}", handler.UpdateCartItem)
+		r.Delete("/items/{itemID}", handler.DeleteCartItem)
+		r.With(requireUser).Post("/items/{itemID}/move-to-wishlist", handler.MoveCartItemToWishlist)
+		r.With(requireUser, handler.idempotent).Post("/checkout", handler.Checkout)
+	})
+	r.Route("/addresses", func(r chi.Router) {
+		r.Use(requireUser)
+		r.Get("/", handler.ListAddresses)
+		r.Post("/", handler.CreateAddress)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", handler.GetAddress)
+			r.Patch("/", handler.UpdateAddress)
+			r.Delete("/", handler.DeleteAddress)
+		})
+	})
+	r.Route("/orders", func(r chi.Router) {
+		r.Use(requireUser)
+		r.With(handler.idempotent).Post("/", handler.CreateOrder)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", handler.GetOrder)
+			r.Post("/cancel", handler.CancelOrder)
+			r.With(handler.idempotent).Post("/payments", handler.PayOrder)
+			r.Get("/payments", handler.ListPayments)
+			r.With(requireAdmin, handler.idempotent).Post("/refunds", handler.RefundOrder)
+			r.Get("/refunds", handler.ListRefunds)
+			r.Post("/returns", handler.CreateReturn)
+			r.Get("/returns", handler.ListOrderReturns)
+		})
+	})
+	r.Route("/returns", func(r chi.Router) {
+		r.Use(requireUser)
+		r.Get("/", handler.ListReturns)
+		r.Get("/{id}", handler.GetReturn)
+		r.With(requireAdmin).Patch("/{id}", handler.UpdateReturn)
+	})
+	r.Post("/webhooks/payments/{provider}", handler.PaymentWebhook)
+	r.Route("/payment-events", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Get("/", handler.ListPaymentEvents)
+		r.Post("/{id}/replay", handler.ReplayPaymentEvent)
+	})
+	r.Route("/wishlists", func(r chi.Router) {
+		r.Get("/shared/{token}", handler.GetSharedWishlist)
+		r.Group(func(r chi.Router) {
+			r.Use(requireUser)
+			r.Get("/", handler.ListWishlists)
+			r.Post("/", handler.CreateWishlist)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", handler.GetWishlist)
+				r.Patch("/", handler.UpdateWishlist)
+				r.Delete("/", handler.DeleteWishlist)
+				r.Post("/share", handler.ShareWishlist)
+				r.Delete("/share", handler.UnshareWishlist)
+				r.Post("/items", handler.AddWishlistItem)
+				r.Delete("/items/{itemID}", handler.DeleteWishlistItem)
+				r.Post("/items/{itemID}/move-to-cart", handler.MoveWishlistItemToCart)
+			})
+		})
+	})
+	r.Route("/promotions", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Post("/", handler.CreatePromotion)
+		r.Get("/", handler.ListPromotions)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", handler.GetPromotion)
+			r.Patch("/", handler.UpdatePromotion)
+			r.Delete("/", handler.DeletePromotion)
+		})
+	})
+	r.Route("/shipping", func(r chi.Router) {
+		r.Post("/quote", handler.QuoteShipping)
+		r.Group(func(r chi.Router) {
+			r.Use(requireAdmin)
+			r.Route("/zones", func(r chi.Router) {
+				r.Post("/", handler.CreateShippingZone)
+				r.Get("/", handler.ListShippingZones)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", handler.GetShippingZone)
+					r.Patch("/", handler.UpdateShippingZone)
+					r.Delete("/", handler.DeleteShippingZone)
+				})
+			})
+			r.Route("/methods", func(r chi.Router) {
+				r.Post("/", handler.CreateShippingMethod)
+				r.Get("/", handler.ListShippingMethods)
+				r.Route("/{id}", func(r chi.Router) {
+					r.Get("/", handler.GetShippingMethod)
+					r.Patch("/", handler.UpdateShippingMethod)
+					r.Delete("/", handler.DeleteShippingMethod)
+				})
+			})
+		})
+	})
+	r.Route("/tax-rates", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Post("/", handler.CreateTaxRate)
+		r.Get("/", handler.ListTaxRates)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", handler.GetTaxRate)
+			r.Patch("/", handler.UpdateTaxRate)
+			r.Delete("/", handler.DeleteTaxRate)
+		})
+	})
+	r.Route("/warehouses", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Post("/", handler.CreateWarehouse)
+		r.Get("/", handler.ListWarehouses)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", handler.GetWarehouse)
+			r.Patch("/", handler.UpdateWarehouse)
+			r.Delete("/", handler.DeleteWarehouse)
+		})
+	})
+	r.Route("/customer-groups", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Post("/", handler.CreateCustomerGroup)
+		r.Get("/", handler.ListCustomerGroups)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", handler.GetCustomerGroup)
+			r.Patch("/", handler.UpdateCustomerGroup)
+			r.Delete("/", handler.DeleteCustomerGroup)
+		})
+	})
+	r.Route("/price-lists", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Post("/", handler.CreatePriceList)
+		r.Get("/", handler.ListPriceLists)
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", handler.GetPriceList)
+			r.Patch("/", handler.UpdatePriceList)
+			r.Delete("/", handler.DeletePriceList)
+		})
+	})
+	r.Route("/exchange-rates", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Get("/", handler.ListExchangeRates)
+		r.Put("/", handler.SetExchangeRates)
+	})
+	r.Route("/reviews", func(r chi.Router) {
+		r.Use(requireAdmin)
+		r.Get("/", handler.ListReviewQueue)
+		r.Patch("/{reviewID}", handler.ModerateReview)
+	})
+	r.Route("/categories", func(r chi.Router) {
+		r.With(requireAdmin).Post("/", handler.CreateCategory)
+		r.Get("/", handler.ListCategories)
+		r.Route("/{slug}", func(r chi.Router) {
+			r.Get("/", handler.GetCategory)
+			r.With(requireAdmin).Patch("/", handler.UpdateCategory)
+			r.With(requireAdmin).Delete("/", handler.DeleteCategory)
+			r.Get("/products", handler.ListCategoryProducts)
 		})
 	})
+	r.Get("/metrics", handler.Metrics)
 	return r
 }
 
@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/server"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// QuoteShipping prices the shipping methods available for sending the
+// caller's cart to an address.
+func (h *handler) QuoteShipping(w http.ResponseWriter, r *http.Request) {
+	var q ShippingQuoteReq
+	err := json.NewDecoder(r.Body).Decode(&q)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	quotes, err := h.server.QuoteShipping(h.ctx, cartOwner(r), server.Location(q.ShipTo))
+	if err != nil {
+		writeError(w, err, "error quoting shipping")
+		return
+	}
+	res := []ShippingQuoteRes{}
+	for _, sq := range quotes {
+		res = append(res, ShippingQuoteRes{
+			MethodID: sq.Method.ID,
+			Name:     sq.Method.Name,
+			Kind:     sq.Method.Kind,
+			Price:    sq.Price,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) CreateShippingZone(w http.ResponseWriter, r *http.Request) {
+	var z ShippingZoneReq
+	err := json.NewDecoder(r.Body).Decode(&z)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	zone := &storer.ShippingZone{}
+	toPatchShippingZone(zone, z)
+	created, err := h.server.CreateShippingZone(h.ctx, zone)
+	if err != nil {
+		writeError(w, err, "error creating shipping zone")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toShippingZoneRes(created))
+}
+
+func (h *handler) ListShippingZones(w http.ResponseWriter, r *http.Request) {
+	zones, err := h.server.ListShippingZones(h.ctx)
+	if err != nil {
+		http.Error(w, "error listing shipping zones", http.StatusInternalServerError)
+		return
+	}
+	res := []*ShippingZoneRes{}
+	for _, z := range zones {
+		res = append(res, toShippingZoneRes(&z))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetShippingZone(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	z, err := h.server.GetShippingZone(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting shipping zone")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toShippingZoneRes(z))
+}
+
+func (h *handler) UpdateShippingZone(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var z ShippingZoneReq
+	err = json.NewDecoder(r.Body).Decode(&z)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	zone, err := h.server.GetShippingZone(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting shipping zone")
+		return
+	}
+	toPatchShippingZone(zone, z)
+	updated, err := h.server.UpdateShippingZone(h.ctx, zone)
+	if err != nil {
+		writeError(w, err, "error updating shipping zone")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toShippingZoneRes(updated))
+}
+
+func (h *handler) DeleteShippingZone(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteShippingZone(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error deleting shipping zone")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) CreateShippingMethod(w http.ResponseWriter, r *http.Request) {
+	var m ShippingMethodReq
+	err := json.NewDecoder(r.Body).Decode(&m)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	// methods are offered unless created otherwise
+	method := &storer.ShippingMethod{Active: true}
+	toPatchShippingMethod(method, m)
+	created, err := h.server.CreateShippingMethod(h.ctx, method)
+	if err != nil {
+		writeError(w, err, "error creating shipping method")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toShippingMethodRes(created))
+}
+
+// ListShippingMethods lists every method, or those of the zone given by the
+// zone_id query parameter.
+func (h *handler) ListShippingMethods(w http.ResponseWriter, r *http.Request) {
+	var zoneID int64
+	if v := r.URL.Query().Get("zone_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "error parsing zone_id", http.StatusBadRequest)
+			return
+		}
+		zoneID = id
+	}
+	methods, err := h.server.ListShippingMethods(h.ctx, zoneID)
+	if err != nil {
+		http.Error(w, "error listing shipping methods", http.StatusInternalServerError)
+		return
+	}
+	res := []*ShippingMethodRes{}
+	for _, m := range methods {
+		res = append(res, toShippingMethodRes(&m))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	m, err := h.server.GetShippingMethod(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting shipping method")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toShippingMethodRes(m))
+}
+
+func (h *handler) UpdateShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var m ShippingMethodReq
+	err = json.NewDecoder(r.Body).Decode(&m)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	method, err := h.server.GetShippingMethod(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting shipping method")
+		return
+	}
+	toPatchShippingMethod(method, m)
+	updated, err := h.server.UpdateShippingMethod(h.ctx, method)
+	if err != nil {
+		writeError(w, err, "error updating shipping method")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toShippingMethodRes(updated))
+}
+
+func (h *handler) DeleteShippingMethod(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteShippingMethod(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error deleting shipping method")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toPatchShippingZone(zone *storer.ShippingZone, z ShippingZoneReq) {
+	if z.Name != "" {
+		zone.Name = z.Name
+	}
+	if z.Locations != nil {
+		zone.Locations = storer.ZoneLocations{}
+		for _, zl := range z.Locations {
+			zone.Locations = append(zone.Locations, storer.ZoneLocation(zl))
+		}
+	}
+}
+
+func toShippingZoneRes(z *storer.ShippingZone) *ShippingZoneRes {
+	res := &ShippingZoneRes{
+		ID:        z.ID,
+		Name:      z.Name,
+		Locations: []ZoneLocationReq{},
+		CreatedAt: z.CreatedAt,
+		UpdatedAt: z.UpdatedAt,
+	}
+	for _, zl := range z.Locations {
+		res.Locations = append(res.Locations, ZoneLocationReq(zl))
+	}
+	return res
+}
+
+func toPatchShippingMethod(method *storer.ShippingMethod, m ShippingMethodReq) {
+	if m.ZoneID != 0 {
+		method.ZoneID = m.ZoneID
+	}
+	if m.Name != "" {
+		method.Name = m.Name
+	}
+	if m.Kind != "" {
+		method.Kind = m.Kind
+	}
+	if m.Price != nil {
+		method.Price = *m.Price
+	}
+	if m.FreeOver != nil {
+		method.FreeOver = *m.FreeOver
+	}
+	if m.Tiers != nil {
+		method.Tiers = storer.ShippingTiers{}
+		for _, t := range m.Tiers {
+			method.Tiers = append(method.Tiers, storer.ShippingTier(t))
+		}
+	}
+	if m.Active != nil {
+		method.Active = *m.Active
+	}
+}
+
+func toShippingMethodRes(m *storer.ShippingMethod) *ShippingMethodRes {
+	res := &ShippingMethodRes{
+		ID:        m.ID,
+		ZoneID:    m.ZoneID,
+		Name:      m.Name,
+		Kind:      m.Kind,
+		Price:     m.Price,
+		FreeOver:  m.FreeOver,
+		Tiers:     []ShippingTierReq{},
+		Active:    m.Active,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+	for _, t := range m.Tiers {
+		res.Tiers = append(res.Tiers, ShippingTierReq(t))
+	}
+	return res
+}
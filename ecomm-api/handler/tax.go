@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateTaxRate(w http.ResponseWriter, r *http.Request) {
+	var tr TaxRateReq
+	err := json.NewDecoder(r.Body).Decode(&tr)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	rate := &storer.TaxRate{}
+	toPatchTaxRate(rate, tr)
+	created, err := h.server.CreateTaxRate(h.ctx, rate)
+	if err != nil {
+		writeError(w, err, "error creating tax rate")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toTaxRateRes(created))
+}
+
+// ListTaxRates lists every rate, or those of the country given by the
+// country query parameter.
+func (h *handler) ListTaxRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.server.ListTaxRates(h.ctx, r.URL.Query().Get("country"))
+	if err != nil {
+		http.Error(w, "error listing tax rates", http.StatusInternalServerError)
+		return
+	}
+	res := []*TaxRateRes{}
+	for _, tr := range rates {
+		res = append(res, toTaxRateRes(&tr))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	tr, err := h.server.GetTaxRate(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting tax rate")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toTaxRateRes(tr))
+}
+
+func (h *handler) UpdateTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var tr TaxRateReq
+	err = json.NewDecoder(r.Body).Decode(&tr)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	rate, err := h.server.GetTaxRate(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting tax rate")
+		return
+	}
+	toPatchTaxRate(rate, tr)
+	updated, err := h.server.UpdateTaxRate(h.ctx, rate)
+	if err != nil {
+		writeError(w, err, "error updating tax rate")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toTaxRateRes(updated))
+}
+
+func (h *handler) DeleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteTaxRate(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error deleting tax rate")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toPatchTaxRate(rate *storer.TaxRate, tr TaxRateReq) {
+	if tr.Name != "" {
+		rate.Name = tr.Name
+	}
+	if tr.Country != "" {
+		rate.Country = tr.Country
+	}
+	if tr.Region != nil {
+		rate.Region = *tr.Region
+	}
+	if tr.PostalPrefix != nil {
+		rate.PostalPrefix = *tr.PostalPrefix
+	}
+	if tr.TaxClass != "" {
+		rate.TaxClass = tr.TaxClass
+	}
+	if tr.Rate != nil {
+		rate.Rate = *tr.Rate
+	}
+	if tr.Priority != nil {
+		rate.Priority = *tr.Priority
+	}
+}
+
+func toTaxRateRes(tr *storer.TaxRate) *TaxRateRes {
+	return &TaxRateRes{
+		ID:           tr.ID,
+		Name:         tr.Name,
+		Country:      tr.Country,
+		Region:       tr.Region,
+		PostalPrefix: tr.PostalPrefix,
+		TaxClass:     tr.TaxClass,
+		Rate:         tr.Rate,
+		Priority:     tr.Priority,
+		CreatedAt:    tr.CreatedAt,
+		UpdatedAt:    tr.UpdatedAt,
+	}
+}
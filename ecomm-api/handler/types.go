@@ -1,27 +1,676 @@
 package handler
 
-import "time"
+import (
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+)
 
 type ProductReq struct {
-	Name         string  `json:"name"`
-	Image        string  `json:"image"`
-	Category     string  `json:"category"`
-	Description  string  `json:"description"`
-	Rating       int     `json:"rating"`
-	NumReviews   int     `json:"num_reviews"`
-	Price        float64 `json:"price"`
-	CountInStock int64   `json:"count_in_stock"`
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// Category is matched against category slugs when CategoryID is unset.
+	Category    string       `json:"category"`
+	CategoryID  *int64       `json:"category_id"`
+	Description string       `json:"description"`
+	Price       money.Amount `json:"price"`
+	// CountInStock is the opening stock on create; later changes go
+	// through stock adjustments.
+	CountInStock *int64 `json:"count_in_stock"`
+	// Weight is in kilograms and the dimensions in centimetres.
+	Weight *float64 `json:"weight"`
+	Length *float64 `json:"length"`
+	Width  *float64 `json:"width"`
+	Height *float64 `json:"height"`
+	// InventoryPolicy is deny, backorder or preorder; deny when empty on
+	// create. On update, setting it also replaces BackorderLimit and
+	// ReleaseDate.
+	InventoryPolicy string     `json:"inventory_policy"`
+	BackorderLimit  *int64     `json:"backorder_limit"`
+	ReleaseDate     *time.Time `json:"release_date"`
 }
 type ProductRes struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Image       string       `json:"image"`
+	Category    string       `json:"category"`
+	CategoryID  *int64       `json:"category_id"`
+	Description string       `json:"description"`
+	Rating      float64      `json:"rating"`
+	NumReviews  int          `json:"num_reviews"`
+	Price       money.Amount `json:"price"`
+	Currency    string       `json:"currency"`
+	// PriceTiers are the customer's cheaper unit prices for larger
+	// quantities.
+	PriceTiers   []PriceTierRes `json:"price_tiers,omitempty"`
+	CountInStock int64          `json:"count_in_stock"`
+	// Available is the stock less the units held for unpaid orders.
+	Available int64   `json:"available"`
+	Weight    float64 `json:"weight"`
+	Length    float64 `json:"length"`
+	Width     float64 `json:"width"`
+	Height    float64 `json:"height"`
+	// InventoryPolicy says whether the product takes orders once it is
+	// out of stock.
+	InventoryPolicy string     `json:"inventory_policy"`
+	BackorderLimit  *int64     `json:"backorder_limit"`
+	ReleaseDate     *time.Time `json:"release_date"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       *time.Time `json:"updated_at"`
+}
+type PriceTierRes struct {
+	MinQuantity int64        `json:"min_quantity"`
+	Price       money.Amount `json:"price"`
+}
+
+type CategoryReq struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	// ParentID places the category below another one; 0 makes it a
+	// top-level category.
+	ParentID *int64 `json:"parent_id"`
+	TaxClass string `json:"tax_class"`
+}
+type CategoryRes struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	ParentID  *int64     `json:"parent_id"`
+	TaxClass  string     `json:"tax_class"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type VariantReq struct {
+	SKU     string            `json:"sku"`
+	Options map[string]string `json:"options"`
+	// Price overrides the product's price; leave it out to inherit it.
+	Price *money.Amount `json:"price"`
+	// CountInStock is the opening stock on create; later changes go
+	// through stock adjustments.
+	CountInStock *int64 `json:"count_in_stock"`
+}
+type VariantRes struct {
+	ID           int64             `json:"id"`
+	ProductID    int64             `json:"product_id"`
+	SKU          string            `json:"sku"`
+	Options      map[string]string `json:"options"`
+	Price        *money.Amount     `json:"price"`
+	Currency     string            `json:"currency"`
+	CountInStock int64             `json:"count_in_stock"`
+	Available    int64             `json:"available"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    *time.Time        `json:"updated_at"`
+}
+
+type UserReq struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+type UserRes struct {
+	ID              int64  `json:"id"`
+	Name            string `json:"name"`
+	Email           string `json:"email"`
+	IsAdmin         bool   `json:"is_admin"`
+	CustomerGroupID *int64 `json:"customer_group_id"`
+}
+type LoginReq struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+type LoginRes struct {
+	Token string   `json:"token"`
+	User  *UserRes `json:"user"`
+}
+
+type ReviewReq struct {
+	Stars int    `json:"stars"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+type ReviewRes struct {
+	ID               int64      `json:"id"`
+	ProductID        int64      `json:"product_id"`
+	UserID           int64      `json:"user_id"`
+	Stars            int        `json:"stars"`
+	Title            string     `json:"title"`
+	Body             string     `json:"body"`
+	VerifiedPurchase bool       `json:"verified_purchase"`
+	Status           string     `json:"status"`
+	ReportCount      int        `json:"report_count"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at"`
+}
+
+type ReviewReportReq struct {
+	Reason string `json:"reason"`
+}
+type ReviewReportRes struct {
+	ID        int64     `json:"id"`
+	ReviewID  int64     `json:"review_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ModerateReviewReq struct {
+	Status string `json:"status"`
+}
+
+type CartItemReq struct {
+	ProductID int64  `json:"product_id"`
+	VariantID *int64 `json:"variant_id"`
+	Quantity  int64  `json:"quantity"`
+}
+type CartItemRes struct {
+	ID        int64        `json:"id"`
+	ProductID int64        `json:"product_id"`
+	VariantID *int64       `json:"variant_id"`
+	Name      string       `json:"name"`
+	Image     string       `json:"image"`
+	Price     money.Amount `json:"price"`
+	Quantity  int64        `json:"quantity"`
+	Available int64        `json:"available"`
+	InStock   bool         `json:"in_stock"`
+	// Backorderable items can be ordered beyond what is available.
+	Backorderable bool `json:"backorderable"`
+}
+type CartRes struct {
+	Items    []CartItemRes `json:"items"`
+	Subtotal money.Amount  `json:"subtotal"`
+}
+
+type LocationReq struct {
+	Country    string `json:"country"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+}
+type CheckoutReq struct {
+	PaymentMethod    string   `json:"payment_method"`
+	ShippingMethodID *int64   `json:"shipping_method_id"`
+	PromotionCodes   []string `json:"promotion_codes"`
+	// Addresses are picked from the address book by id or typed in. The
+	// shipping address defaults to the default address and the billing
+	// address to the shipping address.
+	ShippingAddressID *int64      `json:"shipping_address_id"`
+	ShippingAddress   *AddressReq `json:"shipping_address"`
+	BillingAddressID  *int64      `json:"billing_address_id"`
+	BillingAddress    *AddressReq `json:"billing_address"`
+	// Currency is what the order is priced and paid in, the base currency
+	// when left out.
+	Currency string `json:"currency"`
+}
+
+// CreateOrderReq orders the given items directly, bypassing the cart.
+type CreateOrderReq struct {
+	Items []CartItemReq `json:"items"`
+	CheckoutReq
+}
+
+type OrderItemRes struct {
+	ID        int64        `json:"id"`
+	ProductID int64        `json:"product_id"`
+	VariantID *int64       `json:"variant_id"`
+	Name      string       `json:"name"`
+	Image     string       `json:"image"`
+	Price     money.Amount `json:"price"`
+	Quantity  int64        `json:"quantity"`
+	// Backordered units were out of stock when the item was ordered.
+	Backordered int64             `json:"backordered"`
+	Taxes       []OrderItemTaxRes `json:"taxes"`
+	// Allocations say which warehouses fulfil the item.
+	Allocations []OrderItemAllocationRes `json:"allocations,omitempty"`
+}
+type OrderItemAllocationRes struct {
+	WarehouseID int64 `json:"warehouse_id"`
+	Quantity    int64 `json:"quantity"`
+	// Status is reserved until the order is paid, then taken; released
+	// units are no longer held for the order.
+	Status    string     `json:"status"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+type OrderItemTaxRes struct {
+	TaxRateID *int64       `json:"tax_rate_id"`
+	Name      string       `json:"name"`
+	Rate      float64      `json:"rate"`
+	Amount    money.Amount `json:"amount"`
+	// Included is set when the item's price already contained the tax.
+	Included bool `json:"included"`
+}
+type OrderRes struct {
+	ID               int64              `json:"id"`
+	UserID           int64              `json:"user_id"`
+	Status           string             `json:"status"`
+	PaymentMethod    string             `json:"payment_method"`
+	ShippingMethodID *int64             `json:"shipping_method_id"`
+	ShippingMethod   string             `json:"shipping_method"`
+	TaxPrice         money.Amount       `json:"tax_price"`
+	ShippingPrice    money.Amount       `json:"shipping_price"`
+	DiscountPrice    money.Amount       `json:"discount_price"`
+	TotalPrice       money.Amount       `json:"total_price"`
+	Currency         string             `json:"currency"`
+	ExchangeRate     float64            `json:"exchange_rate"`
+	Items            []OrderItemRes     `json:"items"`
+	Discounts        []OrderDiscountRes `json:"discounts"`
+	ShippingAddress  *OrderAddressRes   `json:"shipping_address"`
+	BillingAddress   *OrderAddressRes   `json:"billing_address"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        *time.Time         `json:"updated_at"`
+}
+
+type WishlistReq struct {
+	Name string `json:"name"`
+}
+type WishlistItemReq struct {
+	ProductID int64  `json:"product_id"`
+	VariantID *int64 `json:"variant_id"`
+}
+type MoveToCartReq struct {
+	Quantity int64 `json:"quantity"`
+}
+type MoveToWishlistReq struct {
+	WishlistID int64 `json:"wishlist_id"`
+}
+type WishlistItemRes struct {
+	ID           int64        `json:"id"`
+	ProductID    int64        `json:"product_id"`
+	VariantID    *int64       `json:"variant_id"`
+	Name         string       `json:"name"`
+	Image        string       `json:"image"`
+	Price        money.Amount `json:"price"`
+	SavedPrice   money.Amount `json:"saved_price"`
+	Available    int64        `json:"available"`
+	InStock      bool         `json:"in_stock"`
+	PriceDropped bool         `json:"price_dropped"`
+	BackInStock  bool         `json:"back_in_stock"`
+}
+type WishlistRes struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Shared    bool              `json:"shared"`
+	ShareLink string            `json:"share_link,omitempty"`
+	Items     []WishlistItemRes `json:"items"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt *time.Time        `json:"updated_at"`
+}
+
+type PromotionScopeReq struct {
+	ProductIDs  []int64 `json:"product_ids"`
+	CategoryIDs []int64 `json:"category_ids"`
+}
+type PromotionReq struct {
+	// Code is left out for promotions that apply automatically.
+	Code *string `json:"code"`
+	Name string  `json:"name"`
+	Kind string  `json:"kind"`
+	// Percent is the percentage off for percentage promotions and Amount
+	// the money off for fixed-amount ones.
+	Percent       *float64           `json:"percent"`
+	Amount        *money.Amount      `json:"amount"`
+	BuyQuantity   *int64             `json:"buy_quantity"`
+	GetQuantity   *int64             `json:"get_quantity"`
+	MinOrderValue *money.Amount      `json:"min_order_value"`
+	Scope         *PromotionScopeReq `json:"scope"`
+	UsageLimit    *int64             `json:"usage_limit"`
+	PerUserLimit  *int64             `json:"per_user_limit"`
+	Stackable     *bool              `json:"stackable"`
+	Active        *bool              `json:"active"`
+	StartsAt      *time.Time         `json:"starts_at"`
+	EndsAt        *time.Time         `json:"ends_at"`
+}
+type PromotionRes struct {
+	ID            int64             `json:"id"`
+	Code          *string           `json:"code"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind"`
+	Percent       float64           `json:"percent"`
+	Amount        money.Amount      `json:"amount"`
+	BuyQuantity   int64             `json:"buy_quantity"`
+	GetQuantity   int64             `json:"get_quantity"`
+	MinOrderValue money.Amount      `json:"min_order_value"`
+	Scope         PromotionScopeReq `json:"scope"`
+	UsageLimit    *int64            `json:"usage_limit"`
+	PerUserLimit  *int64            `json:"per_user_limit"`
+	TimesUsed     int64             `json:"times_used"`
+	Stackable     bool              `json:"stackable"`
+	Active        bool              `json:"active"`
+	StartsAt      *time.Time        `json:"starts_at"`
+	EndsAt        *time.Time        `json:"ends_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     *time.Time        `json:"updated_at"`
+}
+
+type OrderDiscountRes struct {
+	PromotionID *int64       `json:"promotion_id"`
+	Code        *string      `json:"code"`
+	Description string       `json:"description"`
+	Amount      money.Amount `json:"amount"`
+}
+
+type TaxRateReq struct {
+	Name         string   `json:"name"`
+	Country      string   `json:"country"`
+	Region       *string  `json:"region"`
+	PostalPrefix *string  `json:"postal_prefix"`
+	TaxClass     string   `json:"tax_class"`
+	Rate         *float64 `json:"rate"`
+	Priority     *int     `json:"priority"`
+}
+type TaxRateRes struct {
 	ID           int64      `json:"id"`
 	Name         string     `json:"name"`
-	Image        string     `json:"image"`
-	Category     string     `json:"category"`
-	Description  string     `json:"description"`
-	Rating       int        `json:"rating"`
-	NumReviews   int        `json:"num_reviews"`
-	Price        float64    `json:"price"`
-	CountInStock int64      `json:"count_in_stock"`
+	Country      string     `json:"country"`
+	Region       string     `json:"region"`
+	PostalPrefix string     `json:"postal_prefix"`
+	TaxClass     string     `json:"tax_class"`
+	Rate         float64    `json:"rate"`
+	Priority     int        `json:"priority"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    *time.Time `json:"updated_at"`
 }
+
+type ExchangeRateReq struct {
+	Currency string  `json:"currency"`
+	Rate     float64 `json:"rate"`
+}
+type ExchangeRatesRes struct {
+	BaseCurrency string            `json:"base_currency"`
+	Rates        []ExchangeRateRes `json:"rates"`
+}
+type ExchangeRateRes struct {
+	Currency  string    `json:"currency"`
+	Rate      float64   `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WarehouseReq struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Country  string  `json:"country"`
+	Region   *string `json:"region"`
+	Priority *int    `json:"priority"`
+	Active   *bool   `json:"active"`
+}
+type WarehouseRes struct {
+	ID        int64      `json:"id"`
+	Code      string     `json:"code"`
+	Name      string     `json:"name"`
+	Country   string     `json:"country"`
+	Region    string     `json:"region"`
+	Priority  int        `json:"priority"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+type StockLevelRes struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	VariantID   *int64 `json:"variant_id"`
+	Quantity    int64  `json:"quantity"`
+	// Reserved units are held for unpaid orders.
+	Reserved  int64      `json:"reserved"`
+	Available int64      `json:"available"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+type StockAdjustmentReq struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	VariantID   *int64 `json:"variant_id"`
+	// Quantity is added to the warehouse's stock; it is negative when
+	// units are taken out.
+	Quantity int64  `json:"quantity"`
+	Reason   string `json:"reason"`
+	Note     string `json:"note"`
+}
+type StockTransferReq struct {
+	FromWarehouseID int64  `json:"from_warehouse_id"`
+	ToWarehouseID   int64  `json:"to_warehouse_id"`
+	VariantID       *int64 `json:"variant_id"`
+	Quantity        int64  `json:"quantity"`
+	Note            string `json:"note"`
+}
+type InventoryMovementRes struct {
+	ID          int64  `json:"id"`
+	WarehouseID int64  `json:"warehouse_id"`
+	ProductID   int64  `json:"product_id"`
+	VariantID   *int64 `json:"variant_id"`
+	Quantity    int64  `json:"quantity"`
+	// Kind says what moved the stock: order, cancellation, return,
+	// adjustment, transfer or import.
+	Kind      string    `json:"kind"`
+	Reason    string    `json:"reason,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	OrderID   *int64    `json:"order_id"`
+	UserID    *int64    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CustomerGroupReq struct {
+	Name string `json:"name"`
+}
+type CustomerGroupRes struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+type UserCustomerGroupReq struct {
+	// CustomerGroupID null returns the user to catalog prices.
+	CustomerGroupID *int64 `json:"customer_group_id"`
+}
+
+type PriceListEntryReq struct {
+	ProductID   *int64        `json:"product_id"`
+	CategoryID  *int64        `json:"category_id"`
+	MinQuantity int64         `json:"min_quantity"`
+	Price       *money.Amount `json:"price"`
+	PercentOff  float64       `json:"percent_off"`
+}
+type PriceListReq struct {
+	CustomerGroupID int64               `json:"customer_group_id"`
+	Name            string              `json:"name"`
+	Active          *bool               `json:"active"`
+	Entries         []PriceListEntryReq `json:"entries"`
+}
+type PriceListEntryRes struct {
+	ProductID   *int64        `json:"product_id,omitempty"`
+	CategoryID  *int64        `json:"category_id,omitempty"`
+	MinQuantity int64         `json:"min_quantity"`
+	Price       *money.Amount `json:"price,omitempty"`
+	PercentOff  float64       `json:"percent_off,omitempty"`
+}
+type PriceListRes struct {
+	ID              int64               `json:"id"`
+	CustomerGroupID int64               `json:"customer_group_id"`
+	Name            string              `json:"name"`
+	Active          bool                `json:"active"`
+	Entries         []PriceListEntryRes `json:"entries"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       *time.Time          `json:"updated_at"`
+}
+
+type ShippingQuoteReq struct {
+	ShipTo LocationReq `json:"ship_to"`
+}
+type ShippingQuoteRes struct {
+	MethodID int64        `json:"method_id"`
+	Name     string       `json:"name"`
+	Kind     string       `json:"kind"`
+	Price    money.Amount `json:"price"`
+}
+
+type ZoneLocationReq struct {
+	Country      string `json:"country"`
+	Region       string `json:"region"`
+	PostalPrefix string `json:"postal_prefix"`
+}
+type ShippingZoneReq struct {
+	Name      string            `json:"name"`
+	Locations []ZoneLocationReq `json:"locations"`
+}
+type ShippingZoneRes struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Locations []ZoneLocationReq `json:"locations"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt *time.Time        `json:"updated_at"`
+}
+
+// ShippingTierReq starts at FromWeight kilograms for weight methods or at
+// an order value of FromValue for price-tiered ones.
+type ShippingTierReq struct {
+	FromWeight float64      `json:"from_weight,omitempty"`
+	FromValue  money.Amount `json:"from_value,omitempty"`
+	Price      money.Amount `json:"price"`
+}
+type ShippingMethodReq struct {
+	ZoneID   int64             `json:"zone_id"`
+	Name     string            `json:"name"`
+	Kind     string            `json:"kind"`
+	Price    *money.Amount     `json:"price"`
+	FreeOver *money.Amount     `json:"free_over"`
+	Tiers    []ShippingTierReq `json:"tiers"`
+	Active   *bool             `json:"active"`
+}
+type ShippingMethodRes struct {
+	ID        int64             `json:"id"`
+	ZoneID    int64             `json:"zone_id"`
+	Name      string            `json:"name"`
+	Kind      string            `json:"kind"`
+	Price     money.Amount      `json:"price"`
+	FreeOver  money.Amount      `json:"free_over"`
+	Tiers     []ShippingTierReq `json:"tiers"`
+	Active    bool              `json:"active"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt *time.Time        `json:"updated_at"`
+}
+
+type AddressReq struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+	IsDefault  *bool  `json:"is_default"`
+}
+type AddressRes struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Line1      string     `json:"line1"`
+	Line2      string     `json:"line2"`
+	City       string     `json:"city"`
+	Region     string     `json:"region"`
+	PostalCode string     `json:"postal_code"`
+	Country    string     `json:"country"`
+	Phone      string     `json:"phone"`
+	IsDefault  bool       `json:"is_default"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+type OrderAddressRes struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+	Phone      string `json:"phone"`
+}
+
+type PaymentReq struct {
+	// Provider defaults to the order's payment method, then to the
+	// server's default provider.
+	Provider string `json:"provider"`
+	// PaymentToken is the payment method the client tokenized with the
+	// provider.
+	PaymentToken string `json:"payment_token"`
+}
+type PaymentRes struct {
+	ID            int64        `json:"id"`
+	OrderID       int64        `json:"order_id"`
+	Provider      string       `json:"provider"`
+	ProviderRef   string       `json:"provider_ref"`
+	Amount        money.Amount `json:"amount"`
+	Currency      string       `json:"currency"`
+	Status        string       `json:"status"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     *time.Time   `json:"updated_at"`
+}
+type PaymentEventRes struct {
+	ID          int64      `json:"id"`
+	Provider    string     `json:"provider"`
+	EventID     string     `json:"event_id"`
+	Type        string     `json:"type"`
+	PaymentRef  string     `json:"payment_ref"`
+	Payload     string     `json:"payload"`
+	Error       string     `json:"error,omitempty"`
+	ReceivedAt  time.Time  `json:"received_at"`
+	ProcessedAt *time.Time `json:"processed_at"`
+}
+type RefundReq struct {
+	// Amount defaults to what was paid for Items, or to everything not yet
+	// refunded when no items are given.
+	Amount money.Amount    `json:"amount"`
+	Items  []RefundItemReq `json:"items"`
+	Reason string          `json:"reason"`
+	// Restock puts the refunded items back into stock; it defaults to true.
+	Restock *bool `json:"restock"`
+}
+type RefundItemReq struct {
+	OrderItemID int64 `json:"order_item_id"`
+	Quantity    int64 `json:"quantity"`
+}
+type RefundRes struct {
+	ID            int64           `json:"id"`
+	OrderID       int64           `json:"order_id"`
+	PaymentID     int64           `json:"payment_id"`
+	Amount        money.Amount    `json:"amount"`
+	Reason        string          `json:"reason"`
+	Restock       bool            `json:"restock"`
+	Status        string          `json:"status"`
+	ProviderRef   string          `json:"provider_ref"`
+	FailureReason string          `json:"failure_reason,omitempty"`
+	Items         []RefundItemRes `json:"items"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     *time.Time      `json:"updated_at"`
+}
+type RefundItemRes struct {
+	OrderItemID int64 `json:"order_item_id"`
+	Quantity    int64 `json:"quantity"`
+}
+type ReturnReq struct {
+	Items []ReturnItemReq `json:"items"`
+	Note  string          `json:"note"`
+}
+type ReturnItemReq struct {
+	OrderItemID int64  `json:"order_item_id"`
+	Quantity    int64  `json:"quantity"`
+	ReasonCode  string `json:"reason_code"`
+}
+type UpdateReturnReq struct {
+	Status    string  `json:"status"`
+	AdminNote *string `json:"admin_note"`
+	// Restock decides whether accepting the return puts its items back
+	// into stock; returns restock unless told otherwise.
+	Restock *bool `json:"restock"`
+}
+type ReturnRes struct {
+	ID        int64           `json:"id"`
+	OrderID   int64           `json:"order_id"`
+	Status    string          `json:"status"`
+	Note      string          `json:"note"`
+	AdminNote string          `json:"admin_note"`
+	Restock   bool            `json:"restock"`
+	RefundID  *int64          `json:"refund_id"`
+	Items     []ReturnItemRes `json:"items"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt *time.Time      `json:"updated_at"`
+}
+type ReturnItemRes struct {
+	OrderItemID int64  `json:"order_item_id"`
+	Quantity    int64  `json:"quantity"`
+	ReasonCode  string `json:"reason_code"`
+}
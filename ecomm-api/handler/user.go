@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) RegisterUser(w http.ResponseWriter, r *http.Request) {
+	var u UserReq
+	err := json.NewDecoder(r.Body).Decode(&u)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	created, err := h.server.RegisterUser(h.ctx, &storer.User{Name: u.Name, Email: u.Email, Password: u.Password})
+	if err != nil {
+		writeError(w, err, "error registering user")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toUserRes(created))
+}
+
+func (h *handler) Login(w http.ResponseWriter, r *http.Request) {
+	var l LoginReq
+	err := json.NewDecoder(r.Body).Decode(&l)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	u, err := h.server.Authenticate(h.ctx, l.Email, l.Password)
+	if err != nil {
+		writeError(w, err, "invalid email or password")
+		return
+	}
+	// the guest cart follows the shopper into their account; a failed merge
+	// leaves it in place and must not stop them logging in
+	if t := guestCartToken(r); t != "" {
+		if err := h.server.MergeGuestCart(h.ctx, u.ID, t); err != nil {
+			log.Printf("error merging guest cart of user %d: %v", u.ID, err)
+		} else {
+			clearCartToken(w)
+		}
+	}
+	token, err := h.tokens.NewToken(u.ID, u.IsAdmin)
+	if err != nil {
+		http.Error(w, "error creating token", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(LoginRes{Token: token, User: toUserRes(u)})
+}
+
+func (h *handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	u, err := h.server.GetUser(h.ctx, claimsFrom(r).UserID)
+	if err != nil {
+		writeError(w, err, "error getting user")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toUserRes(u))
+}
+
+func toUserRes(u *storer.User) *UserRes {
+	return &UserRes{
+		ID:              u.ID,
+		Name:            u.Name,
+		Email:           u.Email,
+		IsAdmin:         u.IsAdmin,
+		CustomerGroupID: u.CustomerGroupID,
+	}
+}
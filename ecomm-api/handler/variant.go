@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/server"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var v VariantReq
+	err = json.NewDecoder(r.Body).Decode(&v)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	created, err := h.server.CreateVariant(h.ctx, claimsFrom(r), toStorerVariant(productID, v))
+	if err != nil {
+		writeError(w, err, "error creating variant")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toVariantRes(created, h.server.BaseConversion()))
+}
+
+func (h *handler) ListVariants(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	conv, err := h.server.Conversion(h.ctx, requestCurrency(r))
+	if err != nil {
+		writeError(w, err, "error listing variants")
+		return
+	}
+	product, book, err := h.variantPricing(r, productID)
+	if err != nil {
+		writeError(w, err, "error listing variants")
+		return
+	}
+	variants, err := h.server.ListVariants(h.ctx, productID)
+	if err != nil {
+		http.Error(w, "error listing variants", http.StatusInternalServerError)
+		return
+	}
+	res := []*VariantRes{}
+	for _, v := range variants {
+		res = append(res, toCustomerVariantRes(&v, product, conv, book))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := parseVariantPath(r)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	conv, err := h.server.Conversion(h.ctx, requestCurrency(r))
+	if err != nil {
+		writeError(w, err, "error getting variant")
+		return
+	}
+	product, book, err := h.variantPricing(r, productID)
+	if err != nil {
+		writeError(w, err, "error getting variant")
+		return
+	}
+	v, err := h.server.GetVariant(h.ctx, productID, variantID)
+	if err != nil {
+		writeError(w, err, "error getting variant")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCustomerVariantRes(v, product, conv, book))
+}
+
+func (h *handler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := parseVariantPath(r)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var v VariantReq
+	err = json.NewDecoder(r.Body).Decode(&v)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	if v.CountInStock != nil {
+		http.Error(w, "count_in_stock is changed through stock adjustments", http.StatusBadRequest)
+		return
+	}
+	variant, err := h.server.GetVariant(h.ctx, productID, variantID)
+	if err != nil {
+		writeError(w, err, "error getting variant")
+		return
+	}
+	toPatchVariant(variant, v)
+	updated, err := h.server.UpdateVariant(h.ctx, variant)
+	if err != nil {
+		writeError(w, err, "error updating variant")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toVariantRes(updated, h.server.BaseConversion()))
+}
+
+func (h *handler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := parseVariantPath(r)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteVariant(h.ctx, productID, variantID)
+	if err != nil {
+		writeError(w, err, "error deleting variant")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseVariantPath(r *http.Request) (int64, int64, error) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	variantID, err := strconv.ParseInt(chi.URLParam(r, "variantID"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return productID, variantID, nil
+}
+
+func toStorerVariant(productID int64, v VariantReq) *storer.Variant {
+	variant := &storer.Variant{
+		ProductID: productID,
+		SKU:       v.SKU,
+		Options:   v.Options,
+		Price:     v.Price,
+		CreatedAt: time.Now(),
+	}
+	if v.CountInStock != nil {
+		variant.CountInStock = *v.CountInStock
+	}
+	return variant
+}
+
+// toVariantRes shows the variant with its price override, if any, in
+// conv's currency.
+func toVariantRes(v *storer.Variant, conv server.Conversion) *VariantRes {
+	res := &VariantRes{
+		ID:           v.ID,
+		ProductID:    v.ProductID,
+		SKU:          v.SKU,
+		Options:      v.Options,
+		Currency:     conv.Currency,
+		CountInStock: v.CountInStock,
+		Available:    v.Available(),
+		CreatedAt:    v.CreatedAt,
+		UpdatedAt:    v.UpdatedAt,
+	}
+	if v.Price != nil {
+		price := conv.Convert(*v.Price)
+		res.Price = &price
+	}
+	return res
+}
+
+func toPatchVariant(variant *storer.Variant, v VariantReq) {
+	if v.SKU != "" {
+		variant.SKU = v.SKU
+	}
+	if v.Options != nil {
+		variant.Options = v.Options
+	}
+	if v.Price != nil {
+		variant.Price = v.Price
+	}
+}
@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/server"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (h *handler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req WarehouseReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	// warehouses fulfil orders unless created otherwise
+	wh := &storer.Warehouse{Active: true}
+	toPatchWarehouse(wh, req)
+	created, err := h.server.CreateWarehouse(h.ctx, wh)
+	if err != nil {
+		writeError(w, err, "error creating warehouse")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toWarehouseRes(created))
+}
+
+func (h *handler) ListWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := h.server.ListWarehouses(h.ctx)
+	if err != nil {
+		http.Error(w, "error listing warehouses", http.StatusInternalServerError)
+		return
+	}
+	res := []*WarehouseRes{}
+	for _, wh := range warehouses {
+		res = append(res, toWarehouseRes(&wh))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	wh, err := h.server.GetWarehouse(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting warehouse")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWarehouseRes(wh))
+}
+
+func (h *handler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req WarehouseReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	wh, err := h.server.GetWarehouse(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error getting warehouse")
+		return
+	}
+	toPatchWarehouse(wh, req)
+	updated, err := h.server.UpdateWarehouse(h.ctx, wh)
+	if err != nil {
+		writeError(w, err, "error updating warehouse")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWarehouseRes(updated))
+}
+
+func (h *handler) DeleteWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteWarehouse(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error deleting warehouse")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListStockLevels lists what each warehouse holds of the product and its
+// variants.
+func (h *handler) ListStockLevels(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	levels, err := h.server.ListStockLevels(h.ctx, id)
+	if err != nil {
+		writeError(w, err, "error listing stock levels")
+		return
+	}
+	res := []StockLevelRes{}
+	for _, l := range levels {
+		res = append(res, StockLevelRes{
+			WarehouseID: l.WarehouseID,
+			VariantID:   l.VariantID,
+			Quantity:    l.Quantity,
+			Reserved:    l.Reserved,
+			Available:   l.Available(),
+			UpdatedAt:   l.UpdatedAt,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req StockAdjustmentReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	m, err := h.server.AdjustStock(h.ctx, claimsFrom(r), &storer.InventoryMovement{
+		WarehouseID: req.WarehouseID,
+		ProductID:   id,
+		VariantID:   req.VariantID,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		Note:        req.Note,
+	})
+	if err != nil {
+		writeError(w, err, "error adjusting stock")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toInventoryMovementRes(m))
+}
+
+// TransferStock moves stock between warehouses and returns the movements
+// recording both sides.
+func (h *handler) TransferStock(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req StockTransferReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	movements, err := h.server.TransferStock(h.ctx, claimsFrom(r), server.StockTransfer{
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		ProductID:       id,
+		VariantID:       req.VariantID,
+		Quantity:        req.Quantity,
+		Note:            req.Note,
+	})
+	if err != nil {
+		writeError(w, err, "error transferring stock")
+		return
+	}
+	res := []*InventoryMovementRes{}
+	for _, m := range movements {
+		res = append(res, toInventoryMovementRes(&m))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+// ListInventoryHistory lists the product's inventory movements, newest
+// first, optionally of one warehouse and paged with limit and offset.
+func (h *handler) ListInventoryHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	f, err := toMovementFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	movements, err := h.server.ListInventoryHistory(h.ctx, id, f)
+	if err != nil {
+		writeError(w, err, "error listing inventory history")
+		return
+	}
+	res := []*InventoryMovementRes{}
+	for _, m := range movements {
+		res = append(res, toInventoryMovementRes(&m))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func toMovementFilter(r *http.Request) (storer.MovementFilter, error) {
+	q := r.URL.Query()
+	var f storer.MovementFilter
+	var err error
+	if v := q.Get("warehouse_id"); v != "" {
+		if f.WarehouseID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return f, fmt.Errorf("error parsing warehouse_id")
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+			return f, fmt.Errorf("error parsing limit")
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		if f.Offset, err = strconv.Atoi(v); err != nil || f.Offset < 0 {
+			return f, fmt.Errorf("error parsing offset")
+		}
+	}
+	return f, nil
+}
+
+func toPatchWarehouse(wh *storer.Warehouse, req WarehouseReq) {
+	if req.Code != "" {
+		wh.Code = req.Code
+	}
+	if req.Name != "" {
+		wh.Name = req.Name
+	}
+	if req.Country != "" {
+		wh.Country = req.Country
+	}
+	if req.Region != nil {
+		wh.Region = *req.Region
+	}
+	if req.Priority != nil {
+		wh.Priority = *req.Priority
+	}
+	if req.Active != nil {
+		wh.Active = *req.Active
+	}
+}
+
+func toWarehouseRes(wh *storer.Warehouse) *WarehouseRes {
+	return &WarehouseRes{
+		ID:        wh.ID,
+		Code:      wh.Code,
+		Name:      wh.Name,
+		Country:   wh.Country,
+		Region:    wh.Region,
+		Priority:  wh.Priority,
+		Active:    wh.Active,
+		CreatedAt: wh.CreatedAt,
+		UpdatedAt: wh.UpdatedAt,
+	}
+}
+
+func toInventoryMovementRes(m *storer.InventoryMovement) *InventoryMovementRes {
+	return &InventoryMovementRes{
+		ID:          m.ID,
+		WarehouseID: m.WarehouseID,
+		ProductID:   m.ProductID,
+		VariantID:   m.VariantID,
+		Quantity:    m.Quantity,
+		Kind:        m.Kind,
+		Reason:      m.Reason,
+		Note:        m.Note,
+		OrderID:     m.OrderID,
+		UserID:      m.UserID,
+		CreatedAt:   m.CreatedAt,
+	}
+}
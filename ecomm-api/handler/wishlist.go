@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/m21power/ecomm/ecomm-api/server"
+)
+
+func (h *handler) CreateWishlist(w http.ResponseWriter, r *http.Request) {
+	var wr WishlistReq
+	err := json.NewDecoder(r.Body).Decode(&wr)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.server.CreateWishlist(h.ctx, claimsFrom(r).UserID, wr.Name)
+	if err != nil {
+		writeError(w, err, "error creating wishlist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+func (h *handler) ListWishlists(w http.ResponseWriter, r *http.Request) {
+	wls, err := h.server.ListWishlists(h.ctx, claimsFrom(r).UserID)
+	if err != nil {
+		writeError(w, err, "error listing wishlists")
+		return
+	}
+	res := []*WishlistRes{}
+	for _, wl := range wls {
+		res = append(res, toWishlistRes(&wl))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) GetWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.server.GetWishlist(h.ctx, claimsFrom(r).UserID, id)
+	if err != nil {
+		writeError(w, err, "error getting wishlist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+// GetSharedWishlist serves a wishlist's public read-only link.
+func (h *handler) GetSharedWishlist(w http.ResponseWriter, r *http.Request) {
+	wl, err := h.server.GetSharedWishlist(h.ctx, chi.URLParam(r, "token"))
+	if err != nil {
+		writeError(w, err, "error getting wishlist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+func (h *handler) UpdateWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var wr WishlistReq
+	err = json.NewDecoder(r.Body).Decode(&wr)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.server.RenameWishlist(h.ctx, claimsFrom(r).UserID, id, wr.Name)
+	if err != nil {
+		writeError(w, err, "error updating wishlist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+func (h *handler) DeleteWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteWishlist(h.ctx, claimsFrom(r).UserID, id)
+	if err != nil {
+		writeError(w, err, "error deleting wishlist")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShareWishlist issues a new public link for the wishlist. The link is only
+// returned in this response.
+func (h *handler) ShareWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.server.ShareWishlist(h.ctx, claimsFrom(r).UserID, id)
+	if err != nil {
+		writeError(w, err, "error sharing wishlist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+func (h *handler) UnshareWishlist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.server.UnshareWishlist(h.ctx, claimsFrom(r).UserID, id)
+	if err != nil {
+		writeError(w, err, "error unsharing wishlist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+func (h *handler) AddWishlistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var wi WishlistItemReq
+	err = json.NewDecoder(r.Body).Decode(&wi)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.server.AddWishlistItem(h.ctx, claimsFrom(r).UserID, id, wi.ProductID, wi.VariantID)
+	if err != nil {
+		writeError(w, err, "error adding wishlist item")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+func (h *handler) DeleteWishlistItem(w http.ResponseWriter, r *http.Request) {
+	id, itemID, err := parseWishlistItemPath(r)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.server.DeleteWishlistItem(h.ctx, claimsFrom(r).UserID, id, itemID)
+	if err != nil {
+		writeError(w, err, "error deleting wishlist item")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+func (h *handler) MoveWishlistItemToCart(w http.ResponseWriter, r *http.Request) {
+	id, itemID, err := parseWishlistItemPath(r)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	// the body is optional; without one a single unit is moved
+	m := MoveToCartReq{Quantity: 1}
+	err = json.NewDecoder(r.Body).Decode(&m)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	c, err := h.server.MoveWishlistItemToCart(h.ctx, claimsFrom(r).UserID, id, itemID, m.Quantity)
+	if err != nil {
+		writeError(w, err, "error moving wishlist item to cart")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toCartRes(c))
+}
+
+func (h *handler) MoveCartItemToWishlist(w http.ResponseWriter, r *http.Request) {
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "itemID"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var m MoveToWishlistReq
+	err = json.NewDecoder(r.Body).Decode(&m)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	wl, err := h.server.MoveCartItemToWishlist(h.ctx, claimsFrom(r).UserID, itemID, m.WishlistID)
+	if err != nil {
+		writeError(w, err, "error moving cart item to wishlist")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWishlistRes(wl))
+}
+
+func parseWishlistItemPath(r *http.Request) (int64, int64, error) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "itemID"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, itemID, nil
+}
+
+func toWishlistRes(wl *server.PricedWishlist) *WishlistRes {
+	res := &WishlistRes{
+		ID:        wl.ID,
+		Name:      wl.Name,
+		Shared:    wl.ShareToken != nil,
+		Items:     []WishlistItemRes{},
+		CreatedAt: wl.CreatedAt,
+		UpdatedAt: wl.UpdatedAt,
+	}
+	if wl.Token != "" {
+		res.ShareLink = "/wishlists/shared/" + wl.Token
+	}
+	for _, l := range wl.Lines {
+		res.Items = append(res.Items, WishlistItemRes{
+			ID:           l.ID,
+			ProductID:    l.ProductID,
+			VariantID:    l.VariantID,
+			Name:         l.Name,
+			Image:        l.Image,
+			Price:        l.Price,
+			SavedPrice:   l.SavedPrice,
+			Available:    l.Available,
+			InStock:      l.Available > 0,
+			PriceDropped: l.PriceDropped,
+			BackInStock:  l.BackInStock,
+		})
+	}
+	return res
+}
@@ -0,0 +1,147 @@
+// Package money represents amounts of money exactly. An Amount counts minor
+// units, cents, so sums never pick up the rounding errors of floating point.
+// Amounts are stored in decimal(10,2) columns and travel in JSON as decimal
+// strings such as "12.50".
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a quantity of money in minor units.
+type Amount int64
+
+// minorUnits is how many minor units make up a major one.
+const (
+	minorUnits = 100
+	decimals   = 2
+)
+
+// Parse reads a decimal such as "12.5" or "-0.05". More than two decimals
+// are refused unless they are zeros, since the amount could not be kept
+// exactly.
+func Parse(s string) (Amount, error) {
+	orig := s
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", orig)
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > decimals {
+		return 0, fmt.Errorf("invalid amount %q: more than %d decimals", orig, decimals)
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+	digits := whole + frac
+	if strings.TrimLeft(digits, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid amount %q", orig)
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %w", orig, err)
+	}
+	if neg {
+		n = -n
+	}
+	return Amount(n), nil
+}
+
+// FromFloat converts f, in major units, to the nearest amount.
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * minorUnits))
+}
+
+// Float64 returns a in major units, for comparisons with thresholds and for
+// rates; arithmetic on money should stay on Amount.
+func (a Amount) Float64() float64 {
+	return float64(a) / minorUnits
+}
+
+// Mul returns a times n, e.g. a unit price times a quantity.
+func (a Amount) Mul(n int64) Amount {
+	return a * Amount(n)
+}
+
+// MulRate returns a times r rounded to the nearest minor unit, halves away
+// from zero.
+func (a Amount) MulRate(r float64) Amount {
+	return Amount(math.Round(float64(a) * r))
+}
+
+// Share returns a's share of part in whole, e.g. how much of an order's
+// discount falls on one of its lines.
+func (a Amount) Share(part, whole Amount) Amount {
+	if whole == 0 {
+		return 0
+	}
+	return a.MulRate(float64(part) / float64(whole))
+}
+
+func (a Amount) String() string {
+	sign := ""
+	n := int64(a)
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, n/minorUnits, n%minorUnits)
+}
+
+// Value stores a as a decimal string, which MySQL converts exactly.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan reads decimal columns, which the driver returns as text. Numbers are
+// taken to be in major units.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+	case []byte:
+		return a.parse(string(v))
+	case string:
+		return a.parse(v)
+	case int64:
+		*a = Amount(v * minorUnits)
+	case float64:
+		*a = FromFloat(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money.Amount", src)
+	}
+	return nil
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts a decimal string or, for convenience, a JSON
+// number, which is read from its text and not through a float.
+func (a *Amount) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+	return a.parse(s)
+}
+
+func (a *Amount) parse(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
@@ -0,0 +1,85 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tcs := []struct {
+		in   string
+		want Amount
+		ok   bool
+	}{
+		{in: "12.34", want: 1234, ok: true},
+		{in: "12.3", want: 1230, ok: true},
+		{in: "12", want: 1200, ok: true},
+		{in: "0.05", want: 5, ok: true},
+		{in: "-0.05", want: -5, ok: true},
+		{in: ".5", want: 50, ok: true},
+		{in: "19.9900", want: 1999, ok: true},
+		{in: "19.999"},
+		{in: "1e3"},
+		{in: "abc"},
+		{in: ""},
+		{in: "-"},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := Parse(tc.in)
+			if !tc.ok {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAmount(t *testing.T) {
+	require.Equal(t, "12.34", Amount(1234).String())
+	require.Equal(t, "-0.05", Amount(-5).String())
+	require.Equal(t, "0.00", Amount(0).String())
+
+	// 0.1 + 0.2 is exact in minor units
+	require.Equal(t, Amount(30), FromFloat(0.1)+FromFloat(0.2))
+	require.Equal(t, Amount(2997), Amount(999).Mul(3))
+	require.Equal(t, Amount(125), Amount(1250).MulRate(0.1))
+	require.Equal(t, Amount(3), Amount(5).MulRate(0.5))
+	require.Equal(t, Amount(333), Amount(1000).Share(1, 3))
+
+	b, err := json.Marshal(struct {
+		Price Amount `json:"price"`
+	}{Price: 1999})
+	require.NoError(t, err)
+	require.Equal(t, `{"price":"19.99"}`, string(b))
+
+	var v struct {
+		A Amount  `json:"a"`
+		B Amount  `json:"b"`
+		C *Amount `json:"c"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":"19.99","b":0.1,"c":"5"}`), &v))
+	require.Equal(t, Amount(1999), v.A)
+	require.Equal(t, Amount(10), v.B)
+	require.Equal(t, Amount(500), *v.C)
+	require.Error(t, json.Unmarshal([]byte(`{"a":"19.999"}`), &v))
+}
+
+func TestScan(t *testing.T) {
+	var a Amount
+	require.NoError(t, a.Scan([]byte("10.50")))
+	require.Equal(t, Amount(1050), a)
+	require.NoError(t, a.Scan(int64(3)))
+	require.Equal(t, Amount(300), a)
+	require.NoError(t, a.Scan(19.99))
+	require.Equal(t, Amount(1999), a)
+	require.NoError(t, a.Scan(nil))
+	require.Equal(t, Amount(0), a)
+	v, err := Amount(1050).Value()
+	require.NoError(t, err)
+	require.Equal(t, "10.50", v)
+}
@@ -0,0 +1,244 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+)
+
+// FakeSignatureHeader carries the signature of the fake gateway's webhooks.
+const FakeSignatureHeader = "Fake-Signature"
+
+// FakeScenario is how the fake gateway treats a payment token.
+type FakeScenario string
+
+const (
+	FakeApprove        FakeScenario = "approve"
+	FakeDecline        FakeScenario = "decline"
+	FakeCaptureDecline FakeScenario = "capture_decline"
+	FakeUnavailable    FakeScenario = "unavailable"
+)
+
+// ParseFakeScenario parses the name of a scenario.
+func ParseFakeScenario(s string) (FakeScenario, error) {
+	switch sc := FakeScenario(s); sc {
+	case FakeApprove, FakeDecline, FakeCaptureDecline, FakeUnavailable:
+		return sc, nil
+	}
+	return "", fmt.Errorf("unknown fake payment scenario %q", s)
+}
+
+// DefaultFakeScenarios are the test tokens every fake gateway knows. Any
+// other token is approved.
+var DefaultFakeScenarios = map[string]FakeScenario{
+	"tok_approve":         FakeApprove,
+	"tok_decline":         FakeDecline,
+	"tok_capture_decline": FakeCaptureDecline,
+	"tok_unavailable":     FakeUnavailable,
+}
+
+// Fake is an in-memory gateway for local development and tests. It is
+// deterministic: refs and event IDs are numbered in order and the outcome of
+// a payment depends only on its token's scenario. Like a real gateway it
+// reports captures, failures and voids through signed webhooks, which it
+// delivers once DeliverTo has been called.
+type Fake struct {
+	secret []byte
+	client *http.Client
+
+	mu          sync.Mutex
+	scenarios   map[string]FakeScenario
+	next        int
+	nextEvent   int
+	payments    map[string]*fakePayment
+	callbackURL string
+}
+
+var _ WebhookProvider = (*Fake)(nil)
+
+type fakePayment struct {
+	scenario   FakeScenario
+	currency   string
+	authorized money.Amount
+	captured   money.Amount
+	refunded   money.Amount
+	voided     bool
+}
+
+// NewFake returns a fake gateway signing its webhooks with secret and
+// knowing DefaultFakeScenarios and, taking precedence, scenarios.
+func NewFake(secret []byte, scenarios map[string]FakeScenario) *Fake {
+	f := &Fake{
+		secret:    secret,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		scenarios: map[string]FakeScenario{},
+		payments:  map[string]*fakePayment{},
+	}
+	for tok, sc := range DefaultFakeScenarios {
+		f.scenarios[tok] = sc
+	}
+	for tok, sc := range scenarios {
+		f.scenarios[tok] = sc
+	}
+	return f
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) Authorize(ctx context.Context, c Charge) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sc, ok := f.scenarios[c.Token]
+	if !ok {
+		sc = FakeApprove
+	}
+	switch {
+	case sc == FakeDecline:
+		return nil, fmt.Errorf("%w: card declined", ErrDeclined)
+	case sc == FakeUnavailable:
+		return nil, fmt.Errorf("%w: fake gateway is down", ErrUnavailable)
+	case c.Amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", ErrDeclined)
+	}
+	f.next++
+	ref := fmt.Sprintf("fake_%d", f.next)
+	f.payments[ref] = &fakePayment{scenario: sc, currency: c.Currency, authorized: c.Amount}
+	return &Result{Ref: ref}, nil
+}
+
+func (f *Fake) Capture(ctx context.Context, ref string, amount money.Amount) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := f.payment(ref)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.scenario == FakeCaptureDecline:
+		f.emit(Event{Type: EventFailed, Ref: ref, Amount: amount, Currency: p.currency, Reason: "capture declined"})
+		return nil, fmt.Errorf("%w: capture declined", ErrDeclined)
+	case p.voided:
+		return nil, fmt.Errorf("%w: authorization was voided", ErrDeclined)
+	case amount <= 0 || p.captured+amount > p.authorized:
+		return nil, fmt.Errorf("%w: capture exceeds the authorized amount", ErrDeclined)
+	}
+	p.captured += amount
+	f.emit(Event{Type: EventCaptured, Ref: ref, Amount: amount, Currency: p.currency})
+	return &Result{Ref: ref}, nil
+}
+
+func (f *Fake) Void(ctx context.Context, ref string) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := f.payment(ref)
+	if err != nil {
+		return nil, err
+	}
+	if p.captured > 0 {
+		return nil, fmt.Errorf("%w: payment was already captured", ErrDeclined)
+	}
+	p.voided = true
+	f.emit(Event{Type: EventVoided, Ref: ref, Amount: p.authorized, Currency: p.currency})
+	return &Result{Ref: ref}, nil
+}
+
+func (f *Fake) Refund(ctx context.Context, ref string, amount money.Amount) (*Result, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, err := f.payment(ref)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 || p.refunded+amount > p.captured {
+		return nil, fmt.Errorf("%w: refund exceeds the captured amount", ErrDeclined)
+	}
+	p.refunded += amount
+	f.next++
+	return &Result{Ref: fmt.Sprintf("fake_refund_%d", f.next)}, nil
+}
+
+func (f *Fake) payment(ref string) (*fakePayment, error) {
+	p, ok := f.payments[ref]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown payment %q", ErrDeclined, ref)
+	}
+	return p, nil
+}
+
+// DeliverTo makes the fake post its webhooks to url.
+func (f *Fake) DeliverTo(url string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.callbackURL = url
+}
+
+// SignedEvent encodes e the way the fake delivers it, returning the payload
+// and the headers carrying its signature.
+func (f *Fake) SignedEvent(e Event, t time.Time) (http.Header, []byte, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error encoding event: %w", err)
+	}
+	h := http.Header{}
+	h.Set(FakeSignatureHeader, Sign(f.secret, payload, t))
+	return h, payload, nil
+}
+
+func (f *Fake) VerifyWebhook(h http.Header, payload []byte, now time.Time) error {
+	return Verify(f.secret, payload, h.Get(FakeSignatureHeader), now)
+}
+
+func (f *Fake) ParseEvent(payload []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, fmt.Errorf("error decoding event: %w", err)
+	}
+	if e.ID == "" || e.Type == "" {
+		return nil, fmt.Errorf("event is missing its id or type")
+	}
+	return &e, nil
+}
+
+// emit numbers e and delivers it in the background, as gateways do not
+// hold up the API call on their webhooks. f.mu must be held.
+func (f *Fake) emit(e Event) {
+	f.nextEvent++
+	e.ID = fmt.Sprintf("evt_%d", f.nextEvent)
+	if f.callbackURL == "" {
+		return
+	}
+	go f.deliver(f.callbackURL, e)
+}
+
+func (f *Fake) deliver(url string, e Event) {
+	h, payload, err := f.SignedEvent(e, time.Now())
+	if err != nil {
+		log.Printf("payments: fake gateway: %v", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("payments: fake gateway: error creating webhook request: %v", err)
+		return
+	}
+	req.Header = h
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		log.Printf("payments: fake gateway: error delivering %s: %v", e.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("payments: fake gateway: delivering %s: got status %d", e.ID, resp.StatusCode)
+	}
+}
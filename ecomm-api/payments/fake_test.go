@@ -0,0 +1,96 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *Fake)
+	}{
+		{
+			name: "authorize, capture and refund",
+			test: func(t *testing.T, f *Fake) {
+				ctx := context.Background()
+				res, err := f.Authorize(ctx, Charge{Reference: "order-1", Amount: 100, Token: "tok_visa"})
+				require.NoError(t, err)
+				require.Equal(t, "fake_1", res.Ref)
+				_, err = f.Capture(ctx, res.Ref, 150)
+				require.True(t, errors.Is(err, ErrDeclined))
+				_, err = f.Capture(ctx, res.Ref, 100)
+				require.NoError(t, err)
+				_, err = f.Void(ctx, res.Ref)
+				require.True(t, errors.Is(err, ErrDeclined))
+				_, err = f.Refund(ctx, res.Ref, 60)
+				require.NoError(t, err)
+				_, err = f.Refund(ctx, res.Ref, 60)
+				require.True(t, errors.Is(err, ErrDeclined))
+			},
+		},
+		{
+			name: "scenarios",
+			test: func(t *testing.T, f *Fake) {
+				ctx := context.Background()
+				_, err := f.Authorize(ctx, Charge{Amount: 100, Token: "tok_decline"})
+				require.True(t, errors.Is(err, ErrDeclined))
+				_, err = f.Authorize(ctx, Charge{Amount: 100, Token: "tok_unavailable"})
+				require.True(t, errors.Is(err, ErrUnavailable))
+				_, err = f.Authorize(ctx, Charge{Amount: 100, Token: "tok_custom"})
+				require.True(t, errors.Is(err, ErrDeclined))
+			},
+		},
+		{
+			name: "capture declined",
+			test: func(t *testing.T, f *Fake) {
+				ctx := context.Background()
+				res, err := f.Authorize(ctx, Charge{Amount: 100, Token: "tok_capture_decline"})
+				require.NoError(t, err)
+				_, err = f.Capture(ctx, res.Ref, 100)
+				require.True(t, errors.Is(err, ErrDeclined))
+				_, err = f.Void(ctx, res.Ref)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "signed webhooks",
+			test: func(t *testing.T, f *Fake) {
+				received := make(chan *http.Request, 1)
+				bodies := make(chan []byte, 1)
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					b, _ := io.ReadAll(r.Body)
+					received <- r
+					bodies <- b
+				}))
+				defer srv.Close()
+				f.DeliverTo(srv.URL)
+
+				ctx := context.Background()
+				res, err := f.Authorize(ctx, Charge{Amount: 100, Currency: "USD", Token: "tok_visa"})
+				require.NoError(t, err)
+				_, err = f.Capture(ctx, res.Ref, 100)
+				require.NoError(t, err)
+				r := <-received
+				payload := <-bodies
+				require.NoError(t, f.VerifyWebhook(r.Header, payload, time.Now()))
+				e, err := f.ParseEvent(payload)
+				require.NoError(t, err)
+				require.Equal(t, &Event{ID: "evt_1", Type: EventCaptured, Ref: res.Ref, Amount: 100, Currency: "USD"}, e)
+				require.True(t, errors.Is(f.VerifyWebhook(r.Header, append(payload, ' '), time.Now()), ErrInvalidSignature))
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, NewFake([]byte("secret"), map[string]FakeScenario{"tok_custom": FakeDecline}))
+		})
+	}
+}
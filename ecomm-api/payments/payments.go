@@ -0,0 +1,47 @@
+// Package payments moves money through payment gateways. Each gateway is a
+// Provider.
+package payments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+)
+
+var (
+	// ErrDeclined is returned when the gateway refuses the operation, e.g.
+	// the card was declined. Retrying will not help.
+	ErrDeclined = errors.New("payment declined")
+	// ErrUnavailable is returned when the gateway could not be reached or
+	// failed; the operation may be retried.
+	ErrUnavailable = errors.New("payment gateway unavailable")
+)
+
+// Charge asks a gateway to authorize an amount in Currency, a three-letter
+// code. Token is the opaque payment method the client obtained from the
+// gateway, e.g. a tokenized card, and Reference identifies the charge on our
+// side.
+type Charge struct {
+	Reference string
+	Amount    money.Amount
+	Currency  string
+	Token     string
+}
+
+// Result is the gateway's answer. Ref identifies the payment at the gateway
+// and is passed back for later operations on it.
+type Result struct {
+	Ref string
+}
+
+// Provider is a payment gateway. Authorize reserves the money, Capture
+// collects up to the authorized amount, Void releases an authorization
+// that was not captured and Refund gives back up to the captured amount.
+type Provider interface {
+	Name() string
+	Authorize(ctx context.Context, c Charge) (*Result, error)
+	Capture(ctx context.Context, ref string, amount money.Amount) (*Result, error)
+	Void(ctx context.Context, ref string) (*Result, error)
+	Refund(ctx context.Context, ref string, amount money.Amount) (*Result, error)
+}
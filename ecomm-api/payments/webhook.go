@@ -0,0 +1,92 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+)
+
+// ErrInvalidSignature is returned for a webhook whose signature does not
+// match its payload or whose timestamp is too far from now.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// SignatureTolerance is how far a webhook's timestamp may be from now, which
+// bounds how long a captured request can be replayed against us.
+const SignatureTolerance = 5 * time.Minute
+
+// Webhook event types.
+const (
+	EventCaptured = "payment.captured"
+	EventFailed   = "payment.failed"
+	EventVoided   = "payment.voided"
+)
+
+// Event is a gateway's notification that a payment changed. ID is unique
+// per provider; a gateway may deliver the same event more than once.
+// Amount and Currency are what the event moved, e.g. the captured amount.
+type Event struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Ref      string       `json:"payment_ref"`
+	Amount   money.Amount `json:"amount"`
+	Currency string       `json:"currency"`
+	Reason   string       `json:"reason,omitempty"`
+}
+
+// WebhookProvider is a Provider that confirms payments by calling us back.
+// VerifyWebhook authenticates a delivery; ParseEvent decodes a payload that
+// was verified before, e.g. when replaying it.
+type WebhookProvider interface {
+	Provider
+	VerifyWebhook(h http.Header, payload []byte, now time.Time) error
+	ParseEvent(payload []byte) (*Event, error)
+}
+
+// Sign returns a signature header value for payload sent at t, in the form
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<payload>">".
+func Sign(secret, payload []byte, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, signature(secret, ts, payload))
+}
+
+// Verify checks a signature made by Sign and that it was made within
+// SignatureTolerance of now.
+func Verify(secret, payload []byte, header string, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return fmt.Errorf("%w: malformed signature header", ErrInvalidSignature)
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > SignatureTolerance || d < -SignatureTolerance {
+		return fmt.Errorf("%w: timestamp outside the tolerance", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, ts, payload))) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+func signature(secret []byte, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,39 @@
+package payments
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	secret := []byte("secret")
+	payload := []byte(`{"id":"evt_1","type":"payment.captured"}`)
+	now := time.Unix(1700000000, 0)
+	tcs := []struct {
+		name   string
+		header string
+		ok     bool
+	}{
+		{name: "valid", header: Sign(secret, payload, now), ok: true},
+		{name: "within tolerance", header: Sign(secret, payload, now.Add(-SignatureTolerance+time.Second)), ok: true},
+		{name: "stale", header: Sign(secret, payload, now.Add(-SignatureTolerance-time.Second))},
+		{name: "wrong secret", header: Sign([]byte("other"), payload, now)},
+		{name: "tampered timestamp", header: strings.Replace(Sign(secret, payload, now), "t=1700000000", "t=1700000001", 1)},
+		{name: "malformed", header: "v1=abc"},
+		{name: "missing", header: ""},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(secret, payload, tc.header, now)
+			if tc.ok {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, errors.Is(err, ErrInvalidSignature))
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (s *Server) CreateAddress(ctx context.Context, a *storer.Address) (*storer.Address, error) {
+	if err := validatePostalAddress(&a.PostalAddress); err != nil {
+		return nil, err
+	}
+	existing, err := s.storer.ListAddresses(ctx, a.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// the first address becomes the default
+	if len(existing) == 0 {
+		a.IsDefault = true
+	}
+	a.CreatedAt = time.Now()
+	return s.storer.CreateAddress(ctx, a)
+}
+
+func (s *Server) GetAddress(ctx context.Context, userID, id int64) (*storer.Address, error) {
+	return s.ownAddress(ctx, userID, id)
+}
+
+func (s *Server) ListAddresses(ctx context.Context, userID int64) ([]storer.Address, error) {
+	return s.storer.ListAddresses(ctx, userID)
+}
+
+func (s *Server) UpdateAddress(ctx context.Context, a *storer.Address) (*storer.Address, error) {
+	if err := validatePostalAddress(&a.PostalAddress); err != nil {
+		return nil, err
+	}
+	a.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateAddress(ctx, a)
+}
+
+func (s *Server) DeleteAddress(ctx context.Context, userID, id int64) error {
+	if _, err := s.ownAddress(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.storer.DeleteAddress(ctx, id)
+}
+
+func (s *Server) ownAddress(ctx context.Context, userID, id int64) (*storer.Address, error) {
+	a, err := s.storer.GetAddress(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != userID {
+		return nil, fmt.Errorf("error getting address: %w", storer.ErrNotFound)
+	}
+	return a, nil
+}
+
+// addressOrder copies the order's addresses onto it. Each comes from the
+// user's address book when opts names one, or from the order itself when
+// the customer typed it in. The shipping address defaults to the user's
+// default address and the billing address to the shipping address.
+func (s *Server) addressOrder(ctx context.Context, userID int64, o *storer.Order, opts OrderOptions) error {
+	shipTo, err := s.orderAddress(ctx, userID, storer.AddressShipping, opts.ShippingAddressID, o.ShippingAddress)
+	if err != nil {
+		return err
+	}
+	if shipTo == nil {
+		as, err := s.storer.ListAddresses(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if len(as) == 0 || !as[0].IsDefault {
+			return fmt.Errorf("%w: a shipping address is required", ErrInvalid)
+		}
+		shipTo = &storer.OrderAddress{Kind: storer.AddressShipping, PostalAddress: as[0].PostalAddress}
+	}
+	billTo, err := s.orderAddress(ctx, userID, storer.AddressBilling, opts.BillingAddressID, o.BillingAddress)
+	if err != nil {
+		return err
+	}
+	if billTo == nil {
+		billTo = &storer.OrderAddress{Kind: storer.AddressBilling, PostalAddress: shipTo.PostalAddress}
+	}
+	o.ShippingAddress, o.BillingAddress = shipTo, billTo
+	return nil
+}
+
+func (s *Server) orderAddress(ctx context.Context, userID int64, kind string, id *int64, typed *storer.OrderAddress) (*storer.OrderAddress, error) {
+	if id != nil {
+		a, err := s.ownAddress(ctx, userID, *id)
+		if err != nil {
+			return nil, notFoundAsInvalid(err, "address %d does not exist", *id)
+		}
+		return &storer.OrderAddress{Kind: kind, PostalAddress: a.PostalAddress}, nil
+	}
+	if typed == nil {
+		return nil, nil
+	}
+	if err := validatePostalAddress(&typed.PostalAddress); err != nil {
+		return nil, err
+	}
+	return &storer.OrderAddress{Kind: kind, PostalAddress: typed.PostalAddress}, nil
+}
+
+// shipTo is where the order is delivered, or the zero Location when it has
+// no shipping address.
+func shipTo(o *storer.Order) Location {
+	if o.ShippingAddress == nil {
+		return Location{}
+	}
+	a := o.ShippingAddress
+	return Location{Country: a.Country, Region: a.Region, PostalCode: a.PostalCode}
+}
+
+func validatePostalAddress(pa *storer.PostalAddress) error {
+	pa.Name = strings.TrimSpace(pa.Name)
+	pa.Line1 = strings.TrimSpace(pa.Line1)
+	pa.Line2 = strings.TrimSpace(pa.Line2)
+	pa.City = strings.TrimSpace(pa.City)
+	pa.Region = strings.TrimSpace(pa.Region)
+	pa.PostalCode = strings.TrimSpace(pa.PostalCode)
+	pa.Country = strings.ToUpper(strings.TrimSpace(pa.Country))
+	pa.Phone = strings.TrimSpace(pa.Phone)
+	switch {
+	case pa.Name == "":
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	case pa.Line1 == "":
+		return fmt.Errorf("%w: address line 1 is required", ErrInvalid)
+	case pa.City == "":
+		return fmt.Errorf("%w: city is required", ErrInvalid)
+	case len(pa.Country) != 2:
+		return fmt.Errorf("%w: country must be a two-letter code", ErrInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// checkInventoryPolicy defaults the product's inventory policy to
+// storer.InventoryDeny and rejects unknown policies and negative backorder
+// limits.
+func checkInventoryPolicy(p *storer.Product) error {
+	switch p.InventoryPolicy {
+	case "":
+		p.InventoryPolicy = storer.InventoryDeny
+	case storer.InventoryDeny, storer.InventoryBackorder, storer.InventoryPreorder:
+	default:
+		return fmt.Errorf("%w: unknown inventory policy %q", ErrInvalid, p.InventoryPolicy)
+	}
+	if p.BackorderLimit != nil && *p.BackorderLimit < 0 {
+		return fmt.Errorf("%w: backorder limit cannot be negative", ErrInvalid)
+	}
+	return nil
+}
+
+// backorderable reports whether the product takes orders at t beyond its
+// stock. The backorder limit is only checked when the order is stored.
+func (s *Server) backorderable(ctx context.Context, productID int64, t time.Time) (bool, error) {
+	p, err := s.storer.GetProduct(ctx, productID)
+	if err != nil {
+		return false, err
+	}
+	return p.AllowsBackorder(t), nil
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// CartOwner identifies whose cart a request acts on: a logged-in user, or a
+// guest holding the token issued with their cart.
+type CartOwner struct {
+	UserID     int64
+	GuestToken string
+}
+
+func (o CartOwner) guest() bool {
+	return o.UserID == 0
+}
+
+// PricedCart is a cart with every item priced from the catalog at read time.
+type PricedCart struct {
+	*storer.Cart
+	Lines    []CartLine
+	Subtotal money.Amount
+	// Token is set only when a guest cart was just created; the client must
+	// send it back to find the cart again.
+	Token string
+}
+
+// CartLine is a cart item with its current catalog name, price and stock.
+// Backorderable is set when the product can be ordered beyond Available.
+type CartLine struct {
+	storer.CartItem
+	Name          string
+	Image         string
+	Price         money.Amount
+	Available     int64
+	Backorderable bool
+}
+
+// GetCart returns the owner's cart, empty if they have not added anything
+// yet.
+func (s *Server) GetCart(ctx context.Context, owner CartOwner) (*PricedCart, error) {
+	c, err := s.findCart(ctx, owner)
+	if errors.Is(err, storer.ErrNotFound) {
+		return &PricedCart{Cart: &storer.Cart{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.priceCart(ctx, c)
+}
+
+// AddCartItem puts quantity units of a product, or of one of its variants,
+// in the owner's cart, creating the cart on first use. Adding something
+// already in the cart raises its quantity.
+func (s *Server) AddCartItem(ctx context.Context, owner CartOwner, ci *storer.CartItem) (*PricedCart, error) {
+	if _, err := s.priceOrderItem(ctx, cartOrderItem(*ci)); err != nil {
+		return nil, err
+	}
+	c, token, err := s.ownCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if existing := findCartItem(c, ci.ProductID, ci.VariantID); existing != nil {
+		existing.Quantity += ci.Quantity
+		existing.UpdatedAt = toTimePtr(time.Now())
+		_, err = s.storer.UpdateCartItem(ctx, existing)
+	} else {
+		ci.CartID = c.ID
+		ci.CreatedAt = time.Now()
+		_, err = s.storer.CreateCartItem(ctx, ci)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pc, err := s.touchCart(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	pc.Token = token
+	return pc, nil
+}
+
+// UpdateCartItem sets the quantity of an item in the owner's cart.
+func (s *Server) UpdateCartItem(ctx context.Context, owner CartOwner, itemID, quantity int64) (*PricedCart, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalid)
+	}
+	c, ci, err := s.getCartItem(ctx, owner, itemID)
+	if err != nil {
+		return nil, err
+	}
+	ci.Quantity = quantity
+	ci.UpdatedAt = toTimePtr(time.Now())
+	if _, err := s.storer.UpdateCartItem(ctx, ci); err != nil {
+		return nil, err
+	}
+	return s.touchCart(ctx, c)
+}
+
+func (s *Server) DeleteCartItem(ctx context.Context, owner CartOwner, itemID int64) (*PricedCart, error) {
+	c, ci, err := s.getCartItem(ctx, owner, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storer.DeleteCartItem(ctx, ci.ID); err != nil {
+		return nil, err
+	}
+	return s.touchCart(ctx, c)
+}
+
+// Checkout turns the user's cart into an order priced from the catalog, with
+// any promotions applied and tax charged, and empties the cart. o carries
+// the customer's order details.
+func (s *Server) Checkout(ctx context.Context, userID int64, o *storer.Order, opts OrderOptions) (*storer.Order, error) {
+	c, err := s.storer.GetCartByUser(ctx, userID)
+	if err != nil && !errors.Is(err, storer.ErrNotFound) {
+		return nil, err
+	}
+	if c == nil || len(c.Items) == 0 {
+		return nil, fmt.Errorf("%w: the cart is empty", ErrInvalid)
+	}
+	o.Items = nil
+	for _, ci := range c.Items {
+		o.Items = append(o.Items, *cartOrderItem(ci))
+	}
+	o.UserID = userID
+	o.Status = storer.OrderStatusPending
+	o.CreatedAt = time.Now()
+	if err := s.addressOrder(ctx, userID, o, opts); err != nil {
+		return nil, err
+	}
+	if err := s.priceOrder(ctx, o, opts); err != nil {
+		return nil, err
+	}
+	if err := s.allocateOrder(ctx, o); err != nil {
+		return nil, err
+	}
+	return s.storer.CheckoutCart(ctx, c.ID, o)
+}
+
+// findCart returns the owner's cart. Expired guest carts are treated as
+// gone even before the sweeper deletes them.
+func (s *Server) findCart(ctx context.Context, owner CartOwner) (*storer.Cart, error) {
+	if !owner.guest() {
+		return s.storer.GetCartByUser(ctx, owner.UserID)
+	}
+	if owner.GuestToken == "" {
+		return nil, fmt.Errorf("error getting cart: %w", storer.ErrNotFound)
+	}
+	c, err := s.storer.GetCartByGuestToken(ctx, auth.HashOpaqueToken(owner.GuestToken))
+	if err != nil {
+		return nil, err
+	}
+	if c.ExpiresAt != nil && c.ExpiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("error getting cart: %w", storer.ErrNotFound)
+	}
+	return c, nil
+}
+
+// ownCart returns the owner's cart, creating it on first use. For a new
+// guest cart it also returns the token that identifies it.
+func (s *Server) ownCart(ctx context.Context, owner CartOwner) (*storer.Cart, string, error) {
+	c, err := s.findCart(ctx, owner)
+	if !errors.Is(err, storer.ErrNotFound) {
+		return c, "", err
+	}
+	c = &storer.Cart{CreatedAt: time.Now()}
+	var token string
+	if owner.guest() {
+		var hash string
+		token, hash, err = auth.NewOpaqueToken()
+		if err != nil {
+			return nil, "", err
+		}
+		c.GuestToken = &hash
+		c.ExpiresAt = toTimePtr(c.CreatedAt.Add(s.guestCartTTL))
+	} else {
+		c.UserID = &owner.UserID
+	}
+	c, err = s.storer.CreateCart(ctx, c)
+	if errors.Is(err, storer.ErrConflict) && !owner.guest() {
+		// created concurrently by another request of the same user
+		c, err = s.storer.GetCartByUser(ctx, owner.UserID)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return c, token, nil
+}
+
+// touchCart records a change to the cart, extending a guest cart's life, and
+// returns it freshly priced.
+func (s *Server) touchCart(ctx context.Context, c *storer.Cart) (*PricedCart, error) {
+	now := time.Now()
+	c.UpdatedAt = &now
+	if c.GuestToken != nil {
+		c.ExpiresAt = toTimePtr(now.Add(s.guestCartTTL))
+	}
+	if err := s.storer.TouchCart(ctx, c); err != nil {
+		return nil, err
+	}
+	var (
+		fresh *storer.Cart
+		err   error
+	)
+	if c.GuestToken != nil {
+		fresh, err = s.storer.GetCartByGuestToken(ctx, *c.GuestToken)
+	} else {
+		fresh, err = s.storer.GetCartByUser(ctx, *c.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.priceCart(ctx, fresh)
+}
+
+func (s *Server) getCartItem(ctx context.Context, owner CartOwner, itemID int64) (*storer.Cart, *storer.CartItem, error) {
+	c, err := s.findCart(ctx, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range c.Items {
+		if c.Items[i].ID == itemID {
+			return c, &c.Items[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("error getting cart item: %w", storer.ErrNotFound)
+}
+
+func (s *Server) priceCart(ctx context.Context, c *storer.Cart) (*PricedCart, error) {
+	pc := &PricedCart{Cart: c}
+	var book *PriceBook
+	if c.UserID != nil {
+		var err error
+		if book, err = s.priceBook(ctx, *c.UserID); err != nil {
+			return nil, err
+		}
+	}
+	for _, ci := range c.Items {
+		oi := cartOrderItem(ci)
+		var backorderable bool
+		available, err := s.priceOrderItem(ctx, oi)
+		if errors.Is(err, ErrInvalid) {
+			// the catalog changed under the item, e.g. the product is now
+			// sold in variants; show it as unavailable until it is replaced
+			available = 0
+		} else if err != nil {
+			return nil, err
+		} else if err := s.customerPrice(ctx, book, oi); err != nil {
+			return nil, err
+		} else if backorderable, err = s.backorderable(ctx, oi.ProductID, time.Now()); err != nil {
+			return nil, err
+		}
+		pc.Lines = append(pc.Lines, CartLine{
+			CartItem:      ci,
+			Name:          oi.Name,
+			Image:         oi.Image,
+			Price:         oi.Price,
+			Available:     available,
+			Backorderable: backorderable,
+		})
+		if available > 0 || backorderable {
+			pc.Subtotal += oi.Price.Mul(ci.Quantity)
+		}
+	}
+	return pc, nil
+}
+
+func findCartItem(c *storer.Cart, productID int64, variantID *int64) *storer.CartItem {
+	for i := range c.Items {
+		ci := &c.Items[i]
+		if ci.ProductID == productID && sameVariant(ci.VariantID, variantID) {
+			return ci
+		}
+	}
+	return nil
+}
+
+func sameVariant(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func cartOrderItem(ci storer.CartItem) *storer.OrderItem {
+	return &storer.OrderItem{
+		ProductID: ci.ProductID,
+		VariantID: ci.VariantID,
+		Quantity:  ci.Quantity,
+	}
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (s *Server) CreateCategory(ctx context.Context, c *storer.Category) (*storer.Category, error) {
+	if err := s.validateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	c.CreatedAt = time.Now()
+	return s.storer.CreateCategory(ctx, c)
+}
+
+func (s *Server) GetCategory(ctx context.Context, slug string) (*storer.Category, error) {
+	return s.storer.GetCategoryBySlug(ctx, slug)
+}
+
+func (s *Server) ListCategories(ctx context.Context) ([]storer.Category, error) {
+	return s.storer.ListCategories(ctx)
+}
+
+func (s *Server) UpdateCategory(ctx context.Context, c *storer.Category) (*storer.Category, error) {
+	if err := s.validateCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	c.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateCategory(ctx, c)
+}
+
+func (s *Server) DeleteCategory(ctx context.Context, slug string) error {
+	c, err := s.storer.GetCategoryBySlug(ctx, slug)
+	if err != nil {
+		return err
+	}
+	return s.storer.DeleteCategory(ctx, c.ID)
+}
+
+// ListCategoryProducts lists the products of the category and of all of its
+// descendants.
+func (s *Server) ListCategoryProducts(ctx context.Context, slug string, f storer.ProductFilter) ([]storer.Product, error) {
+	c, err := s.storer.GetCategoryBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.storer.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	f.CategoryIDs = descendantIDs(categories, c.ID)
+	return s.storer.ListProducts(ctx, f)
+}
+
+func (s *Server) validateCategory(ctx context.Context, c *storer.Category) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return fmt.Errorf("%w: category name is required", ErrInvalid)
+	}
+	if c.Slug == "" {
+		c.Slug = c.Name
+	}
+	c.Slug = Slugify(c.Slug)
+	if c.Slug == "" {
+		return fmt.Errorf("%w: category slug must contain letters or digits", ErrInvalid)
+	}
+	c.TaxClass = strings.TrimSpace(c.TaxClass)
+	if c.TaxClass == "" {
+		c.TaxClass = storer.TaxClassStandard
+	}
+	if c.ParentID == nil {
+		return nil
+	}
+	categories, err := s.storer.ListCategories(ctx)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, cat := range categories {
+		if cat.ID == *c.ParentID {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: parent category %d does not exist", ErrInvalid, *c.ParentID)
+	}
+	if c.ID != 0 {
+		for _, id := range descendantIDs(categories, c.ID) {
+			if id == *c.ParentID {
+				return fmt.Errorf("%w: a category cannot be moved below itself", ErrInvalid)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveCategory points p at an existing category, by CategoryID when set
+// and otherwise by the slug of its free-text Category, and copies the
+// category's name onto the product.
+func (s *Server) resolveCategory(ctx context.Context, p *storer.Product) error {
+	var (
+		c   *storer.Category
+		err error
+	)
+	switch {
+	case p.CategoryID != nil:
+		c, err = s.storer.GetCategory(ctx, *p.CategoryID)
+	case p.Category != "":
+		c, err = s.storer.GetCategoryBySlug(ctx, Slugify(p.Category))
+	default:
+		return nil
+	}
+	if errors.Is(err, storer.ErrNotFound) {
+		return fmt.Errorf("%w: unknown category", ErrInvalid)
+	}
+	if err != nil {
+		return err
+	}
+	p.CategoryID = &c.ID
+	p.Category = c.Name
+	return nil
+}
+
+// descendantIDs returns id followed by the IDs of every category below it.
+func descendantIDs(categories []storer.Category, id int64) []int64 {
+	children := make(map[int64][]int64)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	ids := []int64{id}
+	seen := map[int64]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids
+}
+
+// Slugify lower-cases s and collapses every run of characters other than
+// letters and digits into a single dash, so "Men's Shoes" becomes
+// "men-s-shoes". It matches the backfill in the categories migration.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+func toTimePtr(t time.Time) *time.Time {
+	return &t
+}
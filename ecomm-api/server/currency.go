@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+const defaultBaseCurrency = "USD"
+
+// WithBaseCurrency sets the currency catalog prices, promotions and
+// shipping are kept in. It defaults to USD.
+func WithBaseCurrency(currency string) Option {
+	return func(s *Server) {
+		s.baseCurrency = strings.ToUpper(currency)
+	}
+}
+
+// Conversion turns amounts in the base currency into Currency.
+type Conversion struct {
+	Currency string
+	Rate     float64
+}
+
+// Convert returns a, in the base currency, in c's currency.
+func (c Conversion) Convert(a money.Amount) money.Amount {
+	return a.MulRate(c.Rate)
+}
+
+// ToBase returns a, in c's currency, in the base currency.
+func (c Conversion) ToBase(a money.Amount) money.Amount {
+	return a.MulRate(1 / c.Rate)
+}
+
+// Conversion looks up the rate to currency; an empty currency is the base
+// currency.
+func (s *Server) Conversion(ctx context.Context, currency string) (Conversion, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" || currency == s.baseCurrency {
+		return s.BaseConversion(), nil
+	}
+	er, err := s.storer.GetExchangeRate(ctx, currency)
+	if errors.Is(err, storer.ErrNotFound) {
+		return Conversion{}, fmt.Errorf("%w: currency %q is not supported", ErrInvalid, currency)
+	}
+	if err != nil {
+		return Conversion{}, err
+	}
+	return Conversion{Currency: er.Currency, Rate: er.Rate}, nil
+}
+
+// BaseConversion leaves amounts in the base currency.
+func (s *Server) BaseConversion() Conversion {
+	return Conversion{Currency: s.baseCurrency, Rate: 1}
+}
+
+func (s *Server) ListExchangeRates(ctx context.Context) ([]storer.ExchangeRate, error) {
+	return s.storer.ListExchangeRates(ctx)
+}
+
+// SetExchangeRates replaces the exchange rate table. Orders already placed
+// keep the rate they were placed at.
+func (s *Server) SetExchangeRates(ctx context.Context, rates []storer.ExchangeRate) ([]storer.ExchangeRate, error) {
+	now := time.Now()
+	seen := map[string]bool{}
+	for i := range rates {
+		er := &rates[i]
+		er.Currency = strings.ToUpper(strings.TrimSpace(er.Currency))
+		switch {
+		case !validCurrency(er.Currency):
+			return nil, fmt.Errorf("%w: currency %q must be a three-letter code", ErrInvalid, er.Currency)
+		case er.Currency == s.baseCurrency:
+			return nil, fmt.Errorf("%w: %s is the base currency", ErrInvalid, er.Currency)
+		case seen[er.Currency]:
+			return nil, fmt.Errorf("%w: currency %s is listed twice", ErrInvalid, er.Currency)
+		case er.Rate <= 0:
+			return nil, fmt.Errorf("%w: the rate of %s must be positive", ErrInvalid, er.Currency)
+		}
+		seen[er.Currency] = true
+		er.UpdatedAt = now
+	}
+	if err := s.storer.SetExchangeRates(ctx, rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func validCurrency(c string) bool {
+	if len(c) != 3 {
+		return false
+	}
+	for _, r := range c {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// convertOrder turns the priced order's amounts into c's currency and
+// records the rate. Each amount is converted on its own and the totals are
+// added up again, so they still match their parts.
+func convertOrder(o *storer.Order, c Conversion) {
+	o.Currency = c.Currency
+	o.ExchangeRate = c.Rate
+	if c.Rate == 1 {
+		return
+	}
+	var subtotal, tax, added, discount money.Amount
+	for i := range o.Items {
+		oi := &o.Items[i]
+		oi.Price = c.Convert(oi.Price)
+		subtotal += oi.Price.Mul(oi.Quantity)
+		for j := range oi.Taxes {
+			t := &oi.Taxes[j]
+			t.Amount = c.Convert(t.Amount)
+			tax += t.Amount
+			if !t.Included {
+				added += t.Amount
+			}
+		}
+	}
+	for i := range o.Discounts {
+		o.Discounts[i].Amount = c.Convert(o.Discounts[i].Amount)
+		discount += o.Discounts[i].Amount
+	}
+	o.ShippingPrice = c.Convert(o.ShippingPrice)
+	o.TaxPrice = tax
+	o.DiscountPrice = discount
+	o.TotalPrice = subtotal + added + o.ShippingPrice - discount
+}
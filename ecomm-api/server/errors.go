@@ -0,0 +1,15 @@
+package server
+
+import "errors"
+
+// ErrInvalid is wrapped by errors reporting a request the server refuses to
+// act on, such as a missing field or a reference to an unknown row. The
+// wrapping error's message is safe to show to the client.
+var ErrInvalid = errors.New("invalid request")
+
+var (
+	// ErrUnauthorized is returned when credentials do not match a user.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is returned when the user may not act on the resource.
+	ErrForbidden = errors.New("forbidden")
+)
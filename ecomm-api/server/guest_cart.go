@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// defaultGuestCartTTL is how long a guest cart survives without changes.
+const defaultGuestCartTTL = 30 * 24 * time.Hour
+
+// CartMergeRule decides the quantity of an item that is in both the guest
+// cart and the user's cart when the two are merged at login.
+type CartMergeRule string
+
+const (
+	// CartMergeSum adds the two quantities.
+	CartMergeSum CartMergeRule = "sum"
+	// CartMergeMax keeps the larger quantity.
+	CartMergeMax CartMergeRule = "max"
+	// CartMergeUser keeps the quantity in the user's cart.
+	CartMergeUser CartMergeRule = "user"
+	// CartMergeGuest takes the quantity in the guest cart.
+	CartMergeGuest CartMergeRule = "guest"
+)
+
+func ParseCartMergeRule(s string) (CartMergeRule, error) {
+	switch r := CartMergeRule(s); r {
+	case CartMergeSum, CartMergeMax, CartMergeUser, CartMergeGuest:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown cart merge rule %q", s)
+}
+
+func WithGuestCartTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		if ttl > 0 {
+			s.guestCartTTL = ttl
+		}
+	}
+}
+
+func WithCartMergeRule(rule CartMergeRule) Option {
+	return func(s *Server) {
+		s.cartMergeRule = rule
+	}
+}
+
+// MergeGuestCart moves the guest cart identified by token into the user's
+// cart and deletes it. A missing or expired guest cart is not an error.
+func (s *Server) MergeGuestCart(ctx context.Context, userID int64, token string) error {
+	guest, err := s.findCart(ctx, CartOwner{GuestToken: token})
+	if errors.Is(err, storer.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c, _, err := s.ownCart(ctx, CartOwner{UserID: userID})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var items []storer.CartItem
+	for _, gi := range guest.Items {
+		existing := findCartItem(c, gi.ProductID, gi.VariantID)
+		if existing == nil {
+			gi.ID = 0
+			gi.CreatedAt = now
+			items = append(items, gi)
+			continue
+		}
+		if q := s.mergeQuantity(existing.Quantity, gi.Quantity); q != existing.Quantity {
+			existing.Quantity = q
+			existing.UpdatedAt = &now
+			items = append(items, *existing)
+		}
+	}
+	return s.storer.MergeCart(ctx, guest.ID, c.ID, items)
+}
+
+func (s *Server) mergeQuantity(user, guest int64) int64 {
+	switch s.cartMergeRule {
+	case CartMergeMax:
+		return max(user, guest)
+	case CartMergeUser:
+		return user
+	case CartMergeGuest:
+		return guest
+	}
+	return user + guest
+}
+
+// SweepGuestCarts deletes expired guest carts every interval until ctx is
+// cancelled.
+func (s *Server) SweepGuestCarts(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		n, err := s.storer.DeleteExpiredCarts(ctx, time.Now())
+		if err != nil {
+			log.Printf("error sweeping guest carts: %v", err)
+		} else if n > 0 {
+			log.Printf("deleted %d expired guest carts", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
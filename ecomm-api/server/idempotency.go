@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// defaultIdempotencyKeyTTL is how long a response is kept for replay.
+const defaultIdempotencyKeyTTL = 24 * time.Hour
+
+// ErrIdempotencyKeyReused is returned when a key is sent again with a
+// different request than the one it was first used for.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was used for a different request")
+
+func WithIdempotencyKeyTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		if ttl > 0 {
+			s.idempotencyKeyTTL = ttl
+		}
+	}
+}
+
+// BeginIdempotentRequest claims the user's key for the request identified
+// by requestHash. When the key already answered that request the stored
+// response is returned with replay set; the caller then sends it instead of
+// handling the request again. Otherwise the caller handles the request and
+// passes the response to FinishIdempotentRequest.
+func (s *Server) BeginIdempotentRequest(ctx context.Context, userID int64, key, requestHash string) (k *storer.IdempotencyKey, replay bool, err error) {
+	now := time.Now()
+	k = &storer.IdempotencyKey{UserID: userID, Key: key, RequestHash: requestHash, CreatedAt: now, ExpiresAt: now.Add(s.idempotencyKeyTTL)}
+	// a second attempt may be needed when the key held by an expired
+	// request is dropped
+	for attempt := 0; attempt < 2; attempt++ {
+		_, err = s.storer.CreateIdempotencyKey(ctx, k)
+		if !errors.Is(err, storer.ErrConflict) {
+			return k, false, err
+		}
+		existing, err := s.storer.GetIdempotencyKey(ctx, userID, key)
+		if errors.Is(err, storer.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if existing.ExpiresAt.Before(now) {
+			if err := s.storer.DeleteIdempotencyKey(ctx, existing.ID); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		if existing.RequestHash != requestHash {
+			return nil, false, fmt.Errorf("error using idempotency key: %w", ErrIdempotencyKeyReused)
+		}
+		if existing.StatusCode == 0 {
+			return nil, false, fmt.Errorf("error using idempotency key: a request with this key is in progress: %w", storer.ErrConflict)
+		}
+		return existing, true, nil
+	}
+	return nil, false, fmt.Errorf("error using idempotency key: %w", storer.ErrConflict)
+}
+
+// FinishIdempotentRequest stores the response to the key's request. Server
+// errors are not stored but release the key, so the request can be retried
+// with it.
+func (s *Server) FinishIdempotentRequest(ctx context.Context, k *storer.IdempotencyKey, statusCode int, contentType string, body []byte) error {
+	if statusCode >= 500 {
+		return s.storer.DeleteIdempotencyKey(ctx, k.ID)
+	}
+	k.StatusCode = statusCode
+	k.ContentType = contentType
+	k.ResponseBody = body
+	_, err := s.storer.UpdateIdempotencyKey(ctx, k)
+	return err
+}
+
+// SweepIdempotencyKeys deletes expired idempotency keys every interval until
+// ctx is cancelled.
+func (s *Server) SweepIdempotencyKeys(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		n, err := s.storer.DeleteExpiredIdempotencyKeys(ctx, time.Now())
+		if err != nil {
+			log.Printf("error sweeping idempotency keys: %v", err)
+		} else if n > 0 {
+			log.Printf("deleted %d expired idempotency keys", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import "strings"
+
+// Location is where an order is delivered.
+type Location struct {
+	Country    string
+	Region     string
+	PostalCode string
+}
+
+// within reports whether l lies in country and, when they are set, region
+// and the postal codes starting with postalPrefix.
+func (l Location) within(country, region, postalPrefix string) bool {
+	return strings.EqualFold(l.Country, country) &&
+		(region == "" || strings.EqualFold(l.Region, region)) &&
+		strings.HasPrefix(normalizePostalCode(l.PostalCode), postalPrefix)
+}
+
+// specificity ranks how narrowly a region and postal code prefix pin down a
+// location; a postal code prefix is more specific than a region.
+func specificity(region, postalPrefix string) int {
+	n := 2 * len(postalPrefix)
+	if region != "" {
+		n++
+	}
+	return n
+}
+
+func normalizePostalCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(code, " ", ""))
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// defaultReviewReportThreshold is how many customer reports send a published
+// review back to the moderation queue.
+const defaultReviewReportThreshold = 3
+
+// WithReviewBlocklist flags new and edited reviews whose title or body
+// contains any of the given words or phrases. Matching ignores case and
+// punctuation.
+func WithReviewBlocklist(words []string) Option {
+	return func(s *Server) {
+		s.reviewBlocklist = newBlocklist(words)
+	}
+}
+
+// WithReviewReportThreshold sets how many reports flag a published review.
+func WithReviewReportThreshold(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.reviewReportThreshold = n
+		}
+	}
+}
+
+// ListReviewQueue returns reviews awaiting a moderation decision, pending and
+// flagged unless statuses narrows it down.
+func (s *Server) ListReviewQueue(ctx context.Context, statuses []string) ([]storer.Review, error) {
+	if len(statuses) == 0 {
+		statuses = []string{storer.ReviewStatusPending, storer.ReviewStatusFlagged}
+	}
+	for _, st := range statuses {
+		if !validReviewStatus(st) {
+			return nil, fmt.Errorf("%w: unknown review status %q", ErrInvalid, st)
+		}
+	}
+	return s.storer.ListReviews(ctx, storer.ReviewFilter{Statuses: statuses})
+}
+
+// ModerateReview approves or rejects a review on behalf of an admin.
+func (s *Server) ModerateReview(ctx context.Context, actor *auth.Claims, id int64, status string) (*storer.Review, error) {
+	if !actor.IsAdmin {
+		return nil, fmt.Errorf("%w: only admins can moderate reviews", ErrForbidden)
+	}
+	if status != storer.ReviewStatusApproved && status != storer.ReviewStatusRejected {
+		return nil, fmt.Errorf("%w: status must be %q or %q", ErrInvalid, storer.ReviewStatusApproved, storer.ReviewStatusRejected)
+	}
+	r, err := s.storer.GetReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status == storer.ReviewStatusApproved {
+		// reports made before the review was looked at again are settled
+		r.ReportCount = 0
+	}
+	r.Status = status
+	r.ModeratedBy = &actor.UserID
+	r.ModeratedAt = toTimePtr(time.Now())
+	return s.storer.UpdateReviewStatus(ctx, r)
+}
+
+// ReportReview records a customer's report against a published review. Once
+// the review collects enough reports since it was last approved it is
+// flagged and unpublished until an admin looks at it again.
+func (s *Server) ReportReview(ctx context.Context, actor *auth.Claims, productID, id int64, reason string) (*storer.ReviewReport, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalid)
+	}
+	r, err := s.GetReview(ctx, productID, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != storer.ReviewStatusApproved {
+		return nil, fmt.Errorf("error reporting review: %w", storer.ErrNotFound)
+	}
+	return s.storer.CreateReviewReport(ctx, r, &storer.ReviewReport{
+		ReviewID:  r.ID,
+		UserID:    actor.UserID,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}, s.reviewReportThreshold)
+}
+
+// screenReview returns the status a new or edited review starts in.
+func (s *Server) screenReview(r *storer.Review) string {
+	if s.reviewBlocklist.matches(r.Title + "\n" + r.Body) {
+		return storer.ReviewStatusFlagged
+	}
+	return storer.ReviewStatusPending
+}
+
+func validReviewStatus(st string) bool {
+	switch st {
+	case storer.ReviewStatusPending, storer.ReviewStatusApproved, storer.ReviewStatusRejected, storer.ReviewStatusFlagged:
+		return true
+	}
+	return false
+}
+
+// blocklist holds normalized phrases, each a space-separated run of words.
+type blocklist []string
+
+func newBlocklist(words []string) blocklist {
+	var bl blocklist
+	for _, w := range words {
+		if n := normalizeWords(w); n != "" {
+			bl = append(bl, n)
+		}
+	}
+	return bl
+}
+
+// matches reports whether text contains a blocked phrase as whole words, so
+// that blocking "ass" does not flag "class".
+func (bl blocklist) matches(text string) bool {
+	if len(bl) == 0 {
+		return false
+	}
+	padded := " " + normalizeWords(text) + " "
+	for _, phrase := range bl {
+		if strings.Contains(padded, " "+phrase+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeWords(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
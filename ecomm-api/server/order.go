@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// CreateOrder prices the order's items from the catalog rather than trusting
+// the client, allocates them to warehouses, then stores the order. Items of
+// products that have variants must name one of them.
+func (s *Server) CreateOrder(ctx context.Context, o *storer.Order, opts OrderOptions) (*storer.Order, error) {
+	o.Status = storer.OrderStatusPending
+	o.CreatedAt = time.Now()
+	if err := s.addressOrder(ctx, o.UserID, o, opts); err != nil {
+		return nil, err
+	}
+	if err := s.priceOrder(ctx, o, opts); err != nil {
+		return nil, err
+	}
+	if err := s.allocateOrder(ctx, o); err != nil {
+		return nil, err
+	}
+	return s.storer.CreateOrder(ctx, o)
+}
+
+// GetOrder returns one of the user's orders; admins may read any order.
+func (s *Server) GetOrder(ctx context.Context, actor *auth.Claims, id int64) (*storer.Order, error) {
+	o, err := s.storer.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.UserID != actor.UserID && !actor.IsAdmin {
+		return nil, fmt.Errorf("error getting order: %w", storer.ErrNotFound)
+	}
+	return o, nil
+}
+
+// CancelOrder cancels one of the user's pending orders, or any pending
+// order for admins, and puts its stock back. Orders with a payment under
+// way or taken cannot be cancelled.
+func (s *Server) CancelOrder(ctx context.Context, actor *auth.Claims, id int64) (*storer.Order, error) {
+	o, err := s.GetOrder(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != storer.OrderStatusPending {
+		return nil, fmt.Errorf("%w: order is %s, only pending orders can be cancelled", ErrInvalid, o.Status)
+	}
+	existing, err := s.storer.ListPayments(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range existing {
+		switch p.Status {
+		case storer.PaymentPending, storer.PaymentAuthorized, storer.PaymentCaptured:
+			return nil, fmt.Errorf("error cancelling order: payment %d is %s: %w", p.ID, p.Status, storer.ErrConflict)
+		}
+	}
+	o.UpdatedAt = toTimePtr(time.Now())
+	if err := s.storer.CancelOrder(ctx, o, actor.UserID); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// OrderOptions carries what the customer chose for an order besides its
+// items.
+type OrderOptions struct {
+	PromotionCodes   []string
+	ShippingMethodID *int64
+	// ShippingAddressID and BillingAddressID pick addresses from the
+	// user's address book.
+	ShippingAddressID *int64
+	BillingAddressID  *int64
+	// Currency is what the order is priced in, the base currency when
+	// empty.
+	Currency string
+}
+
+// priceOrder prices the items and shipping, applies the automatic promotions
+// and those the customer entered, charges tax and computes the order's
+// totals. Items cost what the customer's price lists say. Everything is
+// worked out in the base currency and converted into
+// the order's currency at the current rate at the end.
+func (s *Server) priceOrder(ctx context.Context, o *storer.Order, opts OrderOptions) error {
+	if len(o.Items) == 0 {
+		return fmt.Errorf("%w: an order needs at least one item", ErrInvalid)
+	}
+	conv, err := s.Conversion(ctx, opts.Currency)
+	if err != nil {
+		return err
+	}
+	book, err := s.priceBook(ctx, o.UserID)
+	if err != nil {
+		return err
+	}
+	var subtotal money.Amount
+	for i := range o.Items {
+		if _, err := s.priceOrderItem(ctx, &o.Items[i]); err != nil {
+			return err
+		}
+		if err := s.customerPrice(ctx, book, &o.Items[i]); err != nil {
+			return err
+		}
+		subtotal += o.Items[i].Price.Mul(o.Items[i].Quantity)
+	}
+	if err := s.shipOrder(ctx, o, opts); err != nil {
+		return err
+	}
+	discounts, err := s.applyPromotions(ctx, o, subtotal, opts.PromotionCodes)
+	if err != nil {
+		return err
+	}
+	var discount money.Amount
+	for _, d := range discounts {
+		discount += d.Amount
+	}
+	o.Discounts = discounts
+	o.DiscountPrice = discount
+	tax, err := s.taxOrder(ctx, o, shipTo(o), itemDiscount(discounts, subtotal))
+	if err != nil {
+		return err
+	}
+	o.TotalPrice = subtotal + tax + o.ShippingPrice - discount
+	convertOrder(o, conv)
+	return nil
+}
+
+// priceOrderItem fills in the item's name, image and price from the catalog
+// and returns how many units of it can still be ordered from stock.
+func (s *Server) priceOrderItem(ctx context.Context, oi *storer.OrderItem) (int64, error) {
+	if oi.Quantity <= 0 {
+		return 0, fmt.Errorf("%w: quantity must be positive", ErrInvalid)
+	}
+	p, err := s.storer.GetProduct(ctx, oi.ProductID)
+	if err != nil {
+		return 0, notFoundAsInvalid(err, "product %d does not exist", oi.ProductID)
+	}
+	oi.Name = p.Name
+	oi.Image = p.Image
+	oi.Price = p.Price
+	variants, err := s.storer.ListVariants(ctx, p.ID)
+	if err != nil {
+		return 0, err
+	}
+	if oi.VariantID == nil {
+		if len(variants) > 0 {
+			return 0, fmt.Errorf("%w: product %d is sold in variants, choose one", ErrInvalid, p.ID)
+		}
+		return p.Available(), nil
+	}
+	for _, v := range variants {
+		if v.ID == *oi.VariantID {
+			oi.Name = fmt.Sprintf("%s (%s)", p.Name, v.SKU)
+			if v.Price != nil {
+				oi.Price = *v.Price
+			}
+			return v.Available(), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: variant %d is not a variant of product %d", ErrInvalid, *oi.VariantID, p.ID)
+}
+
+// notFoundAsInvalid turns a missing referenced row into a validation error.
+func notFoundAsInvalid(err error, format string, args ...interface{}) error {
+	if errors.Is(err, storer.ErrNotFound) {
+		return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalid}, args...)...)
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m21power/ecomm/ecomm-api/storer"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogStorer serves one product and its variants.
+type catalogStorer struct {
+	storer.Storer
+	product  storer.Product
+	variants []storer.Variant
+}
+
+func (cs *catalogStorer) GetProduct(context.Context, int64) (*storer.Product, error) {
+	return &cs.product, nil
+}
+
+func (cs *catalogStorer) ListVariants(context.Context, int64) ([]storer.Variant, error) {
+	return cs.variants, nil
+}
+
+func TestPriceOrderItemAvailability(t *testing.T) {
+	variantID := int64(3)
+	tcs := []struct {
+		name string
+		st   *catalogStorer
+		item storer.OrderItem
+		want int64
+	}{
+		{
+			name: "reserved units are not available",
+			st:   &catalogStorer{product: storer.Product{ID: 1, Name: "mug", Price: 900, CountInStock: 10, Reserved: 4}},
+			item: storer.OrderItem{ProductID: 1, Quantity: 1},
+			want: 6,
+		},
+		{
+			name: "variants keep their own reservations",
+			st: &catalogStorer{
+				product:  storer.Product{ID: 1, Name: "shirt", Price: 1500},
+				variants: []storer.Variant{{ID: 3, ProductID: 1, SKU: "M", CountInStock: 5, Reserved: 5}},
+			},
+			item: storer.OrderItem{ProductID: 1, VariantID: &variantID, Quantity: 1},
+			want: 0,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			available, err := NewServer(tc.st).priceOrderItem(context.Background(), &tc.item)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, available)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/payments"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// WithPaymentProvider lets orders be paid through p. The first provider
+// registered is used when the customer does not name one.
+func WithPaymentProvider(p payments.Provider) Option {
+	return func(s *Server) {
+		if s.paymentProviders == nil {
+			s.paymentProviders = map[string]payments.Provider{}
+			s.defaultPaymentProvider = p.Name()
+		}
+		s.paymentProviders[p.Name()] = p
+	}
+}
+
+// PayOrder charges the order's total through the named provider, or the
+// order's payment method or the default provider when provider is empty.
+// The money is authorized and then captured; only after the capture
+// succeeds is the order paid. The order's stock reservations are renewed
+// for the attempt and released when it fails. Each attempt is recorded,
+// failed ones with the reason the provider gave.
+func (s *Server) PayOrder(ctx context.Context, actor *auth.Claims, orderID int64, provider, token string) (*storer.Payment, error) {
+	o, err := s.GetOrder(ctx, actor, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.Status != storer.OrderStatusPending {
+		return nil, fmt.Errorf("%w: order is %s, only pending orders can be paid", ErrInvalid, o.Status)
+	}
+	if o.TotalPrice <= 0 {
+		return nil, fmt.Errorf("%w: order has nothing to pay", ErrInvalid)
+	}
+	pp, err := s.paymentProvider(provider, o.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.storer.ListPayments(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range existing {
+		switch p.Status {
+		case storer.PaymentPending, storer.PaymentAuthorized, storer.PaymentCaptured:
+			return nil, fmt.Errorf("error paying order: payment %d is %s: %w", p.ID, p.Status, storer.ErrConflict)
+		}
+	}
+	if err := s.storer.RenewReservations(ctx, o.ID, time.Now().Add(s.reservationTTL)); err != nil {
+		return nil, err
+	}
+
+	p, err := s.storer.CreatePayment(ctx, &storer.Payment{
+		OrderID:   o.ID,
+		Provider:  pp.Name(),
+		Amount:    o.TotalPrice,
+		Currency:  o.Currency,
+		Status:    storer.PaymentPending,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	res, err := pp.Authorize(ctx, payments.Charge{Reference: fmt.Sprintf("order-%d-payment-%d", o.ID, p.ID), Amount: p.Amount, Currency: p.Currency, Token: token})
+	if err != nil {
+		return nil, s.failPayment(ctx, p, storer.PaymentFailed, err)
+	}
+	// store the ref before capturing so the provider's webhooks about this
+	// payment can find it
+	p.ProviderRef = res.Ref
+	p.Status = storer.PaymentAuthorized
+	now := time.Now()
+	p.UpdatedAt = &now
+	if _, err := s.storer.UpdatePayment(ctx, p, ""); err != nil {
+		return nil, err
+	}
+	if _, err := pp.Capture(ctx, p.ProviderRef, p.Amount); err != nil {
+		// release the hold on the customer's money; should that fail too the
+		// authorization expires at the provider
+		status := storer.PaymentVoided
+		if _, verr := pp.Void(ctx, p.ProviderRef); verr != nil {
+			status = storer.PaymentFailed
+		}
+		return nil, s.failPayment(ctx, p, status, err)
+	}
+	p.Status = storer.PaymentCaptured
+	now = time.Now()
+	p.UpdatedAt = &now
+	return s.storer.UpdatePayment(ctx, p, storer.OrderStatusPaid)
+}
+
+// ListPayments lists the payment attempts of one of the user's orders;
+// admins may list any order's.
+func (s *Server) ListPayments(ctx context.Context, actor *auth.Claims, orderID int64) ([]storer.Payment, error) {
+	if _, err := s.GetOrder(ctx, actor, orderID); err != nil {
+		return nil, err
+	}
+	return s.storer.ListPayments(ctx, orderID)
+}
+
+func (s *Server) paymentProvider(name, orderMethod string) (payments.Provider, error) {
+	if name != "" {
+		pp, ok := s.paymentProviders[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown payment provider %q", ErrInvalid, name)
+		}
+		return pp, nil
+	}
+	if pp, ok := s.paymentProviders[orderMethod]; ok {
+		return pp, nil
+	}
+	pp, ok := s.paymentProviders[s.defaultPaymentProvider]
+	if !ok {
+		return nil, fmt.Errorf("error paying order: no payment provider is configured: %w", payments.ErrUnavailable)
+	}
+	return pp, nil
+}
+
+// failPayment records why the attempt failed, releases the order's stock
+// and returns cause.
+func (s *Server) failPayment(ctx context.Context, p *storer.Payment, status string, cause error) error {
+	p.Status = status
+	p.FailureReason = cause.Error()
+	now := time.Now()
+	p.UpdatedAt = &now
+	if _, err := s.storer.UpdatePayment(ctx, p, ""); err != nil {
+		return errors.Join(cause, err)
+	}
+	if err := s.storer.ReleaseReservations(ctx, p.OrderID, now); err != nil {
+		return errors.Join(cause, err)
+	}
+	return cause
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/payments"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+const paymentEventListLimit = 100
+
+// paymentTransitions lists the statuses a payment in a given status may
+// move to. Webhooks can arrive late or out of order, so an event that would
+// move a payment anywhere else is ignored.
+var paymentTransitions = map[string][]string{
+	storer.PaymentPending:    {storer.PaymentAuthorized, storer.PaymentCaptured, storer.PaymentVoided, storer.PaymentFailed},
+	storer.PaymentAuthorized: {storer.PaymentCaptured, storer.PaymentVoided, storer.PaymentFailed},
+}
+
+// HandlePaymentWebhook verifies and applies a webhook delivered by the named
+// provider. Every event is stored as received and applied at most once:
+// redeliveries of an applied event are acknowledged without effect, while
+// an event that failed to apply is retried.
+func (s *Server) HandlePaymentWebhook(ctx context.Context, provider string, h http.Header, payload []byte) error {
+	wp, err := s.webhookProvider(provider)
+	if err != nil {
+		return err
+	}
+	if err := wp.VerifyWebhook(h, payload, time.Now()); err != nil {
+		return err
+	}
+	ev, err := wp.ParseEvent(payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	e, err := s.storer.CreatePaymentEvent(ctx, &storer.PaymentEvent{
+		Provider:   provider,
+		EventID:    ev.ID,
+		Type:       ev.Type,
+		PaymentRef: ev.Ref,
+		Payload:    payload,
+		ReceivedAt: time.Now(),
+	})
+	if errors.Is(err, storer.ErrConflict) {
+		e, err = s.storer.GetPaymentEventByEventID(ctx, provider, ev.ID)
+		if err != nil {
+			return err
+		}
+		if e.ProcessedAt != nil {
+			return nil
+		}
+	} else if err != nil {
+		return err
+	}
+	return s.processPaymentEvent(ctx, e, ev)
+}
+
+func (s *Server) ListPaymentEvents(ctx context.Context) ([]storer.PaymentEvent, error) {
+	return s.storer.ListPaymentEvents(ctx, paymentEventListLimit)
+}
+
+// ReplayPaymentEvent applies a stored event again. Its signature was checked
+// when it was received, so it is not verified a second time.
+func (s *Server) ReplayPaymentEvent(ctx context.Context, id int64) (*storer.PaymentEvent, error) {
+	e, err := s.storer.GetPaymentEvent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	wp, err := s.webhookProvider(e.Provider)
+	if err != nil {
+		return nil, err
+	}
+	ev, err := wp.ParseEvent(e.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	if err := s.processPaymentEvent(ctx, e, ev); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (s *Server) webhookProvider(name string) (payments.WebhookProvider, error) {
+	wp, ok := s.paymentProviders[name].(payments.WebhookProvider)
+	if !ok {
+		return nil, fmt.Errorf("error handling payment webhook: provider %q: %w", name, storer.ErrNotFound)
+	}
+	return wp, nil
+}
+
+// processPaymentEvent applies ev and records the outcome on e.
+func (s *Server) processPaymentEvent(ctx context.Context, e *storer.PaymentEvent, ev *payments.Event) error {
+	err := s.applyPaymentEvent(ctx, e.Provider, ev)
+	e.Error = ""
+	if err != nil {
+		e.Error = err.Error()
+	} else {
+		now := time.Now()
+		e.ProcessedAt = &now
+	}
+	if _, uerr := s.storer.UpdatePaymentEvent(ctx, e); uerr != nil {
+		return errors.Join(err, uerr)
+	}
+	return err
+}
+
+func (s *Server) applyPaymentEvent(ctx context.Context, provider string, ev *payments.Event) error {
+	var status string
+	switch ev.Type {
+	case payments.EventCaptured:
+		status = storer.PaymentCaptured
+	case payments.EventFailed:
+		status = storer.PaymentFailed
+	case payments.EventVoided:
+		status = storer.PaymentVoided
+	default:
+		// events we do not act on
+		return nil
+	}
+	p, err := s.storer.GetPaymentByProviderRef(ctx, provider, ev.Ref)
+	if err != nil {
+		return err
+	}
+	if !canMovePayment(p.Status, status) {
+		return nil
+	}
+	var orderStatus string
+	if status == storer.PaymentCaptured {
+		// the event stays unprocessed, with the mismatch as its error, for
+		// an admin to look into
+		if ev.Amount != p.Amount || !strings.EqualFold(ev.Currency, p.Currency) {
+			return fmt.Errorf("%w: captured %s %s, but payment %d is for %s %s", ErrInvalid, ev.Amount, ev.Currency, p.ID, p.Amount, p.Currency)
+		}
+		o, err := s.storer.GetOrder(ctx, p.OrderID)
+		if err != nil {
+			return err
+		}
+		if o.Status == storer.OrderStatusPending {
+			orderStatus = storer.OrderStatusPaid
+		}
+	}
+	p.Status = status
+	if status == storer.PaymentFailed {
+		p.FailureReason = ev.Reason
+	}
+	now := time.Now()
+	p.UpdatedAt = &now
+	if _, err := s.storer.UpdatePayment(ctx, p, orderStatus); err != nil {
+		return err
+	}
+	if status != storer.PaymentCaptured {
+		// the order's stock is no longer held for this payment
+		return s.storer.ReleaseReservations(ctx, p.OrderID, now)
+	}
+	return nil
+}
+
+func canMovePayment(from, to string) bool {
+	for _, s := range paymentTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
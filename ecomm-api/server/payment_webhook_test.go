@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/m21power/ecomm/ecomm-api/payments"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+	"github.com/stretchr/testify/require"
+)
+
+// paymentStorer holds one pending order and its authorized payment, and
+// records the order status UpdatePayment was asked to set.
+type paymentStorer struct {
+	storer.Storer
+	payment     storer.Payment
+	orderStatus *string
+}
+
+func (ps *paymentStorer) GetPaymentByProviderRef(context.Context, string, string) (*storer.Payment, error) {
+	p := ps.payment
+	return &p, nil
+}
+
+func (ps *paymentStorer) GetOrder(_ context.Context, id int64) (*storer.Order, error) {
+	return &storer.Order{ID: id, Status: storer.OrderStatusPending}, nil
+}
+
+func (ps *paymentStorer) UpdatePayment(_ context.Context, p *storer.Payment, orderStatus string) (*storer.Payment, error) {
+	ps.orderStatus = &orderStatus
+	return p, nil
+}
+
+func TestApplyCapturedPaymentEvent(t *testing.T) {
+	tcs := []struct {
+		name string
+		ev   payments.Event
+		err  error
+	}{
+		{
+			name: "matches the payment",
+			ev:   payments.Event{Type: payments.EventCaptured, Ref: "fake_1", Amount: 4500, Currency: "usd"},
+		},
+		{
+			name: "different amount",
+			ev:   payments.Event{Type: payments.EventCaptured, Ref: "fake_1", Amount: 100, Currency: "USD"},
+			err:  ErrInvalid,
+		},
+		{
+			name: "different currency",
+			ev:   payments.Event{Type: payments.EventCaptured, Ref: "fake_1", Amount: 4500, Currency: "EUR"},
+			err:  ErrInvalid,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			st := &paymentStorer{payment: storer.Payment{ID: 2, OrderID: 11, Provider: "fake", ProviderRef: "fake_1", Amount: 4500, Currency: "USD", Status: storer.PaymentAuthorized}}
+			err := NewServer(st).applyPaymentEvent(context.Background(), "fake", &tc.ev)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				require.Nil(t, st.orderStatus, "the payment must not be updated")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, storer.OrderStatusPaid, *st.orderStatus)
+		})
+	}
+}
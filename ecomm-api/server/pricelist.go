@@ -0,0 +1,259 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (s *Server) CreateCustomerGroup(ctx context.Context, g *storer.CustomerGroup) (*storer.CustomerGroup, error) {
+	if err := validateCustomerGroup(g); err != nil {
+		return nil, err
+	}
+	g.CreatedAt = time.Now()
+	return s.storer.CreateCustomerGroup(ctx, g)
+}
+
+func (s *Server) GetCustomerGroup(ctx context.Context, id int64) (*storer.CustomerGroup, error) {
+	return s.storer.GetCustomerGroup(ctx, id)
+}
+
+func (s *Server) ListCustomerGroups(ctx context.Context) ([]storer.CustomerGroup, error) {
+	return s.storer.ListCustomerGroups(ctx)
+}
+
+func (s *Server) UpdateCustomerGroup(ctx context.Context, g *storer.CustomerGroup) (*storer.CustomerGroup, error) {
+	if err := validateCustomerGroup(g); err != nil {
+		return nil, err
+	}
+	g.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateCustomerGroup(ctx, g)
+}
+
+func (s *Server) DeleteCustomerGroup(ctx context.Context, id int64) error {
+	return s.storer.DeleteCustomerGroup(ctx, id)
+}
+
+// SetUserCustomerGroup moves a user into a customer group, or back to
+// catalog prices when groupID is nil.
+func (s *Server) SetUserCustomerGroup(ctx context.Context, userID int64, groupID *int64) (*storer.User, error) {
+	u, err := s.storer.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if groupID != nil {
+		if _, err := s.storer.GetCustomerGroup(ctx, *groupID); err != nil {
+			return nil, notFoundAsInvalid(err, "customer group %d does not exist", *groupID)
+		}
+	}
+	if err := s.storer.SetUserCustomerGroup(ctx, userID, groupID); err != nil {
+		return nil, err
+	}
+	u.CustomerGroupID = groupID
+	return u, nil
+}
+
+func (s *Server) CreatePriceList(ctx context.Context, pl *storer.PriceList) (*storer.PriceList, error) {
+	if err := s.validatePriceList(ctx, pl); err != nil {
+		return nil, err
+	}
+	pl.CreatedAt = time.Now()
+	return s.storer.CreatePriceList(ctx, pl)
+}
+
+func (s *Server) GetPriceList(ctx context.Context, id int64) (*storer.PriceList, error) {
+	return s.storer.GetPriceList(ctx, id)
+}
+
+// ListPriceLists lists the price lists of a customer group, or every list
+// when groupID is 0.
+func (s *Server) ListPriceLists(ctx context.Context, groupID int64) ([]storer.PriceList, error) {
+	return s.storer.ListPriceLists(ctx, groupID)
+}
+
+func (s *Server) UpdatePriceList(ctx context.Context, pl *storer.PriceList) (*storer.PriceList, error) {
+	if err := s.validatePriceList(ctx, pl); err != nil {
+		return nil, err
+	}
+	pl.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdatePriceList(ctx, pl)
+}
+
+func (s *Server) DeletePriceList(ctx context.Context, id int64) error {
+	return s.storer.DeletePriceList(ctx, id)
+}
+
+func validateCustomerGroup(g *storer.CustomerGroup) error {
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	return nil
+}
+
+func (s *Server) validatePriceList(ctx context.Context, pl *storer.PriceList) error {
+	pl.Name = strings.TrimSpace(pl.Name)
+	if pl.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	if _, err := s.storer.GetCustomerGroup(ctx, pl.CustomerGroupID); err != nil {
+		return notFoundAsInvalid(err, "customer group %d does not exist", pl.CustomerGroupID)
+	}
+	for i := range pl.Entries {
+		e := &pl.Entries[i]
+		if e.MinQuantity < 1 {
+			e.MinQuantity = 1
+		}
+		switch {
+		case (e.ProductID == nil) == (e.CategoryID == nil):
+			return fmt.Errorf("%w: entry %d must name either a product or a category", ErrInvalid, i)
+		case (e.Price == nil) == (e.PercentOff == 0):
+			return fmt.Errorf("%w: entry %d must set either a price or a percentage off", ErrInvalid, i)
+		case e.Price != nil && e.CategoryID != nil:
+			return fmt.Errorf("%w: entry %d sets a price for a whole category, use a percentage off", ErrInvalid, i)
+		case e.Price != nil && *e.Price < 0:
+			return fmt.Errorf("%w: entry %d has a negative price", ErrInvalid, i)
+		case e.PercentOff < 0 || e.PercentOff > 100:
+			return fmt.Errorf("%w: entry %d must take off between 0 and 100 percent", ErrInvalid, i)
+		}
+		if e.ProductID != nil {
+			if _, err := s.storer.GetProduct(ctx, *e.ProductID); err != nil {
+				return notFoundAsInvalid(err, "product %d does not exist", *e.ProductID)
+			}
+		}
+		if e.CategoryID != nil {
+			if _, err := s.storer.GetCategory(ctx, *e.CategoryID); err != nil {
+				return notFoundAsInvalid(err, "category %d does not exist", *e.CategoryID)
+			}
+		}
+	}
+	return nil
+}
+
+// PriceBook resolves the prices a customer pays from the active price lists
+// of their customer group. A nil PriceBook, for guests and customers
+// outside any group, leaves catalog prices alone.
+type PriceBook struct {
+	entries []storer.PriceListEntry
+	tree    []storer.Category
+}
+
+// PriceTier is the unit price from MinQuantity units on.
+type PriceTier struct {
+	MinQuantity int64
+	Price       money.Amount
+}
+
+// PriceBook returns the price book of the actor, who may be nil.
+func (s *Server) PriceBook(ctx context.Context, actor *auth.Claims) (*PriceBook, error) {
+	if actor == nil {
+		return nil, nil
+	}
+	return s.priceBook(ctx, actor.UserID)
+}
+
+func (s *Server) priceBook(ctx context.Context, userID int64) (*PriceBook, error) {
+	if userID == 0 {
+		return nil, nil
+	}
+	u, err := s.storer.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.CustomerGroupID == nil {
+		return nil, nil
+	}
+	lists, err := s.storer.ListPriceLists(ctx, *u.CustomerGroupID)
+	if err != nil {
+		return nil, err
+	}
+	b := &PriceBook{}
+	for _, pl := range lists {
+		if pl.Active {
+			b.entries = append(b.entries, pl.Entries...)
+		}
+	}
+	if len(b.entries) == 0 {
+		return nil, nil
+	}
+	if slices.ContainsFunc(b.entries, func(e storer.PriceListEntry) bool { return e.CategoryID != nil }) {
+		if b.tree, err = s.storer.ListCategories(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Price is the unit price of quantity units of p whose catalog price, the
+// product's or its variant's, is base. Of the entries that cover p and
+// whose quantity is reached, the lowest price wins; a price list never
+// raises a price above the catalog's.
+func (b *PriceBook) Price(p *storer.Product, base money.Amount, quantity int64) money.Amount {
+	price := base
+	if b == nil {
+		return price
+	}
+	for _, e := range b.entries {
+		if e.MinQuantity > quantity || !b.covers(&e, p) {
+			continue
+		}
+		if e.Price != nil {
+			price = min(price, *e.Price)
+		} else {
+			price = min(price, base.MulRate(1-e.PercentOff/100))
+		}
+	}
+	return price
+}
+
+// Tiers lists the quantities from which p gets cheaper than for a single
+// unit, with the unit price from then on.
+func (b *PriceBook) Tiers(p *storer.Product, base money.Amount) []PriceTier {
+	if b == nil {
+		return nil
+	}
+	var quantities []int64
+	for _, e := range b.entries {
+		if e.MinQuantity > 1 && b.covers(&e, p) && !slices.Contains(quantities, e.MinQuantity) {
+			quantities = append(quantities, e.MinQuantity)
+		}
+	}
+	slices.Sort(quantities)
+	var tiers []PriceTier
+	last := b.Price(p, base, 1)
+	for _, q := range quantities {
+		if price := b.Price(p, base, q); price < last {
+			tiers = append(tiers, PriceTier{MinQuantity: q, Price: price})
+			last = price
+		}
+	}
+	return tiers
+}
+
+// covers reports whether e applies to p. A category entry covers the
+// category's subcategories.
+func (b *PriceBook) covers(e *storer.PriceListEntry, p *storer.Product) bool {
+	if e.ProductID != nil {
+		return *e.ProductID == p.ID
+	}
+	return p.CategoryID != nil && slices.Contains(descendantIDs(b.tree, *e.CategoryID), *p.CategoryID)
+}
+
+// customerPrice reprices a catalog-priced item for the customer of b.
+func (s *Server) customerPrice(ctx context.Context, b *PriceBook, oi *storer.OrderItem) error {
+	if b == nil {
+		return nil
+	}
+	p, err := s.storer.GetProduct(ctx, oi.ProductID)
+	if err != nil {
+		return err
+	}
+	oi.Price = b.Price(p, oi.Price, oi.Quantity)
+	return nil
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (s *Server) CreatePromotion(ctx context.Context, p *storer.Promotion) (*storer.Promotion, error) {
+	if err := validatePromotion(p); err != nil {
+		return nil, err
+	}
+	p.CreatedAt = time.Now()
+	return s.storer.CreatePromotion(ctx, p)
+}
+
+func (s *Server) GetPromotion(ctx context.Context, id int64) (*storer.Promotion, error) {
+	return s.storer.GetPromotion(ctx, id)
+}
+
+func (s *Server) ListPromotions(ctx context.Context) ([]storer.Promotion, error) {
+	return s.storer.ListPromotions(ctx)
+}
+
+func (s *Server) UpdatePromotion(ctx context.Context, p *storer.Promotion) (*storer.Promotion, error) {
+	if err := validatePromotion(p); err != nil {
+		return nil, err
+	}
+	p.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdatePromotion(ctx, p)
+}
+
+func (s *Server) DeletePromotion(ctx context.Context, id int64) error {
+	return s.storer.DeletePromotion(ctx, id)
+}
+
+// applyPromotions works out the discounts for a priced order. Automatic
+// promotions apply whenever the order qualifies; a code the customer
+// entered must be valid and apply to the order. Stackable promotions are
+// combined, any other is applied alone, and the customer gets whichever
+// choice saves the most.
+func (s *Server) applyPromotions(ctx context.Context, o *storer.Order, subtotal money.Amount, codes []string) ([]storer.OrderDiscount, error) {
+	now := time.Now()
+	candidates, err := s.storer.ListAutomaticPromotions(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	var entered []int64
+	for _, code := range normalizeCodes(codes) {
+		p, err := s.storer.GetPromotionByCode(ctx, code)
+		if errors.Is(err, storer.ErrNotFound) {
+			return nil, fmt.Errorf("%w: promotion code %q is not valid", ErrInvalid, code)
+		}
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, *p)
+		entered = append(entered, p.ID)
+	}
+
+	pc, err := s.newPromotionContext(ctx, o, subtotal, candidates)
+	if err != nil {
+		return nil, err
+	}
+	var stacked, best []storer.OrderDiscount
+	var stackedTotal, bestTotal money.Amount
+	for _, p := range candidates {
+		why, err := s.promotionUnavailable(ctx, &p, o, now)
+		if err != nil {
+			return nil, err
+		}
+		var amount money.Amount
+		if why == "" {
+			amount = pc.discount(&p)
+			if amount <= 0 {
+				why = "does not apply to this order"
+			}
+		}
+		if why != "" {
+			if slices.Contains(entered, p.ID) {
+				return nil, fmt.Errorf("%w: promotion code %q %s", ErrInvalid, *p.Code, why)
+			}
+			continue
+		}
+		d := storer.OrderDiscount{PromotionID: &p.ID, Code: p.Code, Description: p.Name, Amount: amount, Shipping: p.Kind == storer.PromotionFreeShipping}
+		if p.Stackable {
+			stacked = append(stacked, d)
+			stackedTotal += amount
+		} else if amount > bestTotal {
+			best = []storer.OrderDiscount{d}
+			bestTotal = amount
+		}
+	}
+	discounts := stacked
+	if bestTotal > stackedTotal {
+		discounts = best
+	}
+	return capDiscounts(discounts, subtotal+o.ShippingPrice), nil
+}
+
+// itemDiscount is the part of discounts taken off the items, as opposed to
+// shipping. It never exceeds their subtotal.
+func itemDiscount(discounts []storer.OrderDiscount, subtotal money.Amount) money.Amount {
+	var amount money.Amount
+	for _, d := range discounts {
+		if !d.Shipping {
+			amount += d.Amount
+		}
+	}
+	return min(amount, subtotal)
+}
+
+// promotionUnavailable explains why p cannot be used for o right now, or
+// returns "" if it can.
+func (s *Server) promotionUnavailable(ctx context.Context, p *storer.Promotion, o *storer.Order, now time.Time) (string, error) {
+	switch {
+	case !p.Active:
+		return "is not active", nil
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return "is not valid yet", nil
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return "has expired", nil
+	case p.UsageLimit != nil && p.TimesUsed >= *p.UsageLimit:
+		return "has been fully redeemed", nil
+	}
+	if p.PerUserLimit != nil {
+		n, err := s.storer.CountPromotionRedemptions(ctx, p.ID, o.UserID)
+		if err != nil {
+			return "", err
+		}
+		if n >= *p.PerUserLimit {
+			return "has already been used the maximum number of times", nil
+		}
+	}
+	return "", nil
+}
+
+// promotionContext holds what the discount calculation needs to know about
+// the order's items.
+type promotionContext struct {
+	order      *storer.Order
+	subtotal   money.Amount
+	categories map[int64]*int64 // product id to category id
+	tree       []storer.Category
+}
+
+func (s *Server) newPromotionContext(ctx context.Context, o *storer.Order, subtotal money.Amount, candidates []storer.Promotion) (*promotionContext, error) {
+	pc := &promotionContext{order: o, subtotal: subtotal, categories: make(map[int64]*int64)}
+	scoped := slices.ContainsFunc(candidates, func(p storer.Promotion) bool { return len(p.Scope.CategoryIDs) > 0 })
+	if !scoped {
+		return pc, nil
+	}
+	tree, err := s.storer.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pc.tree = tree
+	for _, oi := range o.Items {
+		p, err := s.storer.GetProduct(ctx, oi.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		pc.categories[oi.ProductID] = p.CategoryID
+	}
+	return pc, nil
+}
+
+// eligible reports whether the item falls within the promotion's scope. A
+// category in the scope covers its subcategories.
+func (pc *promotionContext) eligible(p *storer.Promotion, oi *storer.OrderItem) bool {
+	if p.Scope.Empty() || slices.Contains(p.Scope.ProductIDs, oi.ProductID) {
+		return true
+	}
+	cat := pc.categories[oi.ProductID]
+	if cat == nil {
+		return false
+	}
+	for _, id := range p.Scope.CategoryIDs {
+		if slices.Contains(descendantIDs(pc.tree, id), *cat) {
+			return true
+		}
+	}
+	return false
+}
+
+// discount returns the amount p takes off the order, 0 if it does not
+// apply.
+func (pc *promotionContext) discount(p *storer.Promotion) money.Amount {
+	if pc.subtotal < p.MinOrderValue {
+		return 0
+	}
+	var eligible, amount money.Amount
+	for i := range pc.order.Items {
+		oi := &pc.order.Items[i]
+		if !pc.eligible(p, oi) {
+			continue
+		}
+		eligible += oi.Price.Mul(oi.Quantity)
+		if p.Kind == storer.PromotionBuyXGetY {
+			free := oi.Quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+			amount += oi.Price.Mul(free)
+		}
+	}
+	if eligible == 0 {
+		return 0
+	}
+	switch p.Kind {
+	case storer.PromotionPercentage:
+		amount = eligible.MulRate(p.Percent / 100)
+	case storer.PromotionFixedAmount:
+		amount = min(p.Amount, eligible)
+	case storer.PromotionFreeShipping:
+		amount = pc.order.ShippingPrice
+	}
+	return amount
+}
+
+// capDiscounts trims the discounts so that together they never exceed
+// limit, the most the order can be reduced by.
+func capDiscounts(discounts []storer.OrderDiscount, limit money.Amount) []storer.OrderDiscount {
+	var res []storer.OrderDiscount
+	for _, d := range discounts {
+		if limit <= 0 {
+			break
+		}
+		d.Amount = min(d.Amount, limit)
+		limit -= d.Amount
+		res = append(res, d)
+	}
+	return res
+}
+
+func normalizeCodes(codes []string) []string {
+	var res []string
+	for _, c := range codes {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c != "" && !slices.Contains(res, c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func validatePromotion(p *storer.Promotion) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Code != nil {
+		code := strings.ToUpper(strings.TrimSpace(*p.Code))
+		p.Code = &code
+		if code == "" {
+			p.Code = nil
+		}
+	}
+	if p.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	switch p.Kind {
+	case storer.PromotionPercentage:
+		if p.Percent <= 0 || p.Percent > 100 {
+			return fmt.Errorf("%w: a percentage must be between 0 and 100", ErrInvalid)
+		}
+	case storer.PromotionFixedAmount:
+		if p.Amount <= 0 {
+			return fmt.Errorf("%w: a fixed amount must be positive", ErrInvalid)
+		}
+	case storer.PromotionFreeShipping:
+	case storer.PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return fmt.Errorf("%w: buy and get quantities must be positive", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown promotion kind %q", ErrInvalid, p.Kind)
+	}
+	if p.MinOrderValue < 0 {
+		return fmt.Errorf("%w: minimum order value cannot be negative", ErrInvalid)
+	}
+	if (p.UsageLimit != nil && *p.UsageLimit <= 0) || (p.PerUserLimit != nil && *p.PerUserLimit <= 0) {
+		return fmt.Errorf("%w: usage limits must be positive", ErrInvalid)
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("%w: a promotion must end after it starts", ErrInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+	"github.com/stretchr/testify/require"
+)
+
+// promotionStorer serves what applyPromotions reads. Any other Storer
+// method panics.
+type promotionStorer struct {
+	storer.Storer
+	automatic  []storer.Promotion
+	codes      []storer.Promotion
+	redeemed   int64
+	categories []storer.Category
+	products   map[int64]*int64 // product id to category id
+}
+
+func (ps *promotionStorer) ListAutomaticPromotions(context.Context, time.Time) ([]storer.Promotion, error) {
+	return ps.automatic, nil
+}
+
+func (ps *promotionStorer) GetPromotionByCode(_ context.Context, code string) (*storer.Promotion, error) {
+	for _, p := range ps.codes {
+		if *p.Code == code {
+			return &p, nil
+		}
+	}
+	return nil, storer.ErrNotFound
+}
+
+func (ps *promotionStorer) CountPromotionRedemptions(context.Context, int64, int64) (int64, error) {
+	return ps.redeemed, nil
+}
+
+func (ps *promotionStorer) ListCategories(context.Context) ([]storer.Category, error) {
+	return ps.categories, nil
+}
+
+func (ps *promotionStorer) GetProduct(_ context.Context, id int64) (*storer.Product, error) {
+	return &storer.Product{ID: id, CategoryID: ps.products[id]}, nil
+}
+
+func TestApplyPromotions(t *testing.T) {
+	// product 1 is in category 3, a subcategory of 2; product 2 has none
+	parent := int64(2)
+	child := int64(3)
+	categories := []storer.Category{{ID: 2}, {ID: 3, ParentID: &parent}}
+	products := map[int64]*int64{1: &child}
+	order := func() *storer.Order {
+		return &storer.Order{
+			UserID:        4,
+			ShippingPrice: 500,
+			Items: []storer.OrderItem{
+				{ProductID: 1, Price: 2000, Quantity: 3},
+				{ProductID: 2, Price: 1000, Quantity: 1},
+			},
+		}
+	}
+	const subtotal money.Amount = 7000
+	promo := func(id int64, kind string, stackable bool) storer.Promotion {
+		return storer.Promotion{ID: id, Name: kind, Kind: kind, Stackable: stackable, Active: true}
+	}
+	percent := func(id int64, pct float64, stackable bool) storer.Promotion {
+		p := promo(id, storer.PromotionPercentage, stackable)
+		p.Percent = pct
+		return p
+	}
+	fixed := func(id int64, amount money.Amount, stackable bool) storer.Promotion {
+		p := promo(id, storer.PromotionFixedAmount, stackable)
+		p.Amount = amount
+		return p
+	}
+	withCode := func(p storer.Promotion, code string) storer.Promotion {
+		p.Code = &code
+		return p
+	}
+	one := int64(1)
+
+	tcs := []struct {
+		name      string
+		st        *promotionStorer
+		codes     []string
+		want      []money.Amount
+		wantItems money.Amount
+		err       error
+	}{
+		{
+			name: "stackable promotions combine when they save more",
+			st:   &promotionStorer{automatic: []storer.Promotion{percent(1, 10, true), fixed(2, 500, true), fixed(3, 1000, false)}},
+			want: []money.Amount{700, 500}, wantItems: 1200,
+		},
+		{
+			name: "a single promotion wins when it saves more",
+			st:   &promotionStorer{automatic: []storer.Promotion{percent(1, 10, true), fixed(2, 500, true), percent(3, 20, false)}},
+			want: []money.Amount{1400}, wantItems: 1400,
+		},
+		{
+			name: "buy two get one free",
+			st: &promotionStorer{automatic: []storer.Promotion{func() storer.Promotion {
+				p := promo(1, storer.PromotionBuyXGetY, false)
+				p.BuyQuantity, p.GetQuantity = 2, 1
+				return p
+			}()}},
+			// only the item bought three times earns a free unit
+			want: []money.Amount{2000}, wantItems: 2000,
+		},
+		{
+			name: "a category covers its subcategories",
+			st: &promotionStorer{automatic: []storer.Promotion{func() storer.Promotion {
+				p := percent(1, 10, false)
+				p.Scope.CategoryIDs = []int64{2}
+				return p
+			}()}, categories: categories, products: products},
+			want: []money.Amount{600}, wantItems: 600,
+		},
+		{
+			name: "free shipping is not an item discount",
+			st:   &promotionStorer{automatic: []storer.Promotion{promo(1, storer.PromotionFreeShipping, false)}},
+			want: []money.Amount{500}, wantItems: 0,
+		},
+		{
+			name: "below the minimum order value",
+			st: &promotionStorer{automatic: []storer.Promotion{func() storer.Promotion {
+				p := percent(1, 10, false)
+				p.MinOrderValue = 7001
+				return p
+			}()}},
+		},
+		{
+			name: "entered code below the minimum order value",
+			st: &promotionStorer{codes: []storer.Promotion{func() storer.Promotion {
+				p := withCode(fixed(1, 500, false), "BIG")
+				p.MinOrderValue = 10000
+				return p
+			}()}},
+			codes: []string{"big"},
+			err:   ErrInvalid,
+		},
+		{
+			name: "entered code used up by the user",
+			st: &promotionStorer{codes: []storer.Promotion{func() storer.Promotion {
+				p := withCode(fixed(1, 500, false), "ONCE")
+				p.PerUserLimit = &one
+				return p
+			}()}, redeemed: 1},
+			codes: []string{"ONCE"},
+			err:   ErrInvalid,
+		},
+		{
+			name:  "unknown code",
+			st:    &promotionStorer{},
+			codes: []string{"NOPE"},
+			err:   ErrInvalid,
+		},
+		{
+			name: "capped at the subtotal plus shipping",
+			st:   &promotionStorer{automatic: []storer.Promotion{fixed(1, 5000, true), fixed(2, 5000, true), promo(3, storer.PromotionFreeShipping, true)}},
+			// nothing is left for free shipping
+			want: []money.Amount{5000, 2500}, wantItems: 7000,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewServer(tc.st)
+			discounts, err := s.applyPromotions(context.Background(), order(), subtotal, tc.codes)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			var amounts []money.Amount
+			for _, d := range discounts {
+				amounts = append(amounts, d.Amount)
+			}
+			require.Equal(t, tc.want, amounts)
+			require.Equal(t, tc.wantItems, itemDiscount(discounts, subtotal))
+		})
+	}
+}
+
+func TestCapDiscounts(t *testing.T) {
+	discounts := []storer.OrderDiscount{{Amount: 300}, {Amount: 500}, {Amount: 200}}
+	tcs := []struct {
+		name  string
+		limit money.Amount
+		want  []money.Amount
+	}{
+		{name: "under the limit", limit: 2000, want: []money.Amount{300, 500, 200}},
+		{name: "trims the last one that fits", limit: 600, want: []money.Amount{300, 300}},
+		{name: "nothing to take off", limit: 0},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var amounts []money.Amount
+			for _, d := range capDiscounts(discounts, tc.limit) {
+				amounts = append(amounts, d.Amount)
+			}
+			require.Equal(t, tc.want, amounts)
+		})
+	}
+}
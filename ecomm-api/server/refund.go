@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/payments"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// RefundOrder gives back money on the order's captured payment. A refund
+// names an amount, order items with quantities, or both; items without an
+// amount are refunded at what was paid for them, and a refund naming
+// neither gives back everything not refunded yet. The refund is recorded
+// before the provider is asked, so it counts against the captured amount
+// while in flight.
+func (s *Server) RefundOrder(ctx context.Context, r *storer.Refund) (*storer.Refund, error) {
+	o, err := s.storer.GetOrder(ctx, r.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Status {
+	case storer.OrderStatusPaid, storer.OrderStatusShipped, storer.OrderStatusDelivered, storer.OrderStatusPartiallyRefunded:
+	default:
+		return nil, fmt.Errorf("%w: order is %s, only paid orders can be refunded", ErrInvalid, o.Status)
+	}
+	p, err := s.capturedPayment(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	pp, ok := s.paymentProviders[p.Provider]
+	if !ok {
+		return nil, fmt.Errorf("error refunding order: payment provider %q is not configured: %w", p.Provider, payments.ErrUnavailable)
+	}
+	remaining, err := s.refundableAmount(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	itemsAmount, err := refundItems(o, r.Items)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case r.Amount > remaining:
+		return nil, fmt.Errorf("%w: only %s of the payment is left to refund", ErrInvalid, remaining)
+	case r.Amount < 0:
+		return nil, fmt.Errorf("%w: refund amount must be positive", ErrInvalid)
+	case r.Amount == 0 && len(r.Items) > 0:
+		// rounding and shipping refunds can leave slightly less than the
+		// items are worth
+		r.Amount = min(itemsAmount, remaining)
+	case r.Amount == 0:
+		r.Amount = remaining
+	}
+	if r.Amount == 0 {
+		return nil, fmt.Errorf("%w: the payment is already fully refunded", ErrInvalid)
+	}
+
+	r.PaymentID = p.ID
+	r.Status = storer.RefundPending
+	r.CreatedAt = time.Now()
+	if _, err := s.storer.CreateRefund(ctx, r); err != nil {
+		return nil, err
+	}
+	res, err := pp.Refund(ctx, p.ProviderRef, r.Amount)
+	now := time.Now()
+	r.UpdatedAt = &now
+	if err != nil {
+		r.Status = storer.RefundFailed
+		r.FailureReason = err.Error()
+		if _, uerr := s.storer.UpdateRefund(ctx, r); uerr != nil {
+			return nil, errors.Join(err, uerr)
+		}
+		return nil, err
+	}
+	r.Status = storer.RefundSucceeded
+	r.ProviderRef = res.Ref
+	return s.storer.UpdateRefund(ctx, r)
+}
+
+// ListRefunds lists the refunds of one of the user's orders; admins may
+// list any order's.
+func (s *Server) ListRefunds(ctx context.Context, actor *auth.Claims, orderID int64) ([]storer.Refund, error) {
+	if _, err := s.GetOrder(ctx, actor, orderID); err != nil {
+		return nil, err
+	}
+	return s.storer.ListRefunds(ctx, orderID)
+}
+
+func (s *Server) capturedPayment(ctx context.Context, orderID int64) (*storer.Payment, error) {
+	ps, err := s.storer.ListPayments(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ps {
+		if ps[i].Status == storer.PaymentCaptured {
+			return &ps[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: order has no captured payment", ErrInvalid)
+}
+
+// refundableAmount is what is left of p after refunds that have not failed.
+func (s *Server) refundableAmount(ctx context.Context, p *storer.Payment) (money.Amount, error) {
+	rs, err := s.storer.ListRefunds(ctx, p.OrderID)
+	if err != nil {
+		return 0, err
+	}
+	remaining := p.Amount
+	for _, r := range rs {
+		if r.PaymentID == p.ID && r.Status != storer.RefundFailed {
+			remaining -= r.Amount
+		}
+	}
+	return max(remaining, 0), nil
+}
+
+// refundItems checks the refunded items against the order, copies their
+// product and variant for restocking and returns what was paid for them.
+func refundItems(o *storer.Order, items []storer.RefundItem) (money.Amount, error) {
+	var subtotal money.Amount
+	for _, oi := range o.Items {
+		subtotal += oi.Price.Mul(oi.Quantity)
+	}
+	discount := itemDiscount(o.Discounts, subtotal)
+	var amount money.Amount
+	seen := map[int64]bool{}
+	for i := range items {
+		ri := &items[i]
+		if ri.Quantity <= 0 {
+			return 0, fmt.Errorf("%w: quantity must be positive", ErrInvalid)
+		}
+		if seen[ri.OrderItemID] {
+			return 0, fmt.Errorf("%w: order item %d is listed twice", ErrInvalid, ri.OrderItemID)
+		}
+		seen[ri.OrderItemID] = true
+		oi := findOrderItem(o, ri.OrderItemID)
+		if oi == nil {
+			return 0, fmt.Errorf("%w: order item %d is not part of order %d", ErrInvalid, ri.OrderItemID, o.ID)
+		}
+		if ri.Quantity > oi.Quantity {
+			return 0, fmt.Errorf("%w: order item %d was ordered %d times", ErrInvalid, oi.ID, oi.Quantity)
+		}
+		ri.ProductID = oi.ProductID
+		ri.VariantID = oi.VariantID
+		amount += itemRefundAmount(oi, ri.Quantity, discount, subtotal)
+	}
+	return amount, nil
+}
+
+// itemRefundAmount is what was paid for quantity units of oi: their price
+// and the tax charged on top of it, less their share of the discount taken
+// off the order's items. Shipping is only given back by refunding an amount.
+func itemRefundAmount(oi *storer.OrderItem, quantity int64, discount, subtotal money.Amount) money.Amount {
+	line := oi.Price.Mul(quantity)
+	var tax money.Amount
+	for _, t := range oi.Taxes {
+		if !t.Included {
+			tax += t.Amount
+		}
+	}
+	tax = tax.MulRate(float64(quantity) / float64(oi.Quantity))
+	return line - discount.Share(line, subtotal) + tax
+}
+
+func findOrderItem(o *storer.Order, id int64) *storer.OrderItem {
+	for i := range o.Items {
+		if o.Items[i].ID == id {
+			return &o.Items[i]
+		}
+	}
+	return nil
+}
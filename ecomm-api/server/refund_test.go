@@ -0,0 +1,80 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefundItems(t *testing.T) {
+	order := func() *storer.Order {
+		return &storer.Order{
+			ID: 11,
+			Items: []storer.OrderItem{
+				// 20% tax on the price less 10% off
+				{ID: 1, ProductID: 9, Price: 2000, Quantity: 2, Taxes: []storer.OrderItemTax{{Amount: 720}}},
+				{ID: 2, ProductID: 8, Price: 1000, Quantity: 1, Taxes: []storer.OrderItemTax{{Amount: 180}}},
+			},
+			Discounts: []storer.OrderDiscount{
+				{Description: "10% off", Amount: 500},
+				{Description: "free shipping", Amount: 700, Shipping: true},
+			},
+		}
+	}
+	tcs := []struct {
+		name  string
+		order func() *storer.Order
+		items []storer.RefundItem
+		want  money.Amount
+		err   error
+	}{
+		{
+			name:  "free shipping is not taken off the items",
+			order: order,
+			items: []storer.RefundItem{{OrderItemID: 1, Quantity: 1}},
+			// 2000 less 200 of the 500 item discount, plus 360 tax
+			want: 2160,
+		},
+		{
+			name:  "every item",
+			order: order,
+			items: []storer.RefundItem{{OrderItemID: 1, Quantity: 2}, {OrderItemID: 2, Quantity: 1}},
+			want:  5400,
+		},
+		{
+			name: "included tax is already in the price",
+			order: func() *storer.Order {
+				o := order()
+				o.Items[1].Taxes[0].Included = true
+				return o
+			},
+			items: []storer.RefundItem{{OrderItemID: 2, Quantity: 1}},
+			want:  900,
+		},
+		{
+			name:  "more than ordered",
+			order: order,
+			items: []storer.RefundItem{{OrderItemID: 2, Quantity: 2}},
+			err:   ErrInvalid,
+		},
+		{
+			name:  "not in the order",
+			order: order,
+			items: []storer.RefundItem{{OrderItemID: 3, Quantity: 1}},
+			err:   ErrInvalid,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := refundItems(tc.order(), tc.items)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, amount)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// defaultReservationTTL is how long checkout holds an order's stock while
+// the customer pays.
+const defaultReservationTTL = 15 * time.Minute
+
+func WithReservationTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		if ttl > 0 {
+			s.reservationTTL = ttl
+		}
+	}
+}
+
+// SweepReservations releases expired stock reservations every interval
+// until ctx is cancelled.
+func (s *Server) SweepReservations(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		n, err := s.storer.ReleaseExpiredReservations(ctx, time.Now())
+		if err != nil {
+			log.Printf("error sweeping stock reservations: %v", err)
+		} else if n > 0 {
+			log.Printf("released %d expired stock reservations", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// returnTransitions lists the statuses an admin may move a return in a
+// given status to. Inspection is optional; a return may be accepted as soon
+// as it is received.
+var returnTransitions = map[string][]string{
+	storer.ReturnRequested: {storer.ReturnApproved, storer.ReturnRejected},
+	storer.ReturnApproved:  {storer.ReturnReceived, storer.ReturnRejected},
+	storer.ReturnReceived:  {storer.ReturnInspected, storer.ReturnAccepted, storer.ReturnRejected},
+	storer.ReturnInspected: {storer.ReturnAccepted, storer.ReturnRejected},
+}
+
+var returnStatuses = []string{
+	storer.ReturnRequested,
+	storer.ReturnApproved,
+	storer.ReturnRejected,
+	storer.ReturnReceived,
+	storer.ReturnInspected,
+	storer.ReturnAccepted,
+}
+
+var returnReasons = []string{
+	storer.ReturnReasonDamaged,
+	storer.ReturnReasonDefective,
+	storer.ReturnReasonWrongItem,
+	storer.ReturnReasonNotAsDescribed,
+	storer.ReturnReasonNoLongerNeeded,
+	storer.ReturnReasonOther,
+}
+
+// CreateReturn opens a return on one of the user's paid orders. Units of an
+// item that are already being returned or were refunded cannot be returned
+// again.
+func (s *Server) CreateReturn(ctx context.Context, actor *auth.Claims, r *storer.Return) (*storer.Return, error) {
+	o, err := s.GetOrder(ctx, actor, r.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	switch o.Status {
+	case storer.OrderStatusPaid, storer.OrderStatusShipped, storer.OrderStatusDelivered, storer.OrderStatusPartiallyRefunded:
+	default:
+		return nil, fmt.Errorf("%w: order is %s, only paid orders can be returned", ErrInvalid, o.Status)
+	}
+	if len(r.Items) == 0 {
+		return nil, fmt.Errorf("%w: a return needs at least one item", ErrInvalid)
+	}
+	taken, err := s.returnedQuantities(ctx, o.ID)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[int64]bool{}
+	for _, ri := range r.Items {
+		if !slices.Contains(returnReasons, ri.ReasonCode) {
+			return nil, fmt.Errorf("%w: reason_code must be one of %v", ErrInvalid, returnReasons)
+		}
+		if ri.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalid)
+		}
+		if seen[ri.OrderItemID] {
+			return nil, fmt.Errorf("%w: order item %d is listed twice", ErrInvalid, ri.OrderItemID)
+		}
+		seen[ri.OrderItemID] = true
+		oi := findOrderItem(o, ri.OrderItemID)
+		if oi == nil {
+			return nil, fmt.Errorf("%w: order item %d is not part of order %d", ErrInvalid, ri.OrderItemID, o.ID)
+		}
+		if left := oi.Quantity - taken[oi.ID]; ri.Quantity > left {
+			return nil, fmt.Errorf("%w: only %d units of order item %d can be returned", ErrInvalid, left, oi.ID)
+		}
+	}
+	r.UserID = o.UserID
+	r.Status = storer.ReturnRequested
+	r.CreatedAt = time.Now()
+	return s.storer.CreateReturn(ctx, r)
+}
+
+// GetReturn returns one of the user's returns; admins may read any return.
+func (s *Server) GetReturn(ctx context.Context, actor *auth.Claims, id int64) (*storer.Return, error) {
+	r, err := s.storer.GetReturn(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.UserID != actor.UserID && !actor.IsAdmin {
+		return nil, fmt.Errorf("error getting return: %w", storer.ErrNotFound)
+	}
+	return r, nil
+}
+
+// ListReturns lists the user's returns, or every user's for admins.
+func (s *Server) ListReturns(ctx context.Context, actor *auth.Claims, f storer.ReturnFilter) ([]storer.Return, error) {
+	if !actor.IsAdmin {
+		f.UserID = actor.UserID
+	}
+	for _, st := range f.Statuses {
+		if !slices.Contains(returnStatuses, st) {
+			return nil, fmt.Errorf("%w: unknown return status %q", ErrInvalid, st)
+		}
+	}
+	return s.storer.ListReturns(ctx, f)
+}
+
+// ListOrderReturns lists the returns of one of the user's orders; admins may
+// list any order's.
+func (s *Server) ListOrderReturns(ctx context.Context, actor *auth.Claims, orderID int64) ([]storer.Return, error) {
+	if _, err := s.GetOrder(ctx, actor, orderID); err != nil {
+		return nil, err
+	}
+	return s.storer.ListReturns(ctx, storer.ReturnFilter{OrderID: orderID})
+}
+
+// UpdateReturn moves a return along on behalf of an admin. Accepting it
+// refunds its items, restocking them if the return says so. The acceptance
+// is claimed before the refund is made, so concurrent requests cannot both
+// refund the return; if the refund fails the return goes back to its
+// previous status.
+func (s *Server) UpdateReturn(ctx context.Context, actor *auth.Claims, r *storer.Return) (*storer.Return, error) {
+	if !actor.IsAdmin {
+		return nil, fmt.Errorf("%w: only admins can update returns", ErrForbidden)
+	}
+	current, err := s.storer.GetReturn(ctx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != current.Status && !slices.Contains(returnTransitions[current.Status], r.Status) {
+		return nil, fmt.Errorf("%w: a %s return cannot become %s", ErrInvalid, current.Status, r.Status)
+	}
+	r.UpdatedAt = toTimePtr(time.Now())
+	if r.Status != storer.ReturnAccepted || current.Status == storer.ReturnAccepted {
+		return s.storer.UpdateReturn(ctx, r, current.Status)
+	}
+	if _, err := s.storer.UpdateReturn(ctx, r, current.Status); err != nil {
+		return nil, err
+	}
+	refund := &storer.Refund{
+		OrderID: r.OrderID,
+		Reason:  fmt.Sprintf("return %d", r.ID),
+		Restock: r.Restock,
+	}
+	for _, ri := range r.Items {
+		refund.Items = append(refund.Items, storer.RefundItem{OrderItemID: ri.OrderItemID, Quantity: ri.Quantity})
+	}
+	refund, err = s.RefundOrder(ctx, refund)
+	if err != nil {
+		r.Status = current.Status
+		if _, uerr := s.storer.UpdateReturn(ctx, r, storer.ReturnAccepted); uerr != nil {
+			log.Printf("error reopening return %d after a failed refund: %v", r.ID, uerr)
+		}
+		return nil, err
+	}
+	r.RefundID = &refund.ID
+	return s.storer.UpdateReturn(ctx, r, storer.ReturnAccepted)
+}
+
+// returnedQuantities sums, per order item, the units in open returns and in
+// refunds that have not failed. Accepted returns are counted through the
+// refund they created.
+func (s *Server) returnedQuantities(ctx context.Context, orderID int64) (map[int64]int64, error) {
+	taken := map[int64]int64{}
+	returns, err := s.storer.ListReturns(ctx, storer.ReturnFilter{OrderID: orderID})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range returns {
+		if r.Status == storer.ReturnRejected || r.Status == storer.ReturnAccepted {
+			continue
+		}
+		for _, ri := range r.Items {
+			taken[ri.OrderItemID] += ri.Quantity
+		}
+	}
+	refunds, err := s.storer.ListRefunds(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, rf := range refunds {
+		if rf.Status == storer.RefundFailed {
+			continue
+		}
+		for _, ri := range rf.Items {
+			taken[ri.OrderItemID] += ri.Quantity
+		}
+	}
+	return taken, nil
+}
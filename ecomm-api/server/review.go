@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// CreateReview stores the user's review of a product. The verified-purchase
+// flag is set when the user has a paid order containing the product.
+// The review awaits moderation, or is flagged if it contains blocked words.
+func (s *Server) CreateReview(ctx context.Context, r *storer.Review) (*storer.Review, error) {
+	if err := validateReview(r); err != nil {
+		return nil, err
+	}
+	if _, err := s.storer.GetProduct(ctx, r.ProductID); err != nil {
+		return nil, err
+	}
+	verified, err := s.storer.HasPaidOrderItem(ctx, r.UserID, r.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	r.VerifiedPurchase = verified
+	r.Status = s.screenReview(r)
+	r.CreatedAt = time.Now()
+	return s.storer.CreateReview(ctx, r)
+}
+
+// GetReview returns the review only if it belongs to productID.
+func (s *Server) GetReview(ctx context.Context, productID, id int64) (*storer.Review, error) {
+	r, err := s.storer.GetReview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.ProductID != productID {
+		return nil, fmt.Errorf("error getting review: %w", storer.ErrNotFound)
+	}
+	return r, nil
+}
+
+// ListReviews returns the product's published reviews.
+func (s *Server) ListReviews(ctx context.Context, productID int64) ([]storer.Review, error) {
+	return s.storer.ListReviews(ctx, storer.ReviewFilter{ProductID: productID, Statuses: []string{storer.ReviewStatusApproved}})
+}
+
+// UpdateReview saves the author's edits to a review. Edited reviews go back
+// through moderation.
+func (s *Server) UpdateReview(ctx context.Context, actor *auth.Claims, r *storer.Review) (*storer.Review, error) {
+	if r.UserID != actor.UserID {
+		return nil, fmt.Errorf("%w: only the author can edit a review", ErrForbidden)
+	}
+	if err := validateReview(r); err != nil {
+		return nil, err
+	}
+	verified, err := s.storer.HasPaidOrderItem(ctx, r.UserID, r.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	r.VerifiedPurchase = verified
+	r.Status = s.screenReview(r)
+	r.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateReview(ctx, r)
+}
+
+// DeleteReview removes a review; authors may delete their own and admins
+// any.
+func (s *Server) DeleteReview(ctx context.Context, actor *auth.Claims, productID, id int64) error {
+	r, err := s.GetReview(ctx, productID, id)
+	if err != nil {
+		return err
+	}
+	if r.UserID != actor.UserID && !actor.IsAdmin {
+		return fmt.Errorf("%w: only the author or an admin can delete a review", ErrForbidden)
+	}
+	return s.storer.DeleteReview(ctx, r)
+}
+
+func validateReview(r *storer.Review) error {
+	r.Title = strings.TrimSpace(r.Title)
+	if r.Stars < 1 || r.Stars > 5 {
+		return fmt.Errorf("%w: stars must be between 1 and 5", ErrInvalid)
+	}
+	if r.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalid)
+	}
+	return nil
+}
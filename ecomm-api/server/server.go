@@ -2,22 +2,71 @@ package server
 
 import (
 	"context"
+	"time"
 
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/payments"
 	"github.com/m21power/ecomm/ecomm-api/storer"
 )
 
 type Server struct {
-	storer *storer.MySQLStorer
+	storer storer.Storer
+
+	reviewBlocklist       blocklist
+	reviewReportThreshold int
+
+	guestCartTTL  time.Duration
+	cartMergeRule CartMergeRule
+
+	taxCalculator TaxCalculator
+	baseCurrency  string
+
+	paymentProviders       map[string]payments.Provider
+	defaultPaymentProvider string
+
+	idempotencyKeyTTL time.Duration
+	reservationTTL    time.Duration
 }
 
-func NewServer(storer *storer.MySQLStorer) *Server {
-	return &Server{storer: storer}
+// Option configures optional Server behaviour.
+type Option func(*Server)
+
+func NewServer(storer storer.Storer, opts ...Option) *Server {
+	s := &Server{
+		storer:                storer,
+		reviewReportThreshold: defaultReviewReportThreshold,
+		guestCartTTL:          defaultGuestCartTTL,
+		cartMergeRule:         CartMergeSum,
+		taxCalculator:         NewTableTaxCalculator(storer, false),
+		baseCurrency:          defaultBaseCurrency,
+		idempotencyKeyTTL:     defaultIdempotencyKeyTTL,
+		reservationTTL:        defaultReservationTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
-func (s *Server) CreateProduct(ctx context.Context, product *storer.Product) (*storer.Product, error) {
+
+// CreateProduct creates the product and puts its initial stock into the
+// first active warehouse.
+func (s *Server) CreateProduct(ctx context.Context, actor *auth.Claims, product *storer.Product) (*storer.Product, error) {
+	if err := checkInventoryPolicy(product); err != nil {
+		return nil, err
+	}
+	if err := s.resolveCategory(ctx, product); err != nil {
+		return nil, err
+	}
+	stock := product.CountInStock
+	product.CountInStock = 0
 	pr, err := s.storer.CreateProduct(ctx, product)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.openingStock(ctx, actor, pr.ID, nil, stock); err != nil {
+		return nil, err
+	}
+	pr.CountInStock = stock
 	return pr, nil
 }
 
@@ -30,14 +79,23 @@ func (s *Server) GetProduct(ctx context.Context, id int64) (*storer.Product, err
 
 }
 
-func (s *Server) ListProducts(ctx context.Context) ([]storer.Product, error) {
-	pr, err := s.storer.ListProducts(ctx)
+func (s *Server) ListProducts(ctx context.Context, f storer.ProductFilter) ([]storer.Product, error) {
+	pr, err := s.storer.ListProducts(ctx, f)
 	if err != nil {
 		return nil, err
 	}
 	return pr, nil
 }
+
+// UpdateProduct updates the product's catalog details. Its stock is left
+// alone; that changes through stock adjustments.
 func (s *Server) UpdateProduct(ctx context.Context, product *storer.Product) (*storer.Product, error) {
+	if err := checkInventoryPolicy(product); err != nil {
+		return nil, err
+	}
+	if err := s.resolveCategory(ctx, product); err != nil {
+		return nil, err
+	}
 	return s.storer.UpdateProduct(ctx, product)
 }
 func (s *Server) DeleteProduct(ctx context.Context, id int64) error {
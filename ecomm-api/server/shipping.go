@@ -0,0 +1,292 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// ShippingQuote is what shipping an order with a method costs.
+type ShippingQuote struct {
+	Method storer.ShippingMethod
+	Price  money.Amount
+}
+
+// QuoteShipping prices the shipping methods available for sending the
+// owner's cart to loc. Items that are out of stock are left out, as they are
+// from the cart's subtotal.
+func (s *Server) QuoteShipping(ctx context.Context, owner CartOwner, loc Location) ([]ShippingQuote, error) {
+	c, err := s.findCart(ctx, owner)
+	if err != nil && !errors.Is(err, storer.ErrNotFound) {
+		return nil, err
+	}
+	book, err := s.priceBook(ctx, owner.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var items []storer.OrderItem
+	if c != nil {
+		for _, ci := range c.Items {
+			oi := cartOrderItem(ci)
+			available, err := s.priceOrderItem(ctx, oi)
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				return nil, err
+			}
+			if err != nil {
+				continue
+			}
+			if available <= 0 {
+				ok, err := s.backorderable(ctx, oi.ProductID, time.Now())
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			if err := s.customerPrice(ctx, book, oi); err != nil {
+				return nil, err
+			}
+			items = append(items, *oi)
+		}
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: the cart is empty", ErrInvalid)
+	}
+	return s.quoteShipping(ctx, loc, items)
+}
+
+// quoteShipping prices the active methods of the zone covering loc for the
+// already priced items. It returns no quotes when no zones are set up at
+// all, and fails when zones are set up but none covers loc.
+func (s *Server) quoteShipping(ctx context.Context, loc Location, items []storer.OrderItem) ([]ShippingQuote, error) {
+	zones, err := s.storer.ListShippingZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(zones) == 0 {
+		return nil, nil
+	}
+	zone := coveringZone(zones, loc)
+	if zone == nil {
+		return nil, fmt.Errorf("%w: we do not ship to this address", ErrInvalid)
+	}
+	methods, err := s.storer.ListShippingMethods(ctx, zone.ID)
+	if err != nil {
+		return nil, err
+	}
+	var weight float64
+	var value money.Amount
+	for _, oi := range items {
+		p, err := s.storer.GetProduct(ctx, oi.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		weight += p.Weight * float64(oi.Quantity)
+		value += oi.Price.Mul(oi.Quantity)
+	}
+	var quotes []ShippingQuote
+	for _, m := range methods {
+		if m.Active {
+			quotes = append(quotes, ShippingQuote{Method: m, Price: shippingPrice(&m, weight, value)})
+		}
+	}
+	return quotes, nil
+}
+
+// shipOrder charges the shipping method the customer chose. A method must
+// be chosen whenever one is available for the order's address.
+func (s *Server) shipOrder(ctx context.Context, o *storer.Order, opts OrderOptions) error {
+	quotes, err := s.quoteShipping(ctx, shipTo(o), o.Items)
+	if err != nil {
+		return err
+	}
+	o.ShippingMethodID = nil
+	o.ShippingMethod = ""
+	o.ShippingPrice = 0
+	if opts.ShippingMethodID == nil {
+		if len(quotes) > 0 {
+			return fmt.Errorf("%w: choose a shipping method", ErrInvalid)
+		}
+		return nil
+	}
+	for _, q := range quotes {
+		if q.Method.ID == *opts.ShippingMethodID {
+			o.ShippingMethodID = &q.Method.ID
+			o.ShippingMethod = q.Method.Name
+			o.ShippingPrice = q.Price
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: shipping method %d is not available for this address", ErrInvalid, *opts.ShippingMethodID)
+}
+
+// coveringZone returns the zone that covers loc most specifically, the
+// first one on a tie.
+func coveringZone(zones []storer.ShippingZone, loc Location) *storer.ShippingZone {
+	var (
+		best     *storer.ShippingZone
+		bestRank int
+	)
+	for i := range zones {
+		for _, zl := range zones[i].Locations {
+			if !loc.within(zl.Country, zl.Region, zl.PostalPrefix) {
+				continue
+			}
+			if rank := specificity(zl.Region, zl.PostalPrefix); best == nil || rank > bestRank {
+				best, bestRank = &zones[i], rank
+			}
+		}
+	}
+	return best
+}
+
+func shippingPrice(m *storer.ShippingMethod, weight float64, value money.Amount) money.Amount {
+	switch m.Kind {
+	case storer.ShippingFreeOver:
+		if value >= m.FreeOver {
+			return 0
+		}
+	case storer.ShippingWeight:
+		return tierPrice(m.Tiers, func(t storer.ShippingTier) bool { return weight >= t.FromWeight })
+	case storer.ShippingPriceTiered:
+		return tierPrice(m.Tiers, func(t storer.ShippingTier) bool { return value >= t.FromValue })
+	}
+	return m.Price
+}
+
+// tierPrice returns the price of the last tier the order reaches. Tiers are
+// kept sorted by where they start.
+func tierPrice(tiers storer.ShippingTiers, reaches func(storer.ShippingTier) bool) money.Amount {
+	var price money.Amount
+	for _, t := range tiers {
+		if !reaches(t) {
+			break
+		}
+		price = t.Price
+	}
+	return price
+}
+
+func (s *Server) CreateShippingZone(ctx context.Context, z *storer.ShippingZone) (*storer.ShippingZone, error) {
+	if err := validateShippingZone(z); err != nil {
+		return nil, err
+	}
+	z.CreatedAt = time.Now()
+	return s.storer.CreateShippingZone(ctx, z)
+}
+
+func (s *Server) GetShippingZone(ctx context.Context, id int64) (*storer.ShippingZone, error) {
+	return s.storer.GetShippingZone(ctx, id)
+}
+
+func (s *Server) ListShippingZones(ctx context.Context) ([]storer.ShippingZone, error) {
+	return s.storer.ListShippingZones(ctx)
+}
+
+func (s *Server) UpdateShippingZone(ctx context.Context, z *storer.ShippingZone) (*storer.ShippingZone, error) {
+	if err := validateShippingZone(z); err != nil {
+		return nil, err
+	}
+	z.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateShippingZone(ctx, z)
+}
+
+func (s *Server) DeleteShippingZone(ctx context.Context, id int64) error {
+	return s.storer.DeleteShippingZone(ctx, id)
+}
+
+func (s *Server) CreateShippingMethod(ctx context.Context, m *storer.ShippingMethod) (*storer.ShippingMethod, error) {
+	if err := s.validateShippingMethod(ctx, m); err != nil {
+		return nil, err
+	}
+	m.CreatedAt = time.Now()
+	return s.storer.CreateShippingMethod(ctx, m)
+}
+
+func (s *Server) GetShippingMethod(ctx context.Context, id int64) (*storer.ShippingMethod, error) {
+	return s.storer.GetShippingMethod(ctx, id)
+}
+
+// ListShippingMethods lists the methods of a zone, or every method when
+// zoneID is 0.
+func (s *Server) ListShippingMethods(ctx context.Context, zoneID int64) ([]storer.ShippingMethod, error) {
+	return s.storer.ListShippingMethods(ctx, zoneID)
+}
+
+func (s *Server) UpdateShippingMethod(ctx context.Context, m *storer.ShippingMethod) (*storer.ShippingMethod, error) {
+	if err := s.validateShippingMethod(ctx, m); err != nil {
+		return nil, err
+	}
+	m.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateShippingMethod(ctx, m)
+}
+
+func (s *Server) DeleteShippingMethod(ctx context.Context, id int64) error {
+	return s.storer.DeleteShippingMethod(ctx, id)
+}
+
+func validateShippingZone(z *storer.ShippingZone) error {
+	z.Name = strings.TrimSpace(z.Name)
+	if z.Name == "" {
+		return fmt.Errorf("%w: shipping zone name is required", ErrInvalid)
+	}
+	if len(z.Locations) == 0 {
+		return fmt.Errorf("%w: a shipping zone needs at least one location", ErrInvalid)
+	}
+	for i := range z.Locations {
+		zl := &z.Locations[i]
+		zl.Country = strings.ToUpper(strings.TrimSpace(zl.Country))
+		if len(zl.Country) != 2 {
+			return fmt.Errorf("%w: country must be a two-letter code", ErrInvalid)
+		}
+		zl.Region = strings.TrimSpace(zl.Region)
+		zl.PostalPrefix = normalizePostalCode(zl.PostalPrefix)
+	}
+	return nil
+}
+
+func (s *Server) validateShippingMethod(ctx context.Context, m *storer.ShippingMethod) error {
+	m.Name = strings.TrimSpace(m.Name)
+	if m.Name == "" {
+		return fmt.Errorf("%w: shipping method name is required", ErrInvalid)
+	}
+	if _, err := s.storer.GetShippingZone(ctx, m.ZoneID); err != nil {
+		return notFoundAsInvalid(err, "shipping zone %d does not exist", m.ZoneID)
+	}
+	if m.Price < 0 || m.FreeOver < 0 {
+		return fmt.Errorf("%w: prices cannot be negative", ErrInvalid)
+	}
+	for _, t := range m.Tiers {
+		if t.FromWeight < 0 || t.FromValue < 0 || t.Price < 0 {
+			return fmt.Errorf("%w: tiers cannot be negative", ErrInvalid)
+		}
+		if m.Kind == storer.ShippingWeight && t.FromValue != 0 || m.Kind == storer.ShippingPriceTiered && t.FromWeight != 0 {
+			return fmt.Errorf("%w: weight tiers start at from_weight, price tiers at from_value", ErrInvalid)
+		}
+	}
+	slices.SortFunc(m.Tiers, func(a, b storer.ShippingTier) int {
+		return cmp.Or(cmp.Compare(a.FromWeight, b.FromWeight), cmp.Compare(a.FromValue, b.FromValue))
+	})
+	switch m.Kind {
+	case storer.ShippingFlat:
+	case storer.ShippingFreeOver:
+		if m.FreeOver <= 0 {
+			return fmt.Errorf("%w: free-over methods need a positive threshold", ErrInvalid)
+		}
+	case storer.ShippingWeight, storer.ShippingPriceTiered:
+		if len(m.Tiers) == 0 {
+			return fmt.Errorf("%w: tiered methods need at least one tier", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown shipping method kind %q", ErrInvalid, m.Kind)
+	}
+	return nil
+}
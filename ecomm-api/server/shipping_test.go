@@ -0,0 +1,65 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShippingPrice(t *testing.T) {
+	flat := &storer.ShippingMethod{Kind: storer.ShippingFlat, Price: 500}
+	freeOver := &storer.ShippingMethod{Kind: storer.ShippingFreeOver, Price: 500, FreeOver: 5000}
+	weight := &storer.ShippingMethod{Kind: storer.ShippingWeight, Tiers: storer.ShippingTiers{
+		{FromWeight: 0, Price: 400},
+		{FromWeight: 2, Price: 700},
+		{FromWeight: 10, Price: 1500},
+	}}
+	priceTiered := &storer.ShippingMethod{Kind: storer.ShippingPriceTiered, Tiers: storer.ShippingTiers{
+		{FromValue: 0, Price: 900},
+		{FromValue: 3000, Price: 500},
+		{FromValue: 10000, Price: 0},
+	}}
+	tcs := []struct {
+		name   string
+		method *storer.ShippingMethod
+		weight float64
+		value  money.Amount
+		want   money.Amount
+	}{
+		{name: "flat", method: flat, weight: 30, value: 100000, want: 500},
+		{name: "free over, below the threshold", method: freeOver, value: 4999, want: 500},
+		{name: "free over, at the threshold", method: freeOver, value: 5000, want: 0},
+		{name: "weight, first tier", method: weight, weight: 0.5, want: 400},
+		{name: "weight, a tier starts at its bound", method: weight, weight: 2, want: 700},
+		{name: "weight, past the last tier", method: weight, weight: 25, want: 1500},
+		{name: "price tiered, middle tier", method: priceTiered, value: 4500, want: 500},
+		{name: "price tiered, weight is ignored", method: priceTiered, weight: 50, value: 100, want: 900},
+		{name: "price tiered, top tier", method: priceTiered, value: 10000, want: 0},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, shippingPrice(tc.method, tc.weight, tc.value))
+		})
+	}
+}
+
+func TestTierPrice(t *testing.T) {
+	tiers := storer.ShippingTiers{{FromWeight: 1, Price: 400}, {FromWeight: 5, Price: 800}}
+	tcs := []struct {
+		name   string
+		weight float64
+		want   money.Amount
+	}{
+		{name: "no tier reached", weight: 0.5, want: 0},
+		{name: "first tier", weight: 1, want: 400},
+		{name: "last tier", weight: 6, want: 800},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tierPrice(tiers, func(st storer.ShippingTier) bool { return tc.weight >= st.FromWeight })
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
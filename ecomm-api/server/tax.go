@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// TaxItem is an order item as a TaxCalculator sees it. Amount is the price
+// of all of its units.
+type TaxItem struct {
+	TaxClass string
+	Amount   money.Amount
+}
+
+// TaxCalculator works out the taxes on items delivered to loc. It returns
+// the tax lines of each item, in the order of items.
+type TaxCalculator interface {
+	Calculate(ctx context.Context, loc Location, items []TaxItem) ([][]storer.OrderItemTax, error)
+}
+
+// TaxRateLister lists the tax rates of a country.
+type TaxRateLister interface {
+	ListTaxRates(ctx context.Context, country string) ([]storer.TaxRate, error)
+}
+
+// TableTaxCalculator charges the rates of the tax table. For each priority
+// the most specific matching rate applies, a postal code prefix being more
+// specific than a region; the rates of different priorities add up. When
+// prices include tax, the tax is taken out of the item's amount instead of
+// being added to it.
+type TableTaxCalculator struct {
+	rates            TaxRateLister
+	pricesIncludeTax bool
+}
+
+func NewTableTaxCalculator(rates TaxRateLister, pricesIncludeTax bool) *TableTaxCalculator {
+	return &TableTaxCalculator{rates: rates, pricesIncludeTax: pricesIncludeTax}
+}
+
+// WithTaxCalculator replaces the default calculator, which charges the rates
+// of the tax table on prices that exclude tax.
+func WithTaxCalculator(c TaxCalculator) Option {
+	return func(s *Server) {
+		s.taxCalculator = c
+	}
+}
+
+// Calculate charges no tax when loc has no country, since no rate can be
+// told to apply.
+func (c *TableTaxCalculator) Calculate(ctx context.Context, loc Location, items []TaxItem) ([][]storer.OrderItemTax, error) {
+	lines := make([][]storer.OrderItemTax, len(items))
+	if loc.Country == "" {
+		return lines, nil
+	}
+	rates, err := c.rates.ListTaxRates(ctx, strings.ToUpper(loc.Country))
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range items {
+		applied := matchTaxRates(rates, loc, item.TaxClass)
+		var total float64
+		for _, tr := range applied {
+			total += tr.Rate
+		}
+		for _, tr := range applied {
+			rate := tr.Rate
+			if c.pricesIncludeTax {
+				rate /= 1 + total
+			}
+			lines[i] = append(lines[i], storer.OrderItemTax{
+				TaxRateID: &tr.ID,
+				Name:      tr.Name,
+				Rate:      tr.Rate,
+				Amount:    item.Amount.MulRate(rate),
+				Included:  c.pricesIncludeTax,
+			})
+		}
+	}
+	return lines, nil
+}
+
+// matchTaxRates picks, for each priority, the most specific rate of class
+// that covers loc.
+func matchTaxRates(rates []storer.TaxRate, loc Location, class string) []storer.TaxRate {
+	if class == "" {
+		class = storer.TaxClassStandard
+	}
+	best := map[int]storer.TaxRate{}
+	var priorities []int
+	for _, tr := range rates {
+		if tr.TaxClass != class || !loc.within(tr.Country, tr.Region, tr.PostalPrefix) {
+			continue
+		}
+		cur, ok := best[tr.Priority]
+		if !ok {
+			priorities = append(priorities, tr.Priority)
+		}
+		if !ok || specificity(tr.Region, tr.PostalPrefix) > specificity(cur.Region, cur.PostalPrefix) {
+			best[tr.Priority] = tr
+		}
+	}
+	applied := make([]storer.TaxRate, 0, len(priorities))
+	for _, p := range priorities {
+		applied = append(applied, best[p])
+	}
+	return applied
+}
+
+func (s *Server) CreateTaxRate(ctx context.Context, tr *storer.TaxRate) (*storer.TaxRate, error) {
+	if err := validateTaxRate(tr); err != nil {
+		return nil, err
+	}
+	tr.CreatedAt = time.Now()
+	return s.storer.CreateTaxRate(ctx, tr)
+}
+
+func (s *Server) GetTaxRate(ctx context.Context, id int64) (*storer.TaxRate, error) {
+	return s.storer.GetTaxRate(ctx, id)
+}
+
+// ListTaxRates lists the rates of a country, or every rate when country is
+// empty.
+func (s *Server) ListTaxRates(ctx context.Context, country string) ([]storer.TaxRate, error) {
+	return s.storer.ListTaxRates(ctx, strings.ToUpper(country))
+}
+
+func (s *Server) UpdateTaxRate(ctx context.Context, tr *storer.TaxRate) (*storer.TaxRate, error) {
+	if err := validateTaxRate(tr); err != nil {
+		return nil, err
+	}
+	tr.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateTaxRate(ctx, tr)
+}
+
+func (s *Server) DeleteTaxRate(ctx context.Context, id int64) error {
+	return s.storer.DeleteTaxRate(ctx, id)
+}
+
+func validateTaxRate(tr *storer.TaxRate) error {
+	tr.Name = strings.TrimSpace(tr.Name)
+	if tr.Name == "" {
+		return fmt.Errorf("%w: tax rate name is required", ErrInvalid)
+	}
+	tr.Country = strings.ToUpper(strings.TrimSpace(tr.Country))
+	if len(tr.Country) != 2 {
+		return fmt.Errorf("%w: country must be a two-letter code", ErrInvalid)
+	}
+	tr.Region = strings.TrimSpace(tr.Region)
+	tr.PostalPrefix = normalizePostalCode(tr.PostalPrefix)
+	tr.TaxClass = strings.TrimSpace(tr.TaxClass)
+	if tr.TaxClass == "" {
+		tr.TaxClass = storer.TaxClassStandard
+	}
+	if tr.Rate < 0 || tr.Rate > 1 {
+		return fmt.Errorf("%w: rate must be a fraction between 0 and 1", ErrInvalid)
+	}
+	return nil
+}
+
+// taxOrder charges tax on the order's items and returns the part of it that
+// is not already contained in their prices. Tax is charged on what the
+// customer pays for the items, their price-list prices less their share of
+// discount, in the tax class of their category.
+func (s *Server) taxOrder(ctx context.Context, o *storer.Order, loc Location, discount money.Amount) (money.Amount, error) {
+	classes := map[int64]string{}
+	items := make([]TaxItem, len(o.Items))
+	shares := lineDiscounts(o.Items, discount)
+	for i, oi := range o.Items {
+		p, err := s.storer.GetProduct(ctx, oi.ProductID)
+		if err != nil {
+			return 0, err
+		}
+		class := storer.TaxClassStandard
+		if p.CategoryID != nil {
+			if _, ok := classes[*p.CategoryID]; !ok {
+				c, err := s.storer.GetCategory(ctx, *p.CategoryID)
+				if err != nil {
+					return 0, err
+				}
+				classes[*p.CategoryID] = c.TaxClass
+			}
+			class = classes[*p.CategoryID]
+		}
+		items[i] = TaxItem{TaxClass: class, Amount: oi.Price.Mul(oi.Quantity) - shares[i]}
+	}
+	lines, err := s.taxCalculator.Calculate(ctx, loc, items)
+	if err != nil {
+		return 0, fmt.Errorf("error calculating tax: %w", err)
+	}
+	var tax, added money.Amount
+	for i := range o.Items {
+		o.Items[i].Taxes = lines[i]
+		for _, t := range lines[i] {
+			tax += t.Amount
+			if !t.Included {
+				added += t.Amount
+			}
+		}
+	}
+	o.TaxPrice = tax
+	return added, nil
+}
+
+// lineDiscounts spreads discount over the items in proportion to what their
+// lines cost, the last line taking what rounding leaves over. No line is
+// discounted below zero.
+func lineDiscounts(items []storer.OrderItem, discount money.Amount) []money.Amount {
+	var subtotal money.Amount
+	for _, oi := range items {
+		subtotal += oi.Price.Mul(oi.Quantity)
+	}
+	shares := make([]money.Amount, len(items))
+	left := min(discount, subtotal)
+	for i, oi := range items {
+		line := oi.Price.Mul(oi.Quantity)
+		shares[i] = min(discount.Share(line, subtotal), left, line)
+		if i == len(items)-1 {
+			shares[i] = min(left, line)
+		}
+		left -= shares[i]
+	}
+	return shares
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineDiscounts(t *testing.T) {
+	items := []storer.OrderItem{
+		{ProductID: 1, Price: 2000, Quantity: 2},
+		{ProductID: 2, Price: 1000, Quantity: 1},
+	}
+	tcs := []struct {
+		name     string
+		discount func() money.Amount
+		want     []money.Amount
+	}{
+		{
+			name: "percentage promotion",
+			discount: func() money.Amount {
+				o := &storer.Order{Items: items}
+				pc := &promotionContext{order: o, subtotal: 5000, categories: map[int64]*int64{}}
+				return pc.discount(&storer.Promotion{Kind: storer.PromotionPercentage, Percent: 10})
+			},
+			// 10% off each line
+			want: []money.Amount{400, 100},
+		},
+		{
+			name:     "rounding goes to the last line",
+			discount: func() money.Amount { return 1001 },
+			want:     []money.Amount{801, 200},
+		},
+		{
+			name:     "never below zero",
+			discount: func() money.Amount { return 9000 },
+			want:     []money.Amount{4000, 1000},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			shares := lineDiscounts(items, tc.discount())
+			require.Equal(t, tc.want, shares)
+		})
+	}
+}
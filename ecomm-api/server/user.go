@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+const minPasswordLength = 8
+
+func (s *Server) RegisterUser(ctx context.Context, u *storer.User) (*storer.User, error) {
+	u.Name = strings.TrimSpace(u.Name)
+	u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+	if u.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	if _, err := mail.ParseAddress(u.Email); err != nil {
+		return nil, fmt.Errorf("%w: email is not valid", ErrInvalid)
+	}
+	if len(u.Password) < minPasswordLength {
+		return nil, fmt.Errorf("%w: password must be at least %d characters", ErrInvalid, minPasswordLength)
+	}
+	hash, err := auth.HashPassword(u.Password)
+	if err != nil {
+		return nil, err
+	}
+	u.Password = hash
+	// admins are promoted in the database, never through the API
+	u.IsAdmin = false
+	return s.storer.CreateUser(ctx, u)
+}
+
+// Authenticate returns the user with the given email if password matches.
+func (s *Server) Authenticate(ctx context.Context, email, password string) (*storer.User, error) {
+	u, err := s.storer.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, storer.ErrNotFound) {
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !auth.CheckPassword(u.Password, password) {
+		return nil, ErrUnauthorized
+	}
+	return u, nil
+}
+
+func (s *Server) GetUser(ctx context.Context, id int64) (*storer.User, error) {
+	return s.storer.GetUser(ctx, id)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (s *Server) CreateVariant(ctx context.Context, actor *auth.Claims, v *storer.Variant) (*storer.Variant, error) {
+	if _, err := s.storer.GetProduct(ctx, v.ProductID); err != nil {
+		return nil, err
+	}
+	if err := s.validateVariant(ctx, v); err != nil {
+		return nil, err
+	}
+	v.CreatedAt = time.Now()
+	stock := v.CountInStock
+	v.CountInStock = 0
+	created, err := s.storer.CreateVariant(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.openingStock(ctx, actor, v.ProductID, &created.ID, stock); err != nil {
+		return nil, err
+	}
+	created.CountInStock = stock
+	return created, nil
+}
+
+// GetVariant returns the variant only if it belongs to productID.
+func (s *Server) GetVariant(ctx context.Context, productID, id int64) (*storer.Variant, error) {
+	v, err := s.storer.GetVariant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if v.ProductID != productID {
+		return nil, fmt.Errorf("error getting variant: %w", storer.ErrNotFound)
+	}
+	return v, nil
+}
+
+func (s *Server) ListVariants(ctx context.Context, productID int64) ([]storer.Variant, error) {
+	return s.storer.ListVariants(ctx, productID)
+}
+
+// UpdateVariant updates the variant's SKU, options and price. Its stock is
+// left alone; that changes through stock adjustments.
+func (s *Server) UpdateVariant(ctx context.Context, v *storer.Variant) (*storer.Variant, error) {
+	if err := s.validateVariant(ctx, v); err != nil {
+		return nil, err
+	}
+	v.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateVariant(ctx, v)
+}
+
+func (s *Server) DeleteVariant(ctx context.Context, productID, id int64) error {
+	if _, err := s.GetVariant(ctx, productID, id); err != nil {
+		return err
+	}
+	return s.storer.DeleteVariant(ctx, id)
+}
+
+// validateVariant checks the variant's fields and that no other variant of
+// the same product has the same option values.
+func (s *Server) validateVariant(ctx context.Context, v *storer.Variant) error {
+	v.SKU = strings.TrimSpace(v.SKU)
+	if v.SKU == "" {
+		return fmt.Errorf("%w: sku is required", ErrInvalid)
+	}
+	if len(v.Options) == 0 {
+		return fmt.Errorf("%w: at least one option is required", ErrInvalid)
+	}
+	if v.Price != nil && *v.Price < 0 {
+		return fmt.Errorf("%w: price cannot be negative", ErrInvalid)
+	}
+	if v.CountInStock < 0 {
+		return fmt.Errorf("%w: count_in_stock cannot be negative", ErrInvalid)
+	}
+	siblings, err := s.storer.ListVariants(ctx, v.ProductID)
+	if err != nil {
+		return err
+	}
+	for _, sib := range siblings {
+		if sib.ID != v.ID && maps.Equal(sib.Options, v.Options) {
+			return fmt.Errorf("%w: variant %s already has these options", ErrInvalid, sib.SKU)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+func (s *Server) CreateWarehouse(ctx context.Context, w *storer.Warehouse) (*storer.Warehouse, error) {
+	if err := validateWarehouse(w); err != nil {
+		return nil, err
+	}
+	w.CreatedAt = time.Now()
+	return s.storer.CreateWarehouse(ctx, w)
+}
+
+func (s *Server) GetWarehouse(ctx context.Context, id int64) (*storer.Warehouse, error) {
+	return s.storer.GetWarehouse(ctx, id)
+}
+
+func (s *Server) ListWarehouses(ctx context.Context) ([]storer.Warehouse, error) {
+	return s.storer.ListWarehouses(ctx)
+}
+
+func (s *Server) UpdateWarehouse(ctx context.Context, w *storer.Warehouse) (*storer.Warehouse, error) {
+	if err := validateWarehouse(w); err != nil {
+		return nil, err
+	}
+	w.UpdatedAt = toTimePtr(time.Now())
+	return s.storer.UpdateWarehouse(ctx, w)
+}
+
+// DeleteWarehouse deletes an unused warehouse. Warehouses that hold stock or
+// fulfilled orders can only be deactivated.
+func (s *Server) DeleteWarehouse(ctx context.Context, id int64) error {
+	return s.storer.DeleteWarehouse(ctx, id)
+}
+
+func validateWarehouse(w *storer.Warehouse) error {
+	w.Code = strings.ToUpper(strings.TrimSpace(w.Code))
+	w.Name = strings.TrimSpace(w.Name)
+	w.Country = strings.ToUpper(strings.TrimSpace(w.Country))
+	w.Region = strings.TrimSpace(w.Region)
+	switch {
+	case w.Code == "":
+		return fmt.Errorf("%w: warehouse code is required", ErrInvalid)
+	case w.Name == "":
+		return fmt.Errorf("%w: warehouse name is required", ErrInvalid)
+	case len(w.Country) != 2:
+		return fmt.Errorf("%w: country must be a two-letter code", ErrInvalid)
+	}
+	return nil
+}
+
+// ListStockLevels lists what each warehouse holds of the product and its
+// variants.
+func (s *Server) ListStockLevels(ctx context.Context, productID int64) ([]storer.StockLevel, error) {
+	if _, err := s.storer.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.storer.ListStockLevels(ctx, productID)
+}
+
+// ListInventoryHistory lists the product's inventory movements, newest
+// first.
+func (s *Server) ListInventoryHistory(ctx context.Context, productID int64, f storer.MovementFilter) ([]storer.InventoryMovement, error) {
+	if _, err := s.storer.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	f.ProductID = productID
+	return s.storer.ListInventoryMovements(ctx, f)
+}
+
+// adjustmentReasons are the reasons stock may be adjusted by hand for.
+var adjustmentReasons = []string{
+	storer.AdjustmentReceived,
+	storer.AdjustmentDamaged,
+	storer.AdjustmentLost,
+	storer.AdjustmentFound,
+	storer.AdjustmentCorrection,
+}
+
+// AdjustStock changes a warehouse's stock of a product or variant by
+// m.Quantity, recording the reason and who made the change in the ledger.
+func (s *Server) AdjustStock(ctx context.Context, actor *auth.Claims, m *storer.InventoryMovement) (*storer.InventoryMovement, error) {
+	if !slices.Contains(adjustmentReasons, m.Reason) {
+		return nil, fmt.Errorf("%w: reason must be one of %s", ErrInvalid, strings.Join(adjustmentReasons, ", "))
+	}
+	if m.Quantity == 0 {
+		return nil, fmt.Errorf("%w: quantity must not be zero", ErrInvalid)
+	}
+	if err := s.validateStockItem(ctx, m.WarehouseID, m.ProductID, m.VariantID); err != nil {
+		return nil, err
+	}
+	m.Kind = storer.MovementAdjustment
+	m.Note = strings.TrimSpace(m.Note)
+	m.OrderID = nil
+	m.UserID = &actor.UserID
+	m.CreatedAt = time.Now()
+	movements, err := s.storer.MoveStock(ctx, []storer.InventoryMovement{*m})
+	if err != nil {
+		return nil, err
+	}
+	return &movements[0], nil
+}
+
+// StockTransfer moves Quantity units of a product, or of one of its
+// variants, from one warehouse to another.
+type StockTransfer struct {
+	FromWarehouseID int64
+	ToWarehouseID   int64
+	ProductID       int64
+	VariantID       *int64
+	Quantity        int64
+	Note            string
+}
+
+// TransferStock moves stock between warehouses, recording both sides as
+// transfer movements. The product's total is unchanged.
+func (s *Server) TransferStock(ctx context.Context, actor *auth.Claims, t StockTransfer) ([]storer.InventoryMovement, error) {
+	if t.Quantity <= 0 {
+		return nil, fmt.Errorf("%w: quantity must be positive", ErrInvalid)
+	}
+	if t.FromWarehouseID == t.ToWarehouseID {
+		return nil, fmt.Errorf("%w: stock must move to another warehouse", ErrInvalid)
+	}
+	for _, id := range []int64{t.FromWarehouseID, t.ToWarehouseID} {
+		if err := s.validateStockItem(ctx, id, t.ProductID, t.VariantID); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	out := storer.InventoryMovement{
+		WarehouseID: t.FromWarehouseID,
+		ProductID:   t.ProductID,
+		VariantID:   t.VariantID,
+		Quantity:    -t.Quantity,
+		Kind:        storer.MovementTransfer,
+		Note:        strings.TrimSpace(t.Note),
+		UserID:      &actor.UserID,
+		CreatedAt:   now,
+	}
+	in := out
+	in.WarehouseID = t.ToWarehouseID
+	in.Quantity = t.Quantity
+	return s.storer.MoveStock(ctx, []storer.InventoryMovement{out, in})
+}
+
+// validateStockItem checks that the warehouse exists and that the variant,
+// if any, belongs to the product. Products sold in variants are stocked by
+// variant.
+func (s *Server) validateStockItem(ctx context.Context, warehouseID, productID int64, variantID *int64) error {
+	if _, err := s.storer.GetWarehouse(ctx, warehouseID); err != nil {
+		return notFoundAsInvalid(err, "warehouse %d does not exist", warehouseID)
+	}
+	variants, err := s.storer.ListVariants(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if variantID == nil {
+		if len(variants) > 0 {
+			return fmt.Errorf("%w: product %d is stocked by variant, choose one", ErrInvalid, productID)
+		}
+		return nil
+	}
+	if !slices.ContainsFunc(variants, func(v storer.Variant) bool { return v.ID == *variantID }) {
+		return fmt.Errorf("%w: variant %d is not a variant of product %d", ErrInvalid, *variantID, productID)
+	}
+	return nil
+}
+
+// openingStock receives a new product's or variant's opening stock into
+// the first active warehouse on actor's behalf. Only admins may change
+// stock.
+func (s *Server) openingStock(ctx context.Context, actor *auth.Claims, productID int64, variantID *int64, quantity int64) error {
+	if quantity == 0 {
+		return nil
+	}
+	if actor == nil || !actor.IsAdmin {
+		return fmt.Errorf("%w: only admins can change stock", ErrForbidden)
+	}
+	warehouses, err := s.storer.ListWarehouses(ctx)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(warehouses, func(w storer.Warehouse) bool { return w.Active })
+	if i < 0 {
+		return fmt.Errorf("%w: there is no active warehouse to hold stock", ErrInvalid)
+	}
+	m := storer.InventoryMovement{
+		WarehouseID: warehouses[i].ID,
+		ProductID:   productID,
+		VariantID:   variantID,
+		Quantity:    quantity,
+		Kind:        storer.MovementAdjustment,
+		Reason:      storer.AdjustmentReceived,
+		UserID:      &actor.UserID,
+		CreatedAt:   time.Now(),
+	}
+	_, err = s.storer.MoveStock(ctx, []storer.InventoryMovement{m})
+	return err
+}
+
+// allocateOrder picks the warehouses that fulfil each item and reserves
+// the units there for the reservation TTL. Warehouses near where the order
+// ships to go first, then by priority. An item ships from the first
+// warehouse with all of it available or, when none has, is split across
+// warehouses in that order. Units reserved for other orders are not
+// available.
+func (s *Server) allocateOrder(ctx context.Context, o *storer.Order) error {
+	warehouses, err := s.storer.ListWarehouses(ctx)
+	if err != nil {
+		return err
+	}
+	ranked := rankWarehouses(warehouses, shipTo(o))
+	expiresAt := toTimePtr(o.CreatedAt.Add(s.reservationTTL))
+	type stockKey struct{ warehouseID, productID, variantID int64 }
+	held := map[stockKey]int64{}
+	loaded := map[int64]bool{}
+	for i := range o.Items {
+		oi := &o.Items[i]
+		if !loaded[oi.ProductID] {
+			levels, err := s.storer.ListStockLevels(ctx, oi.ProductID)
+			if err != nil {
+				return err
+			}
+			for _, l := range levels {
+				held[stockKey{l.WarehouseID, l.ProductID, derefInt64(l.VariantID)}] = l.Available()
+			}
+			loaded[oi.ProductID] = true
+		}
+		at := func(w storer.Warehouse) stockKey {
+			return stockKey{w.ID, oi.ProductID, derefInt64(oi.VariantID)}
+		}
+		oi.Allocations = nil
+		oi.Backordered = 0
+		if j := slices.IndexFunc(ranked, func(w storer.Warehouse) bool { return held[at(w)] >= oi.Quantity }); j >= 0 {
+			held[at(ranked[j])] -= oi.Quantity
+			oi.Allocations = []storer.OrderItemAllocation{{WarehouseID: ranked[j].ID, Quantity: oi.Quantity, ExpiresAt: expiresAt}}
+			continue
+		}
+		remaining := oi.Quantity
+		for _, w := range ranked {
+			q := min(remaining, held[at(w)])
+			if q <= 0 {
+				continue
+			}
+			held[at(w)] -= q
+			remaining -= q
+			oi.Allocations = append(oi.Allocations, storer.OrderItemAllocation{WarehouseID: w.ID, Quantity: q, ExpiresAt: expiresAt})
+			if remaining == 0 {
+				break
+			}
+		}
+		if remaining > 0 {
+			// what no warehouse holds is backordered when the product's
+			// inventory policy allows it
+			ok, err := s.backorderable(ctx, oi.ProductID, o.CreatedAt)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("%w for %q", storer.ErrInsufficientStock, oi.Name)
+			}
+			oi.Backordered = remaining
+		}
+	}
+	return nil
+}
+
+// rankWarehouses returns the active warehouses, those in loc's region first,
+// then those in its country, each group in priority order.
+func rankWarehouses(warehouses []storer.Warehouse, loc Location) []storer.Warehouse {
+	var ranked []storer.Warehouse
+	for _, w := range warehouses {
+		if w.Active {
+			ranked = append(ranked, w)
+		}
+	}
+	distance := func(w storer.Warehouse) int {
+		switch {
+		case loc.within(w.Country, w.Region, "") && w.Region != "":
+			return 0
+		case loc.within(w.Country, "", ""):
+			return 1
+		}
+		return 2
+	}
+	slices.SortStableFunc(ranked, func(a, b storer.Warehouse) int {
+		return distance(a) - distance(b)
+	})
+	return ranked
+}
+
+func derefInt64(p *int64) int64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
@@ -0,0 +1,306 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/auth"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/m21power/ecomm/ecomm-api/storer"
+)
+
+// PricedWishlist is a wishlist with every item priced from the catalog at
+// read time.
+type PricedWishlist struct {
+	*storer.Wishlist
+	Lines []WishlistLine
+	// Token is set only right after the list is shared; it goes into the
+	// public link and cannot be recovered later.
+	Token string
+}
+
+// WishlistLine is a saved item with its current price and stock, and whether
+// either changed in the shopper's favour since it was saved.
+type WishlistLine struct {
+	storer.WishlistItem
+	Name         string
+	Image        string
+	Price        money.Amount
+	Available    int64
+	PriceDropped bool
+	BackInStock  bool
+}
+
+func (s *Server) CreateWishlist(ctx context.Context, userID int64, name string) (*PricedWishlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	wl, err := s.storer.CreateWishlist(ctx, &storer.Wishlist{UserID: userID, Name: name, CreatedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	return s.priceWishlist(ctx, wl)
+}
+
+func (s *Server) ListWishlists(ctx context.Context, userID int64) ([]PricedWishlist, error) {
+	wls, err := s.storer.ListWishlists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var res []PricedWishlist
+	for i := range wls {
+		pw, err := s.priceWishlist(ctx, &wls[i])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *pw)
+	}
+	return res, nil
+}
+
+func (s *Server) GetWishlist(ctx context.Context, userID, id int64) (*PricedWishlist, error) {
+	wl, err := s.ownWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.priceWishlist(ctx, wl)
+}
+
+// GetSharedWishlist returns the wishlist behind a public link.
+func (s *Server) GetSharedWishlist(ctx context.Context, token string) (*PricedWishlist, error) {
+	wl, err := s.storer.GetWishlistByShareToken(ctx, auth.HashOpaqueToken(token))
+	if err != nil {
+		return nil, err
+	}
+	return s.priceWishlist(ctx, wl)
+}
+
+func (s *Server) RenameWishlist(ctx context.Context, userID, id int64, name string) (*PricedWishlist, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalid)
+	}
+	wl, err := s.ownWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	wl.Name = name
+	return s.saveWishlist(ctx, wl)
+}
+
+func (s *Server) DeleteWishlist(ctx context.Context, userID, id int64) error {
+	if _, err := s.ownWishlist(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.storer.DeleteWishlist(ctx, id)
+}
+
+// ShareWishlist gives the wishlist a new public read-only link token.
+// Sharing again replaces the token, which revokes the previous link.
+func (s *Server) ShareWishlist(ctx context.Context, userID, id int64) (*PricedWishlist, error) {
+	wl, err := s.ownWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	token, hash, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	wl.ShareToken = &hash
+	pw, err := s.saveWishlist(ctx, wl)
+	if err != nil {
+		return nil, err
+	}
+	pw.Token = token
+	return pw, nil
+}
+
+// UnshareWishlist revokes the wishlist's public link.
+func (s *Server) UnshareWishlist(ctx context.Context, userID, id int64) (*PricedWishlist, error) {
+	wl, err := s.ownWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	wl.ShareToken = nil
+	return s.saveWishlist(ctx, wl)
+}
+
+// AddWishlistItem saves a product, or one of its variants, to the wishlist.
+// Saving something already on the list leaves it as it is.
+func (s *Server) AddWishlistItem(ctx context.Context, userID, id, productID int64, variantID *int64) (*PricedWishlist, error) {
+	wl, err := s.ownWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if findWishlistItem(wl, productID, variantID) == nil {
+		wi, err := s.newWishlistItem(ctx, wl.ID, productID, variantID)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.storer.CreateWishlistItem(ctx, wi); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetWishlist(ctx, userID, id)
+}
+
+func (s *Server) DeleteWishlistItem(ctx context.Context, userID, id, itemID int64) (*PricedWishlist, error) {
+	wl, err := s.ownWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	wi, err := getWishlistItem(wl, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storer.DeleteWishlistItem(ctx, wi.ID); err != nil {
+		return nil, err
+	}
+	return s.GetWishlist(ctx, userID, id)
+}
+
+// MoveWishlistItemToCart puts quantity units of a saved item in the user's
+// cart and takes it off the wishlist.
+func (s *Server) MoveWishlistItemToCart(ctx context.Context, userID, id, itemID, quantity int64) (*PricedCart, error) {
+	wl, err := s.ownWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	wi, err := getWishlistItem(wl, itemID)
+	if err != nil {
+		return nil, err
+	}
+	ci := &storer.CartItem{ProductID: wi.ProductID, VariantID: wi.VariantID, Quantity: quantity}
+	if _, err := s.priceOrderItem(ctx, cartOrderItem(*ci)); err != nil {
+		return nil, err
+	}
+	c, _, err := s.ownCart(ctx, CartOwner{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	if existing := findCartItem(c, ci.ProductID, ci.VariantID); existing != nil {
+		existing.Quantity += quantity
+		existing.UpdatedAt = toTimePtr(time.Now())
+		ci = existing
+	} else {
+		ci.CartID = c.ID
+		ci.CreatedAt = time.Now()
+	}
+	if err := s.storer.MoveWishlistItemToCart(ctx, wi.ID, ci); err != nil {
+		return nil, err
+	}
+	return s.touchCart(ctx, c)
+}
+
+// MoveCartItemToWishlist saves a cart item for later on one of the user's
+// wishlists and takes it out of the cart.
+func (s *Server) MoveCartItemToWishlist(ctx context.Context, userID, cartItemID, wishlistID int64) (*PricedWishlist, error) {
+	wl, err := s.ownWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, notFoundAsInvalid(err, "wishlist %d does not exist", wishlistID)
+	}
+	_, ci, err := s.getCartItem(ctx, CartOwner{UserID: userID}, cartItemID)
+	if err != nil {
+		return nil, err
+	}
+	if findWishlistItem(wl, ci.ProductID, ci.VariantID) != nil {
+		err = s.storer.DeleteCartItem(ctx, ci.ID)
+	} else {
+		var wi *storer.WishlistItem
+		wi, err = s.newWishlistItem(ctx, wl.ID, ci.ProductID, ci.VariantID)
+		if err != nil {
+			return nil, err
+		}
+		err = s.storer.MoveCartItemToWishlist(ctx, ci.ID, wi)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWishlist(ctx, userID, wishlistID)
+}
+
+// ownWishlist returns the wishlist if it belongs to the user. Other users'
+// lists are reported as missing.
+func (s *Server) ownWishlist(ctx context.Context, userID, id int64) (*storer.Wishlist, error) {
+	wl, err := s.storer.GetWishlist(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if wl.UserID != userID {
+		return nil, fmt.Errorf("error getting wishlist: %w", storer.ErrNotFound)
+	}
+	return wl, nil
+}
+
+func (s *Server) saveWishlist(ctx context.Context, wl *storer.Wishlist) (*PricedWishlist, error) {
+	wl.UpdatedAt = toTimePtr(time.Now())
+	wl, err := s.storer.UpdateWishlist(ctx, wl)
+	if err != nil {
+		return nil, err
+	}
+	return s.priceWishlist(ctx, wl)
+}
+
+// newWishlistItem records the product's current price and stock so that
+// later changes can be pointed out.
+func (s *Server) newWishlistItem(ctx context.Context, wishlistID, productID int64, variantID *int64) (*storer.WishlistItem, error) {
+	oi := &storer.OrderItem{ProductID: productID, VariantID: variantID, Quantity: 1}
+	available, err := s.priceOrderItem(ctx, oi)
+	if err != nil {
+		return nil, err
+	}
+	return &storer.WishlistItem{
+		WishlistID:   wishlistID,
+		ProductID:    productID,
+		VariantID:    variantID,
+		SavedPrice:   oi.Price,
+		SavedInStock: available > 0,
+		CreatedAt:    time.Now(),
+	}, nil
+}
+
+func (s *Server) priceWishlist(ctx context.Context, wl *storer.Wishlist) (*PricedWishlist, error) {
+	pw := &PricedWishlist{Wishlist: wl}
+	for _, wi := range wl.Items {
+		oi := &storer.OrderItem{ProductID: wi.ProductID, VariantID: wi.VariantID, Quantity: 1}
+		available, err := s.priceOrderItem(ctx, oi)
+		if errors.Is(err, ErrInvalid) {
+			available = 0
+		} else if err != nil {
+			return nil, err
+		}
+		pw.Lines = append(pw.Lines, WishlistLine{
+			WishlistItem: wi,
+			Name:         oi.Name,
+			Image:        oi.Image,
+			Price:        oi.Price,
+			Available:    available,
+			PriceDropped: oi.Price < wi.SavedPrice,
+			BackInStock:  !wi.SavedInStock && available > 0,
+		})
+	}
+	return pw, nil
+}
+
+func findWishlistItem(wl *storer.Wishlist, productID int64, variantID *int64) *storer.WishlistItem {
+	for i := range wl.Items {
+		wi := &wl.Items[i]
+		if wi.ProductID == productID && sameVariant(wi.VariantID, variantID) {
+			return wi
+		}
+	}
+	return nil
+}
+
+func getWishlistItem(wl *storer.Wishlist, itemID int64) (*storer.WishlistItem, error) {
+	for i := range wl.Items {
+		if wl.Items[i].ID == itemID {
+			return &wl.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("error getting wishlist item: %w", storer.ErrNotFound)
+}
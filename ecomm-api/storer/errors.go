@@ -0,0 +1,45 @@
+package storer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	// ErrNotFound is returned when the requested row does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write would violate a uniqueness or
+	// foreign key constraint, e.g. a duplicate slug or deleting a row that
+	// is still referenced.
+	ErrConflict = errors.New("conflict")
+	// ErrInsufficientStock is returned when an order asks for more units
+	// than are in stock.
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrUsageLimitReached is returned when an order redeems a promotion
+	// that has been used as often as it may be.
+	ErrUsageLimitReached = errors.New("promotion usage limit reached")
+	// ErrOverRefund is returned when a refund would give back more than was
+	// captured, or more units of an order item than were ordered.
+	ErrOverRefund = errors.New("refund exceeds what was paid")
+)
+
+const (
+	mysqlErrDupEntry        = 1062
+	mysqlErrRowIsReferenced = 1451
+)
+
+// wrapErr annotates err with msg, translating well-known database errors
+// into ErrNotFound and ErrConflict so callers can tell them apart.
+func wrapErr(msg string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", msg, ErrNotFound)
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && (me.Number == mysqlErrDupEntry || me.Number == mysqlErrRowIsReferenced) {
+		return fmt.Errorf("%s: %w: %s", msg, ErrConflict, me.Message)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
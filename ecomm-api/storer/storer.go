@@ -0,0 +1,157 @@
+package storer
+
+import (
+	"context"
+	"time"
+)
+
+// Storer is the persistence API the server depends on. MySQLStorer is the
+// database-backed implementation and CachedStorer decorates any Storer with
+// a read-through product cache.
+type Storer interface {
+	CreateProduct(ctx context.Context, p *Product) (*Product, error)
+	GetProduct(ctx context.Context, id int64) (*Product, error)
+	ListProducts(ctx context.Context, f ProductFilter) ([]Product, error)
+	UpdateProduct(ctx context.Context, p *Product) (*Product, error)
+	DeleteProduct(ctx context.Context, id int64) error
+
+	CreateCategory(ctx context.Context, c *Category) (*Category, error)
+	GetCategory(ctx context.Context, id int64) (*Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (*Category, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	UpdateCategory(ctx context.Context, c *Category) (*Category, error)
+	DeleteCategory(ctx context.Context, id int64) error
+
+	CreateVariant(ctx context.Context, v *Variant) (*Variant, error)
+	GetVariant(ctx context.Context, id int64) (*Variant, error)
+	ListVariants(ctx context.Context, productID int64) ([]Variant, error)
+	UpdateVariant(ctx context.Context, v *Variant) (*Variant, error)
+	DeleteVariant(ctx context.Context, id int64) error
+
+	CreateReview(ctx context.Context, r *Review) (*Review, error)
+	GetReview(ctx context.Context, id int64) (*Review, error)
+	ListReviews(ctx context.Context, f ReviewFilter) ([]Review, error)
+	UpdateReview(ctx context.Context, r *Review) (*Review, error)
+	UpdateReviewStatus(ctx context.Context, r *Review) (*Review, error)
+	DeleteReview(ctx context.Context, r *Review) error
+	CreateReviewReport(ctx context.Context, r *Review, rep *ReviewReport, threshold int) (*ReviewReport, error)
+	HasPaidOrderItem(ctx context.Context, userID, productID int64) (bool, error)
+
+	CreateUser(ctx context.Context, u *User) (*User, error)
+	GetUser(ctx context.Context, id int64) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	SetUserCustomerGroup(ctx context.Context, userID int64, groupID *int64) error
+
+	CreateCustomerGroup(ctx context.Context, g *CustomerGroup) (*CustomerGroup, error)
+	GetCustomerGroup(ctx context.Context, id int64) (*CustomerGroup, error)
+	ListCustomerGroups(ctx context.Context) ([]CustomerGroup, error)
+	UpdateCustomerGroup(ctx context.Context, g *CustomerGroup) (*CustomerGroup, error)
+	DeleteCustomerGroup(ctx context.Context, id int64) error
+	CreatePriceList(ctx context.Context, pl *PriceList) (*PriceList, error)
+	GetPriceList(ctx context.Context, id int64) (*PriceList, error)
+	ListPriceLists(ctx context.Context, groupID int64) ([]PriceList, error)
+	UpdatePriceList(ctx context.Context, pl *PriceList) (*PriceList, error)
+	DeletePriceList(ctx context.Context, id int64) error
+
+	CreateAddress(ctx context.Context, a *Address) (*Address, error)
+	GetAddress(ctx context.Context, id int64) (*Address, error)
+	ListAddresses(ctx context.Context, userID int64) ([]Address, error)
+	UpdateAddress(ctx context.Context, a *Address) (*Address, error)
+	DeleteAddress(ctx context.Context, id int64) error
+
+	CreateCart(ctx context.Context, c *Cart) (*Cart, error)
+	GetCartByUser(ctx context.Context, userID int64) (*Cart, error)
+	GetCartByGuestToken(ctx context.Context, token string) (*Cart, error)
+	TouchCart(ctx context.Context, c *Cart) error
+	DeleteExpiredCarts(ctx context.Context, t time.Time) (int64, error)
+	MergeCart(ctx context.Context, from, into int64, items []CartItem) error
+	CreateCartItem(ctx context.Context, ci *CartItem) (*CartItem, error)
+	UpdateCartItem(ctx context.Context, ci *CartItem) (*CartItem, error)
+	DeleteCartItem(ctx context.Context, id int64) error
+	CheckoutCart(ctx context.Context, cartID int64, o *Order) (*Order, error)
+
+	CreateWishlist(ctx context.Context, wl *Wishlist) (*Wishlist, error)
+	GetWishlist(ctx context.Context, id int64) (*Wishlist, error)
+	GetWishlistByShareToken(ctx context.Context, token string) (*Wishlist, error)
+	ListWishlists(ctx context.Context, userID int64) ([]Wishlist, error)
+	UpdateWishlist(ctx context.Context, wl *Wishlist) (*Wishlist, error)
+	DeleteWishlist(ctx context.Context, id int64) error
+	CreateWishlistItem(ctx context.Context, wi *WishlistItem) (*WishlistItem, error)
+	DeleteWishlistItem(ctx context.Context, id int64) error
+	MoveWishlistItemToCart(ctx context.Context, wishlistItemID int64, ci *CartItem) error
+	MoveCartItemToWishlist(ctx context.Context, cartItemID int64, wi *WishlistItem) error
+
+	CreatePromotion(ctx context.Context, p *Promotion) (*Promotion, error)
+	GetPromotion(ctx context.Context, id int64) (*Promotion, error)
+	GetPromotionByCode(ctx context.Context, code string) (*Promotion, error)
+	ListPromotions(ctx context.Context) ([]Promotion, error)
+	ListAutomaticPromotions(ctx context.Context, t time.Time) ([]Promotion, error)
+	UpdatePromotion(ctx context.Context, p *Promotion) (*Promotion, error)
+	DeletePromotion(ctx context.Context, id int64) error
+	CountPromotionRedemptions(ctx context.Context, promotionID, userID int64) (int64, error)
+
+	CreateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error)
+	GetTaxRate(ctx context.Context, id int64) (*TaxRate, error)
+	ListTaxRates(ctx context.Context, country string) ([]TaxRate, error)
+	UpdateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error)
+	DeleteTaxRate(ctx context.Context, id int64) error
+
+	GetExchangeRate(ctx context.Context, currency string) (*ExchangeRate, error)
+	ListExchangeRates(ctx context.Context) ([]ExchangeRate, error)
+	SetExchangeRates(ctx context.Context, rates []ExchangeRate) error
+
+	CreateShippingZone(ctx context.Context, z *ShippingZone) (*ShippingZone, error)
+	GetShippingZone(ctx context.Context, id int64) (*ShippingZone, error)
+	ListShippingZones(ctx context.Context) ([]ShippingZone, error)
+	UpdateShippingZone(ctx context.Context, z *ShippingZone) (*ShippingZone, error)
+	DeleteShippingZone(ctx context.Context, id int64) error
+	CreateShippingMethod(ctx context.Context, m *ShippingMethod) (*ShippingMethod, error)
+	GetShippingMethod(ctx context.Context, id int64) (*ShippingMethod, error)
+	ListShippingMethods(ctx context.Context, zoneID int64) ([]ShippingMethod, error)
+	UpdateShippingMethod(ctx context.Context, m *ShippingMethod) (*ShippingMethod, error)
+	DeleteShippingMethod(ctx context.Context, id int64) error
+
+	CreateWarehouse(ctx context.Context, w *Warehouse) (*Warehouse, error)
+	GetWarehouse(ctx context.Context, id int64) (*Warehouse, error)
+	ListWarehouses(ctx context.Context) ([]Warehouse, error)
+	UpdateWarehouse(ctx context.Context, w *Warehouse) (*Warehouse, error)
+	DeleteWarehouse(ctx context.Context, id int64) error
+	ListStockLevels(ctx context.Context, productID int64) ([]StockLevel, error)
+	MoveStock(ctx context.Context, movements []InventoryMovement) ([]InventoryMovement, error)
+	ListInventoryMovements(ctx context.Context, f MovementFilter) ([]InventoryMovement, error)
+
+	CreateOrder(ctx context.Context, o *Order) (*Order, error)
+	GetOrder(ctx context.Context, id int64) (*Order, error)
+	ListOrders(ctx context.Context) ([]Order, error)
+	CancelOrder(ctx context.Context, o *Order, userID int64) error
+	RenewReservations(ctx context.Context, orderID int64, expiresAt time.Time) error
+	ReleaseReservations(ctx context.Context, orderID int64, t time.Time) error
+	ReleaseExpiredReservations(ctx context.Context, t time.Time) (int64, error)
+	DeleteOrder(ctx context.Context, id int64) error
+
+	CreateIdempotencyKey(ctx context.Context, k *IdempotencyKey) (*IdempotencyKey, error)
+	GetIdempotencyKey(ctx context.Context, userID int64, key string) (*IdempotencyKey, error)
+	UpdateIdempotencyKey(ctx context.Context, k *IdempotencyKey) (*IdempotencyKey, error)
+	DeleteIdempotencyKey(ctx context.Context, id int64) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, t time.Time) (int64, error)
+
+	CreatePayment(ctx context.Context, p *Payment) (*Payment, error)
+	GetPayment(ctx context.Context, id int64) (*Payment, error)
+	GetPaymentByProviderRef(ctx context.Context, provider, ref string) (*Payment, error)
+	ListPayments(ctx context.Context, orderID int64) ([]Payment, error)
+	UpdatePayment(ctx context.Context, p *Payment, orderStatus string) (*Payment, error)
+	CreateRefund(ctx context.Context, r *Refund) (*Refund, error)
+	ListRefunds(ctx context.Context, orderID int64) ([]Refund, error)
+	UpdateRefund(ctx context.Context, r *Refund) (*Refund, error)
+	CreateReturn(ctx context.Context, r *Return) (*Return, error)
+	GetReturn(ctx context.Context, id int64) (*Return, error)
+	ListReturns(ctx context.Context, f ReturnFilter) ([]Return, error)
+	UpdateReturn(ctx context.Context, r *Return, from string) (*Return, error)
+	CreatePaymentEvent(ctx context.Context, e *PaymentEvent) (*PaymentEvent, error)
+	GetPaymentEvent(ctx context.Context, id int64) (*PaymentEvent, error)
+	GetPaymentEventByEventID(ctx context.Context, provider, eventID string) (*PaymentEvent, error)
+	ListPaymentEvents(ctx context.Context, limit int) ([]PaymentEvent, error)
+	UpdatePaymentEvent(ctx context.Context, e *PaymentEvent) (*PaymentEvent, error)
+}
+
+var _ Storer = (*MySQLStorer)(nil)
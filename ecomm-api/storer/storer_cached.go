@@ -0,0 +1,402 @@
+package storer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/cache"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	productKeyPrefix  = "product:"
+	productsKeyPrefix = "products:"
+	// InvalidationChannel is the bus channel replicas use to tell each other
+	// which cache entries to drop.
+	InvalidationChannel = "ecomm:cache:invalidate"
+)
+
+// CachedStorer is a read-through cache in front of another Storer. Product
+// lookups and filtered product listings are served from the cache; every
+// write that can change a product drops the affected entries and, when a bus
+// is configured, tells the other replicas to do the same. Cache failures
+// never fail a request: reads fall back to the wrapped Storer.
+type CachedStorer struct {
+	Storer
+	cache cache.Backend
+	// near is the part of cache held in this process. Invalidations from
+	// other replicas only drop it: the publisher already cleared anything
+	// shared between them.
+	near    cache.Backend
+	bus     cache.Bus
+	metrics *cache.Metrics
+	node    string
+	group   singleflight.Group
+	// gen is bumped on every invalidation so that a load which raced with a
+	// write does not put the stale row back into the cache.
+	gen atomic.Uint64
+}
+
+// invalidation is the message published on the bus.
+type invalidation struct {
+	Node     string   `json:"node"`
+	Keys     []string `json:"keys"`
+	Prefixes []string `json:"prefixes"`
+}
+
+// NewCachedStorer wraps st. bus may be nil when running a single replica.
+func NewCachedStorer(st Storer, c cache.Backend, bus cache.Bus, metrics *cache.Metrics) *CachedStorer {
+	node := make([]byte, 8)
+	rand.Read(node)
+	near := c
+	if t, ok := c.(*cache.Tiered); ok {
+		near = t.Near()
+	}
+	return &CachedStorer{Storer: st, cache: c, near: near, bus: bus, metrics: metrics, node: hex.EncodeToString(node)}
+}
+
+// Listen applies invalidations published by other replicas until ctx is
+// cancelled.
+func (cs *CachedStorer) Listen(ctx context.Context) error {
+	if cs.bus == nil {
+		return nil
+	}
+	return cs.bus.Subscribe(ctx, InvalidationChannel, func(msg []byte) {
+		var inv invalidation
+		if err := json.Unmarshal(msg, &inv); err != nil {
+			log.Printf("cache: error decoding invalidation: %v", err)
+			return
+		}
+		if inv.Node == cs.node {
+			return
+		}
+		cs.drop(ctx, cs.near, inv)
+	})
+}
+
+func (cs *CachedStorer) GetProduct(ctx context.Context, id int64) (*Product, error) {
+	var p Product
+	err := cs.load(ctx, productKey(id), &p, func() (interface{}, error) {
+		return cs.Storer.GetProduct(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (cs *CachedStorer) ListProducts(ctx context.Context, f ProductFilter) ([]Product, error) {
+	var products []Product
+	key := fmt.Sprintf("%scategories=%v&min=%s&max=%s&limit=%d&offset=%d", productsKeyPrefix, f.CategoryIDs, f.MinPrice, f.MaxPrice, f.Limit, f.Offset)
+	err := cs.load(ctx, key, &products, func() (interface{}, error) {
+		return cs.Storer.ListProducts(ctx, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (cs *CachedStorer) CreateProduct(ctx context.Context, p *Product) (*Product, error) {
+	pr, err := cs.Storer.CreateProduct(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	cs.invalidateProducts(ctx)
+	return pr, nil
+}
+
+func (cs *CachedStorer) UpdateProduct(ctx context.Context, p *Product) (*Product, error) {
+	pr, err := cs.Storer.UpdateProduct(ctx, p)
+	// the row may have changed even if the call reported an error
+	cs.invalidateProducts(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+func (cs *CachedStorer) DeleteProduct(ctx context.Context, id int64) error {
+	err := cs.Storer.DeleteProduct(ctx, id)
+	cs.invalidateProducts(ctx, id)
+	return err
+}
+
+// products carry their category's name, so renaming one rewrites products
+func (cs *CachedStorer) UpdateCategory(ctx context.Context, c *Category) (*Category, error) {
+	cat, err := cs.Storer.UpdateCategory(ctx, c)
+	cs.invalidateAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cat, nil
+}
+
+// reviews drive the product's rating and review count
+func (cs *CachedStorer) CreateReview(ctx context.Context, r *Review) (*Review, error) {
+	rv, err := cs.Storer.CreateReview(ctx, r)
+	cs.invalidateProducts(ctx, r.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (cs *CachedStorer) UpdateReview(ctx context.Context, r *Review) (*Review, error) {
+	rv, err := cs.Storer.UpdateReview(ctx, r)
+	cs.invalidateProducts(ctx, r.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (cs *CachedStorer) UpdateReviewStatus(ctx context.Context, r *Review) (*Review, error) {
+	rv, err := cs.Storer.UpdateReviewStatus(ctx, r)
+	cs.invalidateProducts(ctx, r.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// a report can flag the review and take it out of the product's rating
+func (cs *CachedStorer) CreateReviewReport(ctx context.Context, r *Review, rep *ReviewReport, threshold int) (*ReviewReport, error) {
+	res, err := cs.Storer.CreateReviewReport(ctx, r, rep, threshold)
+	if r.Status == ReviewStatusFlagged {
+		cs.invalidateProducts(ctx, r.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (cs *CachedStorer) DeleteReview(ctx context.Context, r *Review) error {
+	err := cs.Storer.DeleteReview(ctx, r)
+	cs.invalidateProducts(ctx, r.ProductID)
+	return err
+}
+
+// a paid order takes its reserved stock, so the ordered products are
+// dropped
+func (cs *CachedStorer) UpdatePayment(ctx context.Context, p *Payment, orderStatus string) (*Payment, error) {
+	res, err := cs.Storer.UpdatePayment(ctx, p, orderStatus)
+	if orderStatus == OrderStatusPaid {
+		if o, gerr := cs.Storer.GetOrder(ctx, p.OrderID); gerr == nil {
+			cs.invalidateProducts(ctx, orderProductIDs(o)...)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// placing an order reserves stock for its products
+func (cs *CachedStorer) CreateOrder(ctx context.Context, o *Order) (*Order, error) {
+	res, err := cs.Storer.CreateOrder(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	cs.invalidateProducts(ctx, orderProductIDs(o)...)
+	return res, nil
+}
+
+func (cs *CachedStorer) CheckoutCart(ctx context.Context, cartID int64, o *Order) (*Order, error) {
+	res, err := cs.Storer.CheckoutCart(ctx, cartID, o)
+	if err != nil {
+		return nil, err
+	}
+	cs.invalidateProducts(ctx, orderProductIDs(o)...)
+	return res, nil
+}
+
+func (cs *CachedStorer) RenewReservations(ctx context.Context, orderID int64, expiresAt time.Time) error {
+	if err := cs.Storer.RenewReservations(ctx, orderID, expiresAt); err != nil {
+		return err
+	}
+	cs.invalidateOrderProducts(ctx, orderID)
+	return nil
+}
+
+func (cs *CachedStorer) ReleaseReservations(ctx context.Context, orderID int64, t time.Time) error {
+	if err := cs.Storer.ReleaseReservations(ctx, orderID, t); err != nil {
+		return err
+	}
+	cs.invalidateOrderProducts(ctx, orderID)
+	return nil
+}
+
+// the expired reservations can be of any product
+func (cs *CachedStorer) ReleaseExpiredReservations(ctx context.Context, t time.Time) (int64, error) {
+	n, err := cs.Storer.ReleaseExpiredReservations(ctx, t)
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		cs.invalidateAllProducts(ctx)
+	}
+	return n, nil
+}
+
+func (cs *CachedStorer) DeleteOrder(ctx context.Context, id int64) error {
+	o, err := cs.Storer.GetOrder(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = cs.Storer.DeleteOrder(ctx, id)
+	cs.invalidateProducts(ctx, orderProductIDs(o)...)
+	return err
+}
+
+// movements change the products' totals
+func (cs *CachedStorer) MoveStock(ctx context.Context, movements []InventoryMovement) ([]InventoryMovement, error) {
+	res, err := cs.Storer.MoveStock(ctx, movements)
+	ids := make([]int64, 0, len(movements))
+	for _, m := range movements {
+		ids = append(ids, m.ProductID)
+	}
+	cs.invalidateProducts(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// cancelling restocks the order's products
+func (cs *CachedStorer) CancelOrder(ctx context.Context, o *Order, userID int64) error {
+	err := cs.Storer.CancelOrder(ctx, o, userID)
+	cs.invalidateProducts(ctx, orderProductIDs(o)...)
+	return err
+}
+
+// a succeeded refund may restock its items
+func (cs *CachedStorer) UpdateRefund(ctx context.Context, r *Refund) (*Refund, error) {
+	ref, err := cs.Storer.UpdateRefund(ctx, r)
+	if r.Status == RefundSucceeded && r.Restock {
+		ids := make([]int64, 0, len(r.Items))
+		for _, ri := range r.Items {
+			ids = append(ids, ri.ProductID)
+		}
+		cs.invalidateProducts(ctx, ids...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// load serves key from the cache, or runs fetch once for all concurrent
+// callers missing the same key and caches its result. The cached bytes are
+// decoded into dst so each caller gets its own copy.
+func (cs *CachedStorer) load(ctx context.Context, key string, dst interface{}, fetch func() (interface{}, error)) error {
+	b, ok, err := cs.cache.Get(ctx, key)
+	if err != nil {
+		cs.metrics.Errors.Add(1)
+	}
+	if ok {
+		cs.metrics.Hits.Add(1)
+		return json.Unmarshal(b, dst)
+	}
+	cs.metrics.Misses.Add(1)
+	gen := cs.gen.Load()
+	loaded := false
+	v, err, _ := cs.group.Do(key, func() (interface{}, error) {
+		loaded = true
+		res, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(res)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding cache entry: %w", err)
+		}
+		if cs.gen.Load() == gen {
+			if err := cs.cache.Set(ctx, key, b); err != nil {
+				cs.metrics.Errors.Add(1)
+			}
+		}
+		return b, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !loaded {
+		cs.metrics.Shared.Add(1)
+	}
+	return json.Unmarshal(v.([]byte), dst)
+}
+
+// invalidateProducts drops the given products and every cached listing,
+// since any product change can alter which rows a filter matches.
+func (cs *CachedStorer) invalidateProducts(ctx context.Context, ids ...int64) {
+	inv := invalidation{Node: cs.node, Prefixes: []string{productsKeyPrefix}}
+	for _, id := range ids {
+		inv.Keys = append(inv.Keys, productKey(id))
+	}
+	cs.publish(ctx, inv)
+}
+
+func (cs *CachedStorer) invalidateOrderProducts(ctx context.Context, orderID int64) {
+	o, err := cs.Storer.GetOrder(ctx, orderID)
+	if err != nil {
+		// the products stay cached until the TTL expires
+		log.Printf("cache: error getting order %d to invalidate its products: %v", orderID, err)
+		return
+	}
+	cs.invalidateProducts(ctx, orderProductIDs(o)...)
+}
+
+func (cs *CachedStorer) invalidateAllProducts(ctx context.Context) {
+	cs.publish(ctx, invalidation{Node: cs.node, Prefixes: []string{productKeyPrefix, productsKeyPrefix}})
+}
+
+// publish applies inv locally and broadcasts it to the other replicas.
+func (cs *CachedStorer) publish(ctx context.Context, inv invalidation) {
+	cs.drop(ctx, cs.cache, inv)
+	if cs.bus == nil {
+		return
+	}
+	msg, err := json.Marshal(inv)
+	if err != nil {
+		log.Printf("cache: error encoding invalidation: %v", err)
+		return
+	}
+	if err := cs.bus.Publish(ctx, InvalidationChannel, msg); err != nil {
+		cs.metrics.Errors.Add(1)
+		log.Printf("cache: error publishing invalidation, other replicas may serve stale products until the TTL expires: %v", err)
+	}
+}
+
+func (cs *CachedStorer) drop(ctx context.Context, c cache.Backend, inv invalidation) {
+	cs.gen.Add(1)
+	if err := c.Delete(ctx, inv.Keys...); err != nil {
+		cs.metrics.Errors.Add(1)
+		log.Printf("cache: error dropping %v: %v", inv.Keys, err)
+	}
+	for _, prefix := range inv.Prefixes {
+		if err := c.DeletePrefix(ctx, prefix); err != nil {
+			cs.metrics.Errors.Add(1)
+			log.Printf("cache: error dropping %s*: %v", prefix, err)
+		}
+	}
+}
+
+func productKey(id int64) string {
+	return fmt.Sprintf("%s%d", productKeyPrefix, id)
+}
+
+func orderProductIDs(o *Order) []int64 {
+	ids := make([]int64, 0, len(o.Items))
+	for _, oi := range o.Items {
+		ids = append(ids, oi.ProductID)
+	}
+	return ids
+}
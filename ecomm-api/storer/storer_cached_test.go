@@ -0,0 +1,208 @@
+package storer
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/m21power/ecomm/ecomm-api/cache"
+	"github.com/m21power/ecomm/ecomm-api/cache/resptest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachedStorer(t *testing.T) {
+	productColumns := []string{"id", "name", "image", "category", "description", "rating", "num_reviews", "price", "count_in_stock", "created_at", "updated_at"}
+	productRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(productColumns).
+			AddRow(1, "test product", "test.jpg", "test category", "test description", 4, 100, 100.00, 10, time.Time{}, nil)
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *CachedStorer, *cache.Metrics, sqlmock.Sqlmock)
+	}{
+		{
+			name: "second read is served from the cache",
+			test: func(t *testing.T, cs *CachedStorer, m *cache.Metrics, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				for i := 0; i < 2; i++ {
+					p, err := cs.GetProduct(context.Background(), 1)
+					require.NoError(t, err)
+					require.Equal(t, "test product", p.Name)
+				}
+				require.Equal(t, int64(1), m.Hits.Load())
+				require.Equal(t, int64(1), m.Misses.Load())
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "callers get independent copies",
+			test: func(t *testing.T, cs *CachedStorer, m *cache.Metrics, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				p, err := cs.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+				p.Name = "mutated"
+				p, err = cs.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+				require.Equal(t, "test product", p.Name)
+			},
+		},
+		{
+			name: "concurrent misses share one query",
+			test: func(t *testing.T, cs *CachedStorer, m *cache.Metrics, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM products WHERE category_id IN (?, ?)").WithArgs(3, 4).WillDelayFor(50 * time.Millisecond).WillReturnRows(productRows())
+				var wg sync.WaitGroup
+				for i := 0; i < 5; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						lp, err := cs.ListProducts(context.Background(), ProductFilter{CategoryIDs: []int64{3, 4}})
+						require.NoError(t, err)
+						require.Len(t, lp, 1)
+					}()
+				}
+				wg.Wait()
+				require.Equal(t, int64(5), m.Misses.Load())
+				require.Equal(t, int64(4), m.Shared.Load())
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "update invalidates the product and listings",
+			test: func(t *testing.T, cs *CachedStorer, m *cache.Metrics, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				mock.ExpectQuery("SELECT * FROM products").WillReturnRows(productRows())
+				p, err := cs.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+				_, err = cs.ListProducts(context.Background(), ProductFilter{})
+				require.NoError(t, err)
+
+				mock.ExpectExec("UPDATE products SET name=?, image=?, category=?, category_id=?, description=?, price=?, weight=?, length=?, width=?, height=?, inventory_policy=?, backorder_limit=?, release_date=?, updated_at=? WHERE id=?").WillReturnResult(sqlmock.NewResult(1, 1))
+				p.CountInStock = 0
+				_, err = cs.UpdateProduct(context.Background(), p)
+				require.NoError(t, err)
+
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				mock.ExpectQuery("SELECT * FROM products").WillReturnRows(productRows())
+				_, err = cs.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+				_, err = cs.ListProducts(context.Background(), ProductFilter{})
+				require.NoError(t, err)
+				require.Equal(t, int64(0), m.Hits.Load())
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "errors are not cached",
+			test: func(t *testing.T, cs *CachedStorer, m *cache.Metrics, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnError(sqlmock.ErrCancelled)
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				_, err := cs.GetProduct(context.Background(), 1)
+				require.Error(t, err)
+				_, err = cs.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				m := &cache.Metrics{}
+				cs := NewCachedStorer(NewMySQLStorer(db), cache.NewMemory(100, time.Minute, m), nil, m)
+				tc.test(t, cs, m, mock)
+			})
+		})
+	}
+}
+
+func TestCachedStorerAcrossReplicas(t *testing.T) {
+	productRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "image", "category", "description", "rating", "num_reviews", "price", "count_in_stock", "created_at", "updated_at"}).
+			AddRow(1, "test product", "test.jpg", "test category", "test description", 4, 100, 100.00, 10, time.Time{}, nil)
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *resptest.Server, *CachedStorer, *CachedStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "update on one replica invalidates the other",
+			test: func(t *testing.T, srv *resptest.Server, a, b *CachedStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				_, err := b.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+
+				mock.ExpectExec("UPDATE products SET name=?, image=?, category=?, category_id=?, description=?, price=?, weight=?, length=?, width=?, height=?, inventory_policy=?, backorder_limit=?, release_date=?, updated_at=? WHERE id=?").WillReturnResult(sqlmock.NewResult(1, 1))
+				_, err = a.UpdateProduct(context.Background(), &Product{ID: 1})
+				require.NoError(t, err)
+
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				require.Eventually(t, func() bool {
+					_, err := b.GetProduct(context.Background(), 1)
+					return err == nil && mock.ExpectationsWereMet() == nil
+				}, time.Second, 10*time.Millisecond)
+			},
+		},
+		{
+			name: "remote invalidation leaves the shared tier alone",
+			test: func(t *testing.T, srv *resptest.Server, a, b *CachedStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				_, err := b.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+
+				// as if another replica had dropped the product and then
+				// refilled the shared tier before b saw the message
+				msg, err := json.Marshal(invalidation{Node: "other", Keys: []string{productKey(1)}})
+				require.NoError(t, err)
+				require.NoError(t, a.bus.Publish(context.Background(), InvalidationChannel, msg))
+				require.Eventually(t, func() bool {
+					_, ok, _ := b.near.Get(context.Background(), productKey(1))
+					return !ok
+				}, time.Second, 10*time.Millisecond)
+				require.Contains(t, srv.Keys(), productKey(1))
+
+				_, err = b.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+		{
+			name: "reads fall back to the database when the cache is down",
+			test: func(t *testing.T, srv *resptest.Server, a, b *CachedStorer, mock sqlmock.Sqlmock) {
+				srv.Close()
+				mock.ExpectQuery("SELECT * FROM  products WHERE id=?").WithArgs(1).WillReturnRows(productRows())
+				p, err := a.GetProduct(context.Background(), 1)
+				require.NoError(t, err)
+				require.Equal(t, "test product", p.Name)
+				require.Positive(t, a.metrics.Errors.Load())
+				require.NoError(t, mock.ExpectationsWereMet())
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				srv := resptest.NewServer(t)
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				replica := func() *CachedStorer {
+					m := &cache.Metrics{}
+					rc := cache.NewRedis(srv.Addr(), time.Minute)
+					t.Cleanup(func() { rc.Close() })
+					cs := NewCachedStorer(NewMySQLStorer(db), cache.NewTiered(cache.NewMemory(100, time.Minute, m), rc), rc, m)
+					go cs.Listen(ctx)
+					return cs
+				}
+				a, b := replica(), replica()
+				// wait for both subscriptions before writing
+				require.Eventually(t, func() bool {
+					return srv.Subscribers(InvalidationChannel) == 2
+				}, time.Second, 10*time.Millisecond)
+				tc.test(t, srv, a, b, mock)
+			})
+		})
+	}
+}
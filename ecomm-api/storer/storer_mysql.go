@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -18,7 +19,7 @@ func NewMySQLStorer(db *sqlx.DB) *MySQLStorer {
 }
 
 func (ms *MySQLStorer) CreateProduct(ctx context.Context, p *Product) (*Product, error) {
-	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO products (name, image, category, description, rating, num_reviews, price, count_in_stock, created_at) VALUES (:name, :image, :category, :description, :rating, :num_reviews, :price, :count_in_stock, :created_at)", p)
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO products (name, image, category, category_id, description, rating, num_reviews, price, count_in_stock, weight, length, width, height, inventory_policy, backorder_limit, release_date, created_at) VALUES (:name, :image, :category, :category_id, :description, :rating, :num_reviews, :price, :count_in_stock, :weight, :length, :width, :height, :inventory_policy, :backorder_limit, :release_date, :created_at)", p)
 	if err != nil {
 		log.Println(err)
 		return nil, fmt.Errorf("error inserting product: %w", err)
@@ -37,14 +38,15 @@ func (ms *MySQLStorer) GetProduct(ctx context.Context, id int64) (*Product, erro
 	err := ms.db.GetContext(ctx, &p, "SELECT * FROM  products WHERE id=?", id)
 	log.Println(p, err)
 	if err != nil {
-		return nil, fmt.Errorf("error getting product is it here: %w", err)
+		return nil, wrapErr("error getting product", err)
 	}
 	return &p, nil
 }
 
-func (ms *MySQLStorer) ListProducts(ctx context.Context) ([]Product, error) {
+func (ms *MySQLStorer) ListProducts(ctx context.Context, f ProductFilter) ([]Product, error) {
+	query, args := listProductsQuery(f)
 	var products []Product
-	err := ms.db.SelectContext(ctx, &products, "SELECT * FROM products")
+	err := ms.db.SelectContext(ctx, &products, query, args...)
 	log.Println(products, err)
 	if err != nil {
 		return nil, fmt.Errorf("error listing products: %w", err)
@@ -52,8 +54,38 @@ func (ms *MySQLStorer) ListProducts(ctx context.Context) ([]Product, error) {
 	return products, nil
 }
 
+func listProductsQuery(f ProductFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if len(f.CategoryIDs) > 0 {
+		conds = append(conds, "category_id IN (?"+strings.Repeat(", ?", len(f.CategoryIDs)-1)+")")
+		for _, id := range f.CategoryIDs {
+			args = append(args, id)
+		}
+	}
+	if f.MinPrice > 0 {
+		conds = append(conds, "price>=?")
+		args = append(args, f.MinPrice)
+	}
+	if f.MaxPrice > 0 {
+		conds = append(conds, "price<=?")
+		args = append(args, f.MaxPrice)
+	}
+	query := "SELECT * FROM products"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+	return query, args
+}
+
+// UpdateProduct leaves rating and num_reviews alone; they are derived from
+// the product's reviews.
 func (ms *MySQLStorer) UpdateProduct(ctx context.Context, p *Product) (*Product, error) {
-	_, err := ms.db.NamedExecContext(ctx, "UPDATE products SET name=:name, image=:image, category=:category, description=:description, rating=:rating, num_reviews=:num_reviews, price=:price, count_in_stock=:count_in_stock, updated_at=:updated_at WHERE id=:id", p)
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE products SET name=:name, image=:image, category=:category, category_id=:category_id, description=:description, price=:price, weight=:weight, length=:length, width=:width, height=:height, inventory_policy=:inventory_policy, backorder_limit=:backorder_limit, release_date=:release_date, updated_at=:updated_at WHERE id=:id", p)
 	if err != nil {
 		return nil, fmt.Errorf("error updating product: %w", err)
 	}
@@ -71,21 +103,7 @@ func (ms *MySQLStorer) DeleteProduct(ctx context.Context, id int64) error {
 // transaction to create order and order items
 func (ms *MySQLStorer) CreateOrder(ctx context.Context, o *Order) (*Order, error) {
 	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
-		// Insert into orders
-		order, err := ms.createOrder(ctx, tx, o)
-		if err != nil {
-			return fmt.Errorf("error creating order: %w", err)
-		}
-		// Insert order items
-		for _, oi := range o.Items {
-			oi.OrderID = order.ID
-			id, err := ms.createOrderItem(ctx, tx, &oi)
-			if err != nil {
-				return fmt.Errorf("error creating order item: %w", err)
-			}
-			oi.ID = id
-		}
-		return nil
+		return ms.insertOrder(ctx, tx, o)
 	})
 
 	if err != nil {
@@ -96,8 +114,73 @@ func (ms *MySQLStorer) CreateOrder(ctx context.Context, o *Order) (*Order, error
 	return o, nil
 }
 
+// insertOrder stores the order and its items and takes the items out of
+// stock within tx.
+func (ms *MySQLStorer) insertOrder(ctx context.Context, tx *sqlx.Tx, o *Order) error {
+	// Insert into orders
+	order, err := ms.createOrder(ctx, tx, o)
+	if err != nil {
+		return fmt.Errorf("error creating order: %w", err)
+	}
+	// Insert order items and take them out of stock
+	for i := range o.Items {
+		oi := &o.Items[i]
+		oi.OrderID = order.ID
+		id, err := ms.createOrderItem(ctx, tx, oi)
+		if err != nil {
+			return fmt.Errorf("error creating order item: %w", err)
+		}
+		oi.ID = id
+		if err := ms.allocateStock(ctx, tx, o, oi); err != nil {
+			return err
+		}
+		for j := range oi.Taxes {
+			t := &oi.Taxes[j]
+			t.OrderID = order.ID
+			t.OrderItemID = oi.ID
+			res, err := tx.NamedExecContext(ctx, "INSERT INTO order_item_taxes (order_id, order_item_id, tax_rate_id, name, rate, amount, included) VALUES (:order_id, :order_item_id, :tax_rate_id, :name, :rate, :amount, :included)", t)
+			if err != nil {
+				return fmt.Errorf("error inserting order item tax: %w", err)
+			}
+			if t.ID, err = res.LastInsertId(); err != nil {
+				return fmt.Errorf("error getting last insert ID: %w", err)
+			}
+		}
+	}
+	for _, oa := range []*OrderAddress{o.ShippingAddress, o.BillingAddress} {
+		if oa == nil {
+			continue
+		}
+		oa.OrderID = order.ID
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO order_addresses (order_id, kind, name, line1, line2, city, region, postal_code, country, phone) VALUES (:order_id, :kind, :name, :line1, :line2, :city, :region, :postal_code, :country, :phone)", oa)
+		if err != nil {
+			return fmt.Errorf("error inserting order address: %w", err)
+		}
+		if oa.ID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+	}
+	for i := range o.Discounts {
+		od := &o.Discounts[i]
+		od.OrderID = order.ID
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO order_discounts (order_id, promotion_id, code, description, amount, shipping) VALUES (:order_id, :promotion_id, :code, :description, :amount, :shipping)", od)
+		if err != nil {
+			return fmt.Errorf("error inserting order discount: %w", err)
+		}
+		if od.ID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		if od.PromotionID != nil {
+			if err := ms.redeemPromotion(ctx, tx, *od.PromotionID, o); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (ms *MySQLStorer) createOrder(ctx context.Context, tx *sqlx.Tx, o *Order) (*Order, error) {
-	res, err := tx.NamedExecContext(ctx, "INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (:payment_method, :tax_price, :shipping_price, :total_price)", o)
+	res, err := tx.NamedExecContext(ctx, "INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (:user_id, :status, :payment_method, :shipping_method_id, :shipping_method, :tax_price, :shipping_price, :discount_price, :total_price, :currency, :exchange_rate, :created_at)", o)
 	if err != nil {
 		return nil, fmt.Errorf("error inserting order: %w", err)
 	}
@@ -110,7 +193,7 @@ func (ms *MySQLStorer) createOrder(ctx context.Context, tx *sqlx.Tx, o *Order) (
 }
 
 func (ms *MySQLStorer) createOrderItem(ctx context.Context, tx *sqlx.Tx, oi *OrderItem) (int64, error) {
-	res, err := tx.NamedExecContext(ctx, "INSERT INTO order_items (name,quantity,image,price,product_id,order_id) VALUES (:name,:quantity,:image,:price,:product_id,:order_id)", oi)
+	res, err := tx.NamedExecContext(ctx, "INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (:name,:quantity,:image,:price,:product_id,:variant_id,:order_id,:backordered)", oi)
 	if err != nil {
 		return 0, fmt.Errorf("error inserting order item: %w", err)
 	}
@@ -122,18 +205,51 @@ func (ms *MySQLStorer) createOrderItem(ctx context.Context, tx *sqlx.Tx, oi *Ord
 
 }
 
+// allocateStock reserves the item's units at the warehouses it is allocated
+// to, until each allocation's ExpiresAt. Every unit has to be allocated or
+// backordered; stock held nowhere cannot be sold.
+func (ms *MySQLStorer) allocateStock(ctx context.Context, tx *sqlx.Tx, o *Order, oi *OrderItem) error {
+	allocated := oi.Backordered
+	for _, a := range oi.Allocations {
+		allocated += a.Quantity
+	}
+	if allocated < oi.Quantity {
+		return fmt.Errorf("%w for %q", ErrInsufficientStock, oi.Name)
+	}
+	if oi.Backordered > 0 {
+		if err := checkBackorder(ctx, tx, o, oi); err != nil {
+			return err
+		}
+	}
+	for i := range oi.Allocations {
+		a := &oi.Allocations[i]
+		a.OrderID = o.ID
+		a.OrderItemID = oi.ID
+		a.Status = AllocationReserved
+		err := reserveStock(ctx, tx, &reservation{WarehouseID: a.WarehouseID, ProductID: oi.ProductID, VariantID: oi.VariantID, Quantity: a.Quantity}, o.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("allocating %q: %w", oi.Name, err)
+		}
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO order_item_allocations (order_id, order_item_id, warehouse_id, quantity, status, expires_at) VALUES (:order_id, :order_item_id, :warehouse_id, :quantity, :status, :expires_at)", a)
+		if err != nil {
+			return fmt.Errorf("error inserting order item allocation: %w", err)
+		}
+		if a.ID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+	}
+	return nil
+}
+
 func (ms *MySQLStorer) GetOrder(ctx context.Context, id int64) (*Order, error) {
 	var o Order
 	err := ms.db.GetContext(ctx, &o, "SELECT * FROM orders WHERE id=?", id)
 	if err != nil {
-		return nil, fmt.Errorf("error getting order: %w", err)
+		return nil, wrapErr("error getting order", err)
 	}
-	var oi []OrderItem
-	err = ms.db.SelectContext(ctx, &oi, "SELECT * FROM order_items WHERE order_id=?", id)
-	if err != nil {
-		return nil, fmt.Errorf("error getting order items: %w", err)
+	if err := ms.loadOrderDetails(ctx, &o); err != nil {
+		return nil, err
 	}
-	o.Items = oi
 	return &o, nil
 }
 
@@ -144,16 +260,66 @@ func (ms *MySQLStorer) ListOrders(ctx context.Context) ([]Order, error) {
 		return nil, fmt.Errorf("error listing orders: %w", err)
 	}
 	for i := range orders {
-		var oi []OrderItem
-		err = ms.db.SelectContext(ctx, &oi, "SELECT * FROM order_items WHERE order_id=?", orders[i].ID)
-		if err != nil {
-			return nil, fmt.Errorf("error getting order items: %w", err)
+		if err := ms.loadOrderDetails(ctx, &orders[i]); err != nil {
+			return nil, err
 		}
-		orders[i].Items = oi
 	}
 	return orders, nil
 }
 
+// loadOrderDetails loads the order's items with their taxes and
+// allocations, its discounts and its addresses.
+func (ms *MySQLStorer) loadOrderDetails(ctx context.Context, o *Order) error {
+	var oi []OrderItem
+	err := ms.db.SelectContext(ctx, &oi, "SELECT * FROM order_items WHERE order_id=?", o.ID)
+	if err != nil {
+		return fmt.Errorf("error getting order items: %w", err)
+	}
+	var taxes []OrderItemTax
+	err = ms.db.SelectContext(ctx, &taxes, "SELECT * FROM order_item_taxes WHERE order_id=? ORDER BY id", o.ID)
+	if err != nil {
+		return fmt.Errorf("error getting order item taxes: %w", err)
+	}
+	for _, t := range taxes {
+		for i := range oi {
+			if oi[i].ID == t.OrderItemID {
+				oi[i].Taxes = append(oi[i].Taxes, t)
+			}
+		}
+	}
+	var allocations []OrderItemAllocation
+	err = ms.db.SelectContext(ctx, &allocations, "SELECT * FROM order_item_allocations WHERE order_id=? ORDER BY id", o.ID)
+	if err != nil {
+		return fmt.Errorf("error getting order item allocations: %w", err)
+	}
+	for _, a := range allocations {
+		for i := range oi {
+			if oi[i].ID == a.OrderItemID {
+				oi[i].Allocations = append(oi[i].Allocations, a)
+			}
+		}
+	}
+	o.Items = oi
+	err = ms.db.SelectContext(ctx, &o.Discounts, "SELECT * FROM order_discounts WHERE order_id=?", o.ID)
+	if err != nil {
+		return fmt.Errorf("error getting order discounts: %w", err)
+	}
+	var addresses []OrderAddress
+	err = ms.db.SelectContext(ctx, &addresses, "SELECT * FROM order_addresses WHERE order_id=?", o.ID)
+	if err != nil {
+		return fmt.Errorf("error getting order addresses: %w", err)
+	}
+	for i := range addresses {
+		switch addresses[i].Kind {
+		case AddressShipping:
+			o.ShippingAddress = &addresses[i]
+		case AddressBilling:
+			o.BillingAddress = &addresses[i]
+		}
+	}
+	return nil
+}
+
 // updata order items
 // delete order and order items
 func (ms *MySQLStorer) DeleteOrder(ctx context.Context, id int64) error {
@@ -174,6 +340,88 @@ func (ms *MySQLStorer) DeleteOrder(ctx context.Context, id int64) error {
 	}
 	return nil
 }
+
+// CancelOrder cancels a pending order. Reserved units are released and
+// units already taken, by orders placed before reservations, are put back
+// where they came from as cancellation movements made by userID. The
+// order's promotion uses are given back. o must carry its items as GetOrder
+// loads them. An order that is no longer pending returns ErrConflict.
+func (ms *MySQLStorer) CancelOrder(ctx context.Context, o *Order, userID int64) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE orders SET status=?, updated_at=? WHERE id=? AND status=?", OrderStatusCancelled, o.UpdatedAt, o.ID, OrderStatusPending)
+		if err != nil {
+			return fmt.Errorf("error cancelling order: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error getting rows affected: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: order %d is not pending", ErrConflict, o.ID)
+		}
+		if err := unredeemPromotions(ctx, tx, o.ID); err != nil {
+			return err
+		}
+		at := time.Now()
+		if o.UpdatedAt != nil {
+			at = *o.UpdatedAt
+		}
+		restock := func(warehouseID, productID int64, variantID *int64, quantity int64) error {
+			return addStock(ctx, tx, &InventoryMovement{
+				WarehouseID: warehouseID,
+				ProductID:   productID,
+				VariantID:   variantID,
+				Quantity:    quantity,
+				Kind:        MovementCancellation,
+				OrderID:     &o.ID,
+				UserID:      &userID,
+				CreatedAt:   at,
+			})
+		}
+		// items of orders placed before warehouses; backordered units never
+		// left stock, so only the rest goes back
+		for _, oi := range o.Items {
+			if len(oi.Allocations) > 0 {
+				continue
+			}
+			quantity, err := restockableUnits(ctx, tx, 0, oi.ID)
+			if err != nil {
+				return err
+			}
+			if quantity <= 0 {
+				continue
+			}
+			warehouseID, err := restockWarehouse(ctx, tx, oi.ID)
+			if err != nil {
+				return err
+			}
+			if err := restock(warehouseID, oi.ProductID, oi.VariantID, quantity); err != nil {
+				return err
+			}
+		}
+		rs, err := lockReservations(ctx, tx, "a.order_id=? AND a.status<>?", o.ID, AllocationReleased)
+		if err != nil {
+			return err
+		}
+		for _, r := range rs {
+			if r.Status == AllocationReserved {
+				err = releaseStock(ctx, tx, &r, at)
+			} else {
+				err = restock(r.WarehouseID, r.ProductID, r.VariantID, r.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE order_item_allocations SET status=? WHERE order_id=?", AllocationReleased, o.ID)
+		if err != nil {
+			return fmt.Errorf("error releasing order item allocations: %w", err)
+		}
+		o.Status = OrderStatusCancelled
+		return nil
+	})
+}
+
 func (ms *MySQLStorer) execTx(ctx context.Context, fn func(*sqlx.Tx) error) error {
 	// Begin the transaction
 	tx, err := ms.db.BeginTxx(ctx, nil)
@@ -0,0 +1,80 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreateAddress adds an address to the user's book. A new default address
+// replaces the previous one.
+func (ms *MySQLStorer) CreateAddress(ctx context.Context, a *Address) (*Address, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO addresses (user_id, name, line1, line2, city, region, postal_code, country, phone, is_default, created_at) VALUES (:user_id, :name, :line1, :line2, :city, :region, :postal_code, :country, :phone, :is_default, :created_at)", a)
+		if err != nil {
+			return wrapErr("error inserting address", err)
+		}
+		if a.ID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		return clearOtherDefaults(ctx, tx, a)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (ms *MySQLStorer) GetAddress(ctx context.Context, id int64) (*Address, error) {
+	var a Address
+	err := ms.db.GetContext(ctx, &a, "SELECT * FROM addresses WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting address", err)
+	}
+	return &a, nil
+}
+
+// ListAddresses lists the user's addresses, the default one first.
+func (ms *MySQLStorer) ListAddresses(ctx context.Context, userID int64) ([]Address, error) {
+	var as []Address
+	err := ms.db.SelectContext(ctx, &as, "SELECT * FROM addresses WHERE user_id=? ORDER BY is_default DESC, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing addresses: %w", err)
+	}
+	return as, nil
+}
+
+func (ms *MySQLStorer) UpdateAddress(ctx context.Context, a *Address) (*Address, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, "UPDATE addresses SET name=:name, line1=:line1, line2=:line2, city=:city, region=:region, postal_code=:postal_code, country=:country, phone=:phone, is_default=:is_default, updated_at=:updated_at WHERE id=:id", a)
+		if err != nil {
+			return wrapErr("error updating address", err)
+		}
+		return clearOtherDefaults(ctx, tx, a)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (ms *MySQLStorer) DeleteAddress(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM addresses WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting address", err)
+	}
+	return nil
+}
+
+// clearOtherDefaults keeps a the user's only default address.
+func clearOtherDefaults(ctx context.Context, tx *sqlx.Tx, a *Address) error {
+	if !a.IsDefault {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "UPDATE addresses SET is_default=false WHERE user_id=? AND id<>?", a.UserID, a.ID)
+	if err != nil {
+		return fmt.Errorf("error clearing default address: %w", err)
+	}
+	return nil
+}
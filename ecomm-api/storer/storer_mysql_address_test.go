@@ -0,0 +1,100 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAddress(t *testing.T) {
+	insert := "INSERT INTO addresses (user_id, name, line1, line2, city, region, postal_code, country, phone, is_default, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	newAddress := func(isDefault bool) *Address {
+		return &Address{
+			UserID: 4,
+			PostalAddress: PostalAddress{
+				Name:       "Ada Lovelace",
+				Line1:      "12 Example St",
+				City:       "London",
+				PostalCode: "N1 9GU",
+				Country:    "GB",
+			},
+			IsDefault: isDefault,
+			CreatedAt: time.Now(),
+		}
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "default replaces the previous default",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				a := newAddress(true)
+				mock.ExpectBegin()
+				mock.ExpectExec(insert).WithArgs(a.UserID, a.Name, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, a.Phone, true, a.CreatedAt).WillReturnResult(sqlmock.NewResult(7, 1))
+				mock.ExpectExec("UPDATE addresses SET is_default=false WHERE user_id=? AND id<>?").WithArgs(int64(4), int64(7)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				created, err := st.CreateAddress(context.Background(), a)
+				require.NoError(t, err)
+				require.Equal(t, int64(7), created.ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "not default",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(8, 1))
+				mock.ExpectCommit()
+				_, err := st.CreateAddress(context.Background(), newAddress(false))
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestCreateOrderStoresAddresses(t *testing.T) {
+	shipTo := PostalAddress{Name: "Ada Lovelace", Line1: "12 Example St", City: "London", PostalCode: "N1 9GU", Country: "GB"}
+	o := &Order{
+		UserID:          4,
+		Status:          OrderStatusPending,
+		TotalPrice:      20,
+		CreatedAt:       time.Now(),
+		Items:           []OrderItem{{Name: "product 9", Quantity: 2, Price: 10, ProductID: 9, Allocations: []OrderItemAllocation{{WarehouseID: 1, Quantity: 2}}}},
+		ShippingAddress: &OrderAddress{Kind: AddressShipping, PostalAddress: shipTo},
+		BillingAddress:  &OrderAddress{Kind: AddressBilling, PostalAddress: shipTo},
+	}
+	insert := "INSERT INTO order_addresses (order_id, kind, name, line1, line2, city, region, postal_code, country, phone) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(11, 1))
+		mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(5, 1))
+		mock.ExpectExec(reserveStockSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(allocationSQL).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(insert).WithArgs(int64(11), AddressShipping, shipTo.Name, shipTo.Line1, shipTo.Line2, shipTo.City, shipTo.Region, shipTo.PostalCode, shipTo.Country, shipTo.Phone).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(insert).WithArgs(int64(11), AddressBilling, shipTo.Name, shipTo.Line1, shipTo.Line2, shipTo.City, shipTo.Region, shipTo.PostalCode, shipTo.Country, shipTo.Phone).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		created, err := st.CreateOrder(context.Background(), o)
+		require.NoError(t, err)
+		require.Equal(t, int64(11), created.BillingAddress.OrderID)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,35 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// checkBackorder makes sure the product's inventory policy lets oi's
+// backordered units be ordered. The product row stays locked until tx ends,
+// so concurrent orders cannot both fit under the backorder limit. Units on
+// backorder are those of orders still pending or paid, including oi.
+func checkBackorder(ctx context.Context, tx *sqlx.Tx, o *Order, oi *OrderItem) error {
+	var p Product
+	err := tx.GetContext(ctx, &p, "SELECT inventory_policy, backorder_limit, release_date FROM products WHERE id=? FOR UPDATE", oi.ProductID)
+	if err != nil {
+		return wrapErr("error getting inventory policy", err)
+	}
+	if !p.AllowsBackorder(o.CreatedAt) {
+		return fmt.Errorf("%w for %q", ErrInsufficientStock, oi.Name)
+	}
+	if p.BackorderLimit == nil {
+		return nil
+	}
+	var backordered int64
+	err = tx.GetContext(ctx, &backordered, "SELECT COALESCE(SUM(oi.backordered), 0) FROM order_items oi JOIN orders o ON o.id=oi.order_id WHERE oi.product_id=? AND o.status IN (?, ?)", oi.ProductID, OrderStatusPending, OrderStatusPaid)
+	if err != nil {
+		return fmt.Errorf("error getting backordered units: %w", err)
+	}
+	if backordered > *p.BackorderLimit {
+		return fmt.Errorf("%w for %q: only %d more can be backordered", ErrInsufficientStock, oi.Name, *p.BackorderLimit-(backordered-oi.Backordered))
+	}
+	return nil
+}
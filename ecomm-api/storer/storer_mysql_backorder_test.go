@@ -0,0 +1,123 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrderBackordersItems(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(15 * time.Minute)
+	policySQL := "SELECT inventory_policy, backorder_limit, release_date FROM products WHERE id=? FOR UPDATE"
+	backorderedSQL := "SELECT COALESCE(SUM(oi.backordered), 0) FROM order_items oi JOIN orders o ON o.id=oi.order_id WHERE oi.product_id=? AND o.status IN (?, ?)"
+	policyColumns := []string{"inventory_policy", "backorder_limit", "release_date"}
+	// two units in stock, three more backordered
+	order := func() *Order {
+		return &Order{
+			UserID:    1,
+			Status:    OrderStatusPending,
+			CreatedAt: now,
+			Items: []OrderItem{{
+				Name:        "product 1",
+				Quantity:    5,
+				Price:       9999,
+				ProductID:   1,
+				Backordered: 3,
+				Allocations: []OrderItemAllocation{{WarehouseID: 1, Quantity: 2, ExpiresAt: &expiresAt}},
+			}},
+		}
+	}
+	expectItem := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WithArgs("product 1", 5, "", "99.99", 1, nil, 1, 3).WillReturnResult(sqlmock.NewResult(4, 1))
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "within_limit",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				expectItem(mock)
+				mock.ExpectQuery(policySQL).WithArgs(1).WillReturnRows(sqlmock.NewRows(policyColumns).AddRow(InventoryBackorder, 10, nil))
+				mock.ExpectQuery(backorderedSQL).WithArgs(1, OrderStatusPending, OrderStatusPaid).WillReturnRows(sqlmock.NewRows([]string{"backordered"}).AddRow(10))
+				mock.ExpectExec(reserveStockSQL).WithArgs(2, now, 1, 1, nil, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(allocationSQL).WithArgs(1, 4, 1, 2, AllocationReserved, &expiresAt).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+				created, err := st.CreateOrder(context.Background(), order())
+				require.NoError(t, err)
+				require.Equal(t, int64(3), created.Items[0].Backordered)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "over_limit",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				expectItem(mock)
+				mock.ExpectQuery(policySQL).WithArgs(1).WillReturnRows(sqlmock.NewRows(policyColumns).AddRow(InventoryBackorder, 10, nil))
+				mock.ExpectQuery(backorderedSQL).WithArgs(1, OrderStatusPending, OrderStatusPaid).WillReturnRows(sqlmock.NewRows([]string{"backordered"}).AddRow(11))
+				mock.ExpectRollback()
+				_, err := st.CreateOrder(context.Background(), order())
+				require.ErrorIs(t, err, ErrInsufficientStock)
+				require.ErrorContains(t, err, "only 2 more can be backordered")
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "deny",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				expectItem(mock)
+				mock.ExpectQuery(policySQL).WithArgs(1).WillReturnRows(sqlmock.NewRows(policyColumns).AddRow(InventoryDeny, nil, nil))
+				mock.ExpectRollback()
+				_, err := st.CreateOrder(context.Background(), order())
+				require.ErrorIs(t, err, ErrInsufficientStock)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "preorder_released",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				expectItem(mock)
+				mock.ExpectQuery(policySQL).WithArgs(1).WillReturnRows(sqlmock.NewRows(policyColumns).AddRow(InventoryPreorder, nil, now.Add(-time.Hour)))
+				mock.ExpectRollback()
+				_, err := st.CreateOrder(context.Background(), order())
+				require.ErrorIs(t, err, ErrInsufficientStock)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "not_covered",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				o := order()
+				o.Items[0].Backordered = 2
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(4, 1))
+				mock.ExpectRollback()
+				_, err := st.CreateOrder(context.Background(), o)
+				require.ErrorIs(t, err, ErrInsufficientStock)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
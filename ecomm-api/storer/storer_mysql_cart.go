@@ -0,0 +1,159 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (ms *MySQLStorer) CreateCart(ctx context.Context, c *Cart) (*Cart, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO carts (user_id, guest_token, expires_at, created_at) VALUES (:user_id, :guest_token, :expires_at, :created_at)", c)
+	if err != nil {
+		return nil, wrapErr("error inserting cart", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	c.ID = id
+	return c, nil
+}
+
+// GetCartByUser returns the user's cart with its items.
+func (ms *MySQLStorer) GetCartByUser(ctx context.Context, userID int64) (*Cart, error) {
+	return ms.getCart(ctx, "SELECT * FROM carts WHERE user_id=?", userID)
+}
+
+// GetCartByGuestToken returns the guest cart stored under the token hash.
+func (ms *MySQLStorer) GetCartByGuestToken(ctx context.Context, token string) (*Cart, error) {
+	return ms.getCart(ctx, "SELECT * FROM carts WHERE guest_token=?", token)
+}
+
+func (ms *MySQLStorer) getCart(ctx context.Context, query string, args ...interface{}) (*Cart, error) {
+	var c Cart
+	err := ms.db.GetContext(ctx, &c, query, args...)
+	if err != nil {
+		return nil, wrapErr("error getting cart", err)
+	}
+	err = ms.db.SelectContext(ctx, &c.Items, "SELECT * FROM cart_items WHERE cart_id=? ORDER BY id", c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cart items: %w", err)
+	}
+	return &c, nil
+}
+
+// TouchCart records a change to the cart and pushes back its expiry.
+func (ms *MySQLStorer) TouchCart(ctx context.Context, c *Cart) error {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE carts SET expires_at=:expires_at, updated_at=:updated_at WHERE id=:id", c)
+	if err != nil {
+		return fmt.Errorf("error updating cart: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpiredCarts removes guest carts that expired before t, with their
+// items, and reports how many were removed.
+func (ms *MySQLStorer) DeleteExpiredCarts(ctx context.Context, t time.Time) (int64, error) {
+	res, err := ms.db.ExecContext(ctx, "DELETE FROM carts WHERE user_id IS NULL AND expires_at<?", t)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired carts: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// MergeCart saves items into the cart into and deletes the cart from, in one
+// transaction. Items with an ID are updated, the others inserted into into.
+func (ms *MySQLStorer) MergeCart(ctx context.Context, from, into int64, items []CartItem) error {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		for i := range items {
+			items[i].CartID = into
+			if err := ms.saveCartItem(ctx, tx, &items[i]); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM carts WHERE id=?", from)
+		if err != nil {
+			return fmt.Errorf("error deleting merged cart: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error merging carts: %w", err)
+	}
+	return nil
+}
+
+// saveCartItem updates the item if it has an ID and inserts it otherwise.
+func (ms *MySQLStorer) saveCartItem(ctx context.Context, tx *sqlx.Tx, ci *CartItem) error {
+	if ci.ID != 0 {
+		_, err := tx.NamedExecContext(ctx, "UPDATE cart_items SET quantity=:quantity, updated_at=:updated_at WHERE id=:id", ci)
+		if err != nil {
+			return fmt.Errorf("error saving cart item: %w", err)
+		}
+		return nil
+	}
+	res, err := tx.NamedExecContext(ctx, "INSERT INTO cart_items (cart_id, product_id, variant_id, quantity, created_at) VALUES (:cart_id, :product_id, :variant_id, :quantity, :created_at)", ci)
+	if err != nil {
+		return fmt.Errorf("error saving cart item: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	ci.ID = id
+	return nil
+}
+
+func (ms *MySQLStorer) CreateCartItem(ctx context.Context, ci *CartItem) (*CartItem, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO cart_items (cart_id, product_id, variant_id, quantity, created_at) VALUES (:cart_id, :product_id, :variant_id, :quantity, :created_at)", ci)
+	if err != nil {
+		return nil, wrapErr("error inserting cart item", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	ci.ID = id
+	return ci, nil
+}
+
+func (ms *MySQLStorer) UpdateCartItem(ctx context.Context, ci *CartItem) (*CartItem, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE cart_items SET quantity=:quantity, updated_at=:updated_at WHERE id=:id", ci)
+	if err != nil {
+		return nil, fmt.Errorf("error updating cart item: %w", err)
+	}
+	return ci, nil
+}
+
+func (ms *MySQLStorer) DeleteCartItem(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM cart_items WHERE id=?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting cart item: %w", err)
+	}
+	return nil
+}
+
+// CheckoutCart stores the order built from the cart and empties the cart in
+// the same transaction, so a failed order leaves the cart untouched.
+func (ms *MySQLStorer) CheckoutCart(ctx context.Context, cartID int64, o *Order) (*Order, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		if err := ms.insertOrder(ctx, tx, o); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id=?", cartID)
+		if err != nil {
+			return fmt.Errorf("error emptying cart: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error checking out cart: %w", err)
+	}
+	return o, nil
+}
@@ -0,0 +1,170 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCartByUser(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM carts WHERE user_id=?").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "created_at"}).AddRow(2, 4, time.Now()))
+				mock.ExpectQuery("SELECT * FROM cart_items WHERE cart_id=? ORDER BY id").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id", "cart_id", "product_id", "variant_id", "quantity", "created_at"}).AddRow(1, 2, 9, nil, 3, time.Now()))
+				c, err := st.GetCartByUser(context.Background(), 4)
+				require.NoError(t, err)
+				require.Len(t, c.Items, 1)
+				require.Equal(t, int64(3), c.Items[0].Quantity)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "no cart",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM carts WHERE user_id=?").WithArgs(4).WillReturnError(sql.ErrNoRows)
+				_, err := st.GetCartByUser(context.Background(), 4)
+				require.ErrorIs(t, err, ErrNotFound)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestCheckoutCart(t *testing.T) {
+	o := &Order{
+		UserID:        4,
+		Status:        OrderStatusPending,
+		PaymentMethod: "card",
+		TotalPrice:    20,
+		Items: []OrderItem{
+			{Name: "product 9", Quantity: 2, Image: "9.jpg", Price: 10, ProductID: 9, Allocations: []OrderItemAllocation{{WarehouseID: 1, Quantity: 2}}},
+		},
+	}
+	oi := o.Items[0]
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(11, 1))
+				mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WithArgs(oi.Name, oi.Quantity, oi.Image, oi.Price, oi.ProductID, oi.VariantID, 11, oi.Backordered).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(reserveStockSQL).WithArgs(oi.Quantity, o.CreatedAt, 1, oi.ProductID, nil, oi.Quantity).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(allocationSQL).WithArgs(11, 1, 1, oi.Quantity, AllocationReserved, nil).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("DELETE FROM cart_items WHERE cart_id=?").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				co, err := st.CheckoutCart(context.Background(), 2, o)
+				require.NoError(t, err)
+				require.Equal(t, int64(11), co.ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "insufficient stock keeps the cart",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(11, 1))
+				mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(reserveStockSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				_, err := st.CheckoutCart(context.Background(), 2, o)
+				require.ErrorIs(t, err, ErrInsufficientStock)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestMergeCart(t *testing.T) {
+	now := time.Now()
+	items := []CartItem{
+		{ID: 3, ProductID: 9, Quantity: 5, UpdatedAt: &now},
+		{ProductID: 10, Quantity: 1, CreatedAt: now},
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE cart_items SET quantity=?, updated_at=? WHERE id=?").WithArgs(int64(5), &now, int64(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO cart_items (cart_id, product_id, variant_id, quantity, created_at) VALUES (?, ?, ?, ?, ?)").WithArgs(int64(2), int64(10), nil, int64(1), now).WillReturnResult(sqlmock.NewResult(4, 1))
+				mock.ExpectExec("DELETE FROM carts WHERE id=?").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				err := st.MergeCart(context.Background(), 7, 2, items)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "error keeps the guest cart",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE cart_items SET quantity=?, updated_at=? WHERE id=?").WillReturnError(fmt.Errorf("db error"))
+				mock.ExpectRollback()
+				err := st.MergeCart(context.Background(), 7, 2, items)
+				require.Error(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestDeleteExpiredCarts(t *testing.T) {
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		now := time.Now()
+		mock.ExpectExec("DELETE FROM carts WHERE user_id IS NULL AND expires_at<?").WithArgs(now).WillReturnResult(sqlmock.NewResult(0, 3))
+		n, err := st.DeleteExpiredCarts(context.Background(), now)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), n)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
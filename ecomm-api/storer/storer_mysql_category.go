@@ -0,0 +1,78 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (ms *MySQLStorer) CreateCategory(ctx context.Context, c *Category) (*Category, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO categories (name, slug, parent_id, tax_class, created_at) VALUES (:name, :slug, :parent_id, :tax_class, :created_at)", c)
+	if err != nil {
+		return nil, wrapErr("error inserting category", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	c.ID = id
+	return c, nil
+}
+
+func (ms *MySQLStorer) GetCategory(ctx context.Context, id int64) (*Category, error) {
+	var c Category
+	err := ms.db.GetContext(ctx, &c, "SELECT * FROM categories WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting category", err)
+	}
+	return &c, nil
+}
+
+func (ms *MySQLStorer) GetCategoryBySlug(ctx context.Context, slug string) (*Category, error) {
+	var c Category
+	err := ms.db.GetContext(ctx, &c, "SELECT * FROM categories WHERE slug=?", slug)
+	if err != nil {
+		return nil, wrapErr("error getting category", err)
+	}
+	return &c, nil
+}
+
+func (ms *MySQLStorer) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	err := ms.db.SelectContext(ctx, &categories, "SELECT * FROM categories ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("error listing categories: %w", err)
+	}
+	return categories, nil
+}
+
+// UpdateCategory also renames the category on its products, which keep the
+// name alongside category_id.
+func (ms *MySQLStorer) UpdateCategory(ctx context.Context, c *Category) (*Category, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, "UPDATE categories SET name=:name, slug=:slug, parent_id=:parent_id, tax_class=:tax_class, updated_at=:updated_at WHERE id=:id", c)
+		if err != nil {
+			return wrapErr("error updating category", err)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE products SET category=? WHERE category_id=?", c.Name, c.ID)
+		if err != nil {
+			return fmt.Errorf("error renaming category on products: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteCategory fails with ErrConflict while products or subcategories
+// still reference the category.
+func (ms *MySQLStorer) DeleteCategory(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM categories WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting category", err)
+	}
+	return nil
+}
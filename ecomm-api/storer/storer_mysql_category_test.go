@@ -0,0 +1,181 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCategory(t *testing.T) {
+	parentID := int64(1)
+	c := &Category{
+		Name:     "Running Shoes",
+		Slug:     "running-shoes",
+		ParentID: &parentID,
+		TaxClass: TaxClassStandard,
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO categories (name, slug, parent_id, tax_class, created_at) VALUES (?, ?, ?, ?, ?)").WithArgs(c.Name, c.Slug, c.ParentID, c.TaxClass, c.CreatedAt).WillReturnResult(sqlmock.NewResult(2, 1))
+				cc, err := st.CreateCategory(context.Background(), c)
+				require.NoError(t, err)
+				require.Equal(t, int64(2), cc.ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "duplicate slug",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO categories (name, slug, parent_id, tax_class, created_at) VALUES (?, ?, ?, ?, ?)").WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+				_, err := st.CreateCategory(context.Background(), c)
+				require.ErrorIs(t, err, ErrConflict)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestGetCategoryBySlug(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "name", "slug", "parent_id", "created_at", "updated_at"}).
+					AddRow(1, "Shoes", "shoes", nil, time.Now(), nil)
+				mock.ExpectQuery("SELECT * FROM categories WHERE slug=?").WithArgs("shoes").WillReturnRows(rows)
+				c, err := st.GetCategoryBySlug(context.Background(), "shoes")
+				require.NoError(t, err)
+				require.Equal(t, int64(1), c.ID)
+				require.Nil(t, c.ParentID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "not found",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "name", "slug", "parent_id", "created_at", "updated_at"})
+				mock.ExpectQuery("SELECT * FROM categories WHERE slug=?").WithArgs("hats").WillReturnRows(rows)
+				_, err := st.GetCategoryBySlug(context.Background(), "hats")
+				require.ErrorIs(t, err, ErrNotFound)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestUpdateCategory(t *testing.T) {
+	c := &Category{
+		ID:       1,
+		Name:     "Footwear",
+		Slug:     "footwear",
+		TaxClass: TaxClassStandard,
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success renames products",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE categories SET name=?, slug=?, parent_id=?, tax_class=?, updated_at=? WHERE id=?").WithArgs(c.Name, c.Slug, c.ParentID, c.TaxClass, c.UpdatedAt, c.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET category=? WHERE category_id=?").WithArgs(c.Name, c.ID).WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectCommit()
+				_, err := st.UpdateCategory(context.Background(), c)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "failure_rollback",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE categories SET name=?, slug=?, parent_id=?, tax_class=?, updated_at=? WHERE id=?").WillReturnError(fmt.Errorf("db error"))
+				mock.ExpectRollback()
+				_, err := st.UpdateCategory(context.Background(), c)
+				require.Error(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestDeleteCategory(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM categories WHERE id=?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				err := st.DeleteCategory(context.Background(), 1)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "still referenced",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM categories WHERE id=?").WithArgs(1).WillReturnError(&mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row"})
+				err := st.DeleteCategory(context.Background(), 1)
+				require.ErrorIs(t, err, ErrConflict)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (ms *MySQLStorer) GetExchangeRate(ctx context.Context, currency string) (*ExchangeRate, error) {
+	var er ExchangeRate
+	err := ms.db.GetContext(ctx, &er, "SELECT * FROM exchange_rates WHERE currency=?", currency)
+	if err != nil {
+		return nil, wrapErr("error getting exchange rate", err)
+	}
+	return &er, nil
+}
+
+func (ms *MySQLStorer) ListExchangeRates(ctx context.Context) ([]ExchangeRate, error) {
+	var rates []ExchangeRate
+	err := ms.db.SelectContext(ctx, &rates, "SELECT * FROM exchange_rates ORDER BY currency")
+	if err != nil {
+		return nil, fmt.Errorf("error listing exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// SetExchangeRates replaces the whole table with rates, so a currency left
+// out can no longer be sold in.
+func (ms *MySQLStorer) SetExchangeRates(ctx context.Context, rates []ExchangeRate) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM exchange_rates"); err != nil {
+			return fmt.Errorf("error deleting exchange rates: %w", err)
+		}
+		for i := range rates {
+			_, err := tx.NamedExecContext(ctx, "INSERT INTO exchange_rates (currency, rate, updated_at) VALUES (:currency, :rate, :updated_at)", &rates[i])
+			if err != nil {
+				return wrapErr("error inserting exchange rate", err)
+			}
+		}
+		return nil
+	})
+}
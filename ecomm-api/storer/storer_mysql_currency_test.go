@@ -0,0 +1,71 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetExchangeRates(t *testing.T) {
+	now := time.Now()
+	rates := []ExchangeRate{
+		{Currency: "EUR", Rate: 0.92, UpdatedAt: now},
+		{Currency: "GBP", Rate: 0.79, UpdatedAt: now},
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM exchange_rates").WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec("INSERT INTO exchange_rates (currency, rate, updated_at) VALUES (?, ?, ?)").WithArgs("EUR", 0.92, now).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO exchange_rates (currency, rate, updated_at) VALUES (?, ?, ?)").WithArgs("GBP", 0.79, now).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				err := st.SetExchangeRates(context.Background(), rates)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "failure rollback",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM exchange_rates").WillReturnResult(sqlmock.NewResult(0, 3))
+				mock.ExpectExec("INSERT INTO exchange_rates (currency, rate, updated_at) VALUES (?, ?, ?)").WithArgs("EUR", 0.92, now).WillReturnError(fmt.Errorf("db error"))
+				mock.ExpectRollback()
+				err := st.SetExchangeRates(context.Background(), rates)
+				require.Error(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectQuery("SELECT * FROM exchange_rates WHERE currency=?").WithArgs("JPY").WillReturnRows(sqlmock.NewRows([]string{"currency", "rate", "updated_at"}))
+		_, err := st.GetExchangeRate(context.Background(), "JPY")
+		require.ErrorIs(t, err, ErrNotFound)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
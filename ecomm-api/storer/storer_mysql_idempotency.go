@@ -0,0 +1,62 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CreateIdempotencyKey claims a key for the user. A key the user already
+// holds fails with ErrConflict.
+func (ms *MySQLStorer) CreateIdempotencyKey(ctx context.Context, k *IdempotencyKey) (*IdempotencyKey, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at) VALUES (:user_id, :idempotency_key, :request_hash, :created_at, :expires_at)", k)
+	if err != nil {
+		return nil, wrapErr("error inserting idempotency key", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	k.ID = id
+	return k, nil
+}
+
+func (ms *MySQLStorer) GetIdempotencyKey(ctx context.Context, userID int64, key string) (*IdempotencyKey, error) {
+	var k IdempotencyKey
+	err := ms.db.GetContext(ctx, &k, "SELECT * FROM idempotency_keys WHERE user_id=? AND idempotency_key=?", userID, key)
+	if err != nil {
+		return nil, wrapErr("error getting idempotency key", err)
+	}
+	return &k, nil
+}
+
+// UpdateIdempotencyKey stores the response to the key's request.
+func (ms *MySQLStorer) UpdateIdempotencyKey(ctx context.Context, k *IdempotencyKey) (*IdempotencyKey, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE idempotency_keys SET status_code=:status_code, content_type=:content_type, response_body=:response_body WHERE id=:id", k)
+	if err != nil {
+		return nil, wrapErr("error updating idempotency key", err)
+	}
+	return k, nil
+}
+
+func (ms *MySQLStorer) DeleteIdempotencyKey(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting idempotency key", err)
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes keys that expired before t and
+// reports how many were removed.
+func (ms *MySQLStorer) DeleteExpiredIdempotencyKeys(ctx context.Context, t time.Time) (int64, error) {
+	res, err := ms.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at<?", t)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting expired idempotency keys: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,53 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateIdempotencyKey(t *testing.T) {
+	insert := "INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?)"
+	now := time.Now()
+	k := &IdempotencyKey{UserID: 1, Key: "retry-me", RequestHash: "abc", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec(insert).WithArgs(1, "retry-me", "abc", k.CreatedAt, k.ExpiresAt).WillReturnResult(sqlmock.NewResult(8, 1))
+				created, err := st.CreateIdempotencyKey(context.Background(), k)
+				require.NoError(t, err)
+				require.Equal(t, int64(8), created.ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "key taken",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec(insert).WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+				_, err := st.CreateIdempotencyKey(context.Background(), k)
+				require.True(t, errors.Is(err, ErrConflict))
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MoveStock applies the movements, all or none of them, and records them in
+// the ledger. A movement that takes out more than its warehouse holds fails
+// with ErrInsufficientStock.
+func (ms *MySQLStorer) MoveStock(ctx context.Context, movements []InventoryMovement) ([]InventoryMovement, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		for i := range movements {
+			if err := addStock(ctx, tx, &movements[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movements, nil
+}
+
+// ListInventoryMovements lists ledger entries, newest first.
+func (ms *MySQLStorer) ListInventoryMovements(ctx context.Context, f MovementFilter) ([]InventoryMovement, error) {
+	query, args := listMovementsQuery(f)
+	var movements []InventoryMovement
+	err := ms.db.SelectContext(ctx, &movements, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing inventory movements: %w", err)
+	}
+	return movements, nil
+}
+
+func listMovementsQuery(f MovementFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.ProductID != 0 {
+		conds = append(conds, "product_id=?")
+		args = append(args, f.ProductID)
+	}
+	if f.WarehouseID != 0 {
+		conds = append(conds, "warehouse_id=?")
+		args = append(args, f.WarehouseID)
+	}
+	query := "SELECT * FROM inventory_movements"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+	return query, args
+}
+
+// addStock applies m to the warehouse's stock of the product, or of its
+// variant, and to the product's or variant's total, and appends m to the
+// ledger. It is the only way stock changes. Taking out more than the
+// warehouse holds beyond what is reserved fails with ErrInsufficientStock.
+func addStock(ctx context.Context, tx *sqlx.Tx, m *InventoryMovement) error {
+	if m.Quantity < 0 {
+		res, err := tx.ExecContext(ctx, "UPDATE stock_levels SET quantity=quantity+?, updated_at=? WHERE warehouse_id=? AND product_id=? AND variant_id<=>? AND quantity-reserved>=?", m.Quantity, m.CreatedAt, m.WarehouseID, m.ProductID, m.VariantID, -m.Quantity)
+		if err != nil {
+			return fmt.Errorf("error updating stock level: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error getting rows affected: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("%w at warehouse %d", ErrInsufficientStock, m.WarehouseID)
+		}
+	} else {
+		_, err := tx.ExecContext(ctx, "INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE quantity=quantity+VALUES(quantity), updated_at=VALUES(updated_at)", m.WarehouseID, m.ProductID, m.VariantID, m.Quantity, m.CreatedAt)
+		if err != nil {
+			return wrapErr("error updating stock level", err)
+		}
+	}
+	var err error
+	if m.VariantID != nil {
+		_, err = tx.ExecContext(ctx, "UPDATE product_variants SET count_in_stock=count_in_stock+? WHERE id=?", m.Quantity, *m.VariantID)
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE products SET count_in_stock=count_in_stock+? WHERE id=?", m.Quantity, m.ProductID)
+	}
+	if err != nil {
+		return fmt.Errorf("error updating stock: %w", err)
+	}
+	res, err := tx.NamedExecContext(ctx, "INSERT INTO inventory_movements (warehouse_id, product_id, variant_id, quantity, kind, reason, note, order_id, user_id, created_at) VALUES (:warehouse_id, :product_id, :variant_id, :quantity, :kind, :reason, :note, :order_id, :user_id, :created_at)", m)
+	if err != nil {
+		return wrapErr("error inserting inventory movement", err)
+	}
+	if m.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListInventoryMovements(t *testing.T) {
+	now := time.Now()
+	columns := []string{"id", "warehouse_id", "product_id", "variant_id", "quantity", "kind", "reason", "note", "order_id", "user_id", "created_at"}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		rows := sqlmock.NewRows(columns).
+			AddRow(2, 1, 9, nil, -2, MovementOrder, "", "", 11, 4, now).
+			AddRow(1, 1, 9, nil, 10, MovementAdjustment, AdjustmentReceived, "", nil, 1, now)
+		mock.ExpectQuery("SELECT * FROM inventory_movements WHERE product_id=? AND warehouse_id=? ORDER BY id DESC LIMIT ? OFFSET ?").WithArgs(9, 1, 20, 0).WillReturnRows(rows)
+		movements, err := st.ListInventoryMovements(context.Background(), MovementFilter{ProductID: 9, WarehouseID: 1, Limit: 20})
+		require.NoError(t, err)
+		require.Len(t, movements, 2)
+		require.Equal(t, MovementOrder, movements[0].Kind)
+		require.Equal(t, int64(11), *movements[0].OrderID)
+		require.Nil(t, movements[1].OrderID)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestCancelOrder(t *testing.T) {
+	now := time.Now()
+	cancelSQL := "UPDATE orders SET status=?, updated_at=? WHERE id=? AND status=?"
+	unredeemSQL := "UPDATE promotions p JOIN promotion_redemptions r ON r.promotion_id=p.id SET p.times_used=p.times_used-1 WHERE r.order_id=?"
+	order := func() *Order {
+		return &Order{
+			ID:        11,
+			UserID:    4,
+			Status:    OrderStatusPending,
+			UpdatedAt: &now,
+			Items: []OrderItem{
+				{ID: 5, Name: "product 9", Quantity: 3, ProductID: 9, Allocations: []OrderItemAllocation{{WarehouseID: 2, Quantity: 1, Status: AllocationReserved}, {WarehouseID: 1, Quantity: 2, Status: AllocationTaken}}},
+			},
+		}
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				o := order()
+				mock.ExpectBegin()
+				mock.ExpectExec(cancelSQL).WithArgs(OrderStatusCancelled, &now, 11, OrderStatusPending).WillReturnResult(sqlmock.NewResult(0, 1))
+				// the promotion the order redeemed can be used again
+				mock.ExpectExec(unredeemSQL).WithArgs(11).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM promotion_redemptions WHERE order_id=?").WithArgs(11).WillReturnResult(sqlmock.NewResult(0, 1))
+				// the reserved unit is released, the units taken before
+				// reservations go back to the warehouse they came from
+				rows := sqlmock.NewRows(reservationColumns).
+					AddRow(1, 11, 4, 2, 9, nil, 1, AllocationReserved).
+					AddRow(2, 11, 4, 1, 9, nil, 2, AllocationTaken)
+				mock.ExpectQuery(reservationsSQL+" WHERE a.order_id=? AND a.status<>? ORDER BY a.id FOR UPDATE").WithArgs(11, AllocationReleased).WillReturnRows(rows)
+				mock.ExpectExec(releaseStockSQL).WithArgs(1, now, 2, 9, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(putStockSQL).WithArgs(1, 9, nil, 2, now).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET count_in_stock=count_in_stock+? WHERE id=?").WithArgs(2, 9).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(movementSQL).WithArgs(1, 9, nil, 2, MovementCancellation, "", "", 11, 1, now).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE order_item_allocations SET status=? WHERE order_id=?").WithArgs(AllocationReleased, 11).WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+				err := st.CancelOrder(context.Background(), o, 1)
+				require.NoError(t, err)
+				require.Equal(t, OrderStatusCancelled, o.Status)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "fully backordered item",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				o := order()
+				o.Items = []OrderItem{{ID: 6, Name: "product 9", Quantity: 2, Backordered: 2, ProductID: 9}}
+				mock.ExpectBegin()
+				mock.ExpectExec(cancelSQL).WithArgs(OrderStatusCancelled, &now, 11, OrderStatusPending).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(unredeemSQL).WithArgs(11).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DELETE FROM promotion_redemptions WHERE order_id=?").WithArgs(11).WillReturnResult(sqlmock.NewResult(0, 0))
+				// nothing was taken from stock, so nothing goes back
+				mock.ExpectQuery(restockableSQL).WithArgs(0, RefundSucceeded, 6).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+				mock.ExpectQuery(reservationsSQL+" WHERE a.order_id=? AND a.status<>? ORDER BY a.id FOR UPDATE").WithArgs(11, AllocationReleased).WillReturnRows(sqlmock.NewRows(reservationColumns))
+				mock.ExpectExec("UPDATE order_item_allocations SET status=? WHERE order_id=?").WithArgs(AllocationReleased, 11).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				err := st.CancelOrder(context.Background(), o, 1)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "not_pending",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(cancelSQL).WithArgs(OrderStatusCancelled, &now, 11, OrderStatusPending).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				err := st.CancelOrder(context.Background(), order(), 1)
+				require.ErrorIs(t, err, ErrConflict)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (ms *MySQLStorer) CreatePayment(ctx context.Context, p *Payment) (*Payment, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO payments (order_id, provider, provider_ref, amount, currency, status, failure_reason, created_at) VALUES (:order_id, :provider, :provider_ref, :amount, :currency, :status, :failure_reason, :created_at)", p)
+	if err != nil {
+		return nil, wrapErr("error inserting payment", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	p.ID = id
+	return p, nil
+}
+
+func (ms *MySQLStorer) GetPayment(ctx context.Context, id int64) (*Payment, error) {
+	var p Payment
+	err := ms.db.GetContext(ctx, &p, "SELECT * FROM payments WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting payment", err)
+	}
+	return &p, nil
+}
+
+func (ms *MySQLStorer) GetPaymentByProviderRef(ctx context.Context, provider, ref string) (*Payment, error) {
+	var p Payment
+	err := ms.db.GetContext(ctx, &p, "SELECT * FROM payments WHERE provider=? AND provider_ref=?", provider, ref)
+	if err != nil {
+		return nil, wrapErr("error getting payment", err)
+	}
+	return &p, nil
+}
+
+// ListPayments lists the order's payment attempts, oldest first.
+func (ms *MySQLStorer) ListPayments(ctx context.Context, orderID int64) ([]Payment, error) {
+	var ps []Payment
+	err := ms.db.SelectContext(ctx, &ps, "SELECT * FROM payments WHERE order_id=? ORDER BY id", orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing payments: %w", err)
+	}
+	return ps, nil
+}
+
+// UpdatePayment records the payment's new state. When orderStatus is set the
+// payment's order moves to it in the same transaction, so an order is never
+// paid without a captured payment. A paid order's reserved stock is taken
+// out of the warehouses.
+func (ms *MySQLStorer) UpdatePayment(ctx context.Context, p *Payment, orderStatus string) (*Payment, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, "UPDATE payments SET provider_ref=:provider_ref, status=:status, failure_reason=:failure_reason, updated_at=:updated_at WHERE id=:id", p)
+		if err != nil {
+			return wrapErr("error updating payment", err)
+		}
+		if orderStatus == "" {
+			return nil
+		}
+		if orderStatus == OrderStatusPaid {
+			at := time.Now()
+			if p.UpdatedAt != nil {
+				at = *p.UpdatedAt
+			}
+			if err := takeReservations(ctx, tx, p.OrderID, at); err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE orders SET status=?, updated_at=? WHERE id=?", orderStatus, p.UpdatedAt, p.OrderID)
+		if err != nil {
+			return fmt.Errorf("error updating order status: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// CreatePaymentEvent records a webhook delivery. Providers identify events
+// uniquely, so a redelivered event fails with ErrConflict.
+func (ms *MySQLStorer) CreatePaymentEvent(ctx context.Context, e *PaymentEvent) (*PaymentEvent, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO payment_events (provider, event_id, type, payment_ref, payload, received_at) VALUES (:provider, :event_id, :type, :payment_ref, :payload, :received_at)", e)
+	if err != nil {
+		return nil, wrapErr("error inserting payment event", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	e.ID = id
+	return e, nil
+}
+
+func (ms *MySQLStorer) GetPaymentEvent(ctx context.Context, id int64) (*PaymentEvent, error) {
+	var e PaymentEvent
+	err := ms.db.GetContext(ctx, &e, "SELECT * FROM payment_events WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting payment event", err)
+	}
+	return &e, nil
+}
+
+func (ms *MySQLStorer) GetPaymentEventByEventID(ctx context.Context, provider, eventID string) (*PaymentEvent, error) {
+	var e PaymentEvent
+	err := ms.db.GetContext(ctx, &e, "SELECT * FROM payment_events WHERE provider=? AND event_id=?", provider, eventID)
+	if err != nil {
+		return nil, wrapErr("error getting payment event", err)
+	}
+	return &e, nil
+}
+
+// ListPaymentEvents lists the latest limit events, newest first.
+func (ms *MySQLStorer) ListPaymentEvents(ctx context.Context, limit int) ([]PaymentEvent, error) {
+	var es []PaymentEvent
+	err := ms.db.SelectContext(ctx, &es, "SELECT * FROM payment_events ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("error listing payment events: %w", err)
+	}
+	return es, nil
+}
+
+func (ms *MySQLStorer) UpdatePaymentEvent(ctx context.Context, e *PaymentEvent) (*PaymentEvent, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE payment_events SET error=:error, processed_at=:processed_at WHERE id=:id", e)
+	if err != nil {
+		return nil, wrapErr("error updating payment event", err)
+	}
+	return e, nil
+}
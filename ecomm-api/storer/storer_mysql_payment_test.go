@@ -0,0 +1,119 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatePayment(t *testing.T) {
+	now := time.Now()
+	p := &Payment{ID: 4, OrderID: 9, Provider: "fake", ProviderRef: "fake_1", Amount: 120, Status: PaymentCaptured, UpdatedAt: &now}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "moves the order",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE payments SET provider_ref=?, status=?, failure_reason=?, updated_at=? WHERE id=?").WithArgs("fake_1", PaymentCaptured, "", p.UpdatedAt, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+				// the reserved units are taken out of stock
+				mock.ExpectQuery(reservationsSQL+" WHERE a.order_id=? AND a.status=? ORDER BY a.id FOR UPDATE").WithArgs(9, AllocationReserved).WillReturnRows(sqlmock.NewRows(reservationColumns).AddRow(7, 9, 3, 1, 2, nil, 2, AllocationReserved))
+				mock.ExpectExec(releaseStockSQL).WithArgs(2, now, 1, 2, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(takeStockSQL).WithArgs(-2, now, 1, 2, nil, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE products SET count_in_stock=count_in_stock+? WHERE id=?").WithArgs(-2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(movementSQL).WithArgs(1, 2, nil, -2, MovementOrder, "", "", 9, 3, now).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE order_item_allocations SET status=? WHERE id=?").WithArgs(AllocationTaken, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE orders SET status=?, updated_at=? WHERE id=?").WithArgs(OrderStatusPaid, p.UpdatedAt, 9).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				_, err := st.UpdatePayment(context.Background(), p, OrderStatusPaid)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "leaves the order",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE payments SET provider_ref=?, status=?, failure_reason=?, updated_at=? WHERE id=?").WithArgs("fake_1", PaymentCaptured, "", p.UpdatedAt, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				_, err := st.UpdatePayment(context.Background(), p, "")
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestListPayments(t *testing.T) {
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		rows := sqlmock.NewRows([]string{"id", "order_id", "provider", "provider_ref", "amount", "status", "failure_reason", "created_at", "updated_at"}).
+			AddRow(1, 9, "fake", "", 120, PaymentFailed, "card declined", time.Now(), nil).
+			AddRow(2, 9, "fake", "fake_2", 120, PaymentCaptured, "", time.Now(), nil)
+		mock.ExpectQuery("SELECT * FROM payments WHERE order_id=? ORDER BY id").WithArgs(9).WillReturnRows(rows)
+		ps, err := st.ListPayments(context.Background(), 9)
+		require.NoError(t, err)
+		require.Len(t, ps, 2)
+		require.Equal(t, "card declined", ps[0].FailureReason)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestCreatePaymentEvent(t *testing.T) {
+	insert := "INSERT INTO payment_events (provider, event_id, type, payment_ref, payload, received_at) VALUES (?, ?, ?, ?, ?, ?)"
+	e := &PaymentEvent{Provider: "fake", EventID: "evt_1", Type: "payment.captured", PaymentRef: "fake_1", Payload: []byte(`{"id":"evt_1"}`), ReceivedAt: time.Now()}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec(insert).WithArgs("fake", "evt_1", "payment.captured", "fake_1", e.Payload, e.ReceivedAt).WillReturnResult(sqlmock.NewResult(5, 1))
+				created, err := st.CreatePaymentEvent(context.Background(), e)
+				require.NoError(t, err)
+				require.Equal(t, int64(5), created.ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "redelivered",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec(insert).WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+				_, err := st.CreatePaymentEvent(context.Background(), e)
+				require.True(t, errors.Is(err, ErrConflict))
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
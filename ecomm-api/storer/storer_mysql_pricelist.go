@@ -0,0 +1,111 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+)
+
+func (ms *MySQLStorer) CreateCustomerGroup(ctx context.Context, g *CustomerGroup) (*CustomerGroup, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO customer_groups (name, created_at) VALUES (:name, :created_at)", g)
+	if err != nil {
+		return nil, wrapErr("error inserting customer group", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	g.ID = id
+	return g, nil
+}
+
+func (ms *MySQLStorer) GetCustomerGroup(ctx context.Context, id int64) (*CustomerGroup, error) {
+	var g CustomerGroup
+	err := ms.db.GetContext(ctx, &g, "SELECT * FROM customer_groups WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting customer group", err)
+	}
+	return &g, nil
+}
+
+func (ms *MySQLStorer) ListCustomerGroups(ctx context.Context) ([]CustomerGroup, error) {
+	var groups []CustomerGroup
+	err := ms.db.SelectContext(ctx, &groups, "SELECT * FROM customer_groups ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error listing customer groups: %w", err)
+	}
+	return groups, nil
+}
+
+func (ms *MySQLStorer) UpdateCustomerGroup(ctx context.Context, g *CustomerGroup) (*CustomerGroup, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE customer_groups SET name=:name, updated_at=:updated_at WHERE id=:id", g)
+	if err != nil {
+		return nil, wrapErr("error updating customer group", err)
+	}
+	return g, nil
+}
+
+// DeleteCustomerGroup also deletes the group's price lists; its members
+// fall back to catalog prices.
+func (ms *MySQLStorer) DeleteCustomerGroup(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM customer_groups WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting customer group", err)
+	}
+	return nil
+}
+
+func (ms *MySQLStorer) CreatePriceList(ctx context.Context, pl *PriceList) (*PriceList, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO price_lists (customer_group_id, name, active, entries, created_at) VALUES (:customer_group_id, :name, :active, :entries, :created_at)", pl)
+	if err != nil {
+		return nil, wrapErr("error inserting price list", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	pl.ID = id
+	return pl, nil
+}
+
+func (ms *MySQLStorer) GetPriceList(ctx context.Context, id int64) (*PriceList, error) {
+	var pl PriceList
+	err := ms.db.GetContext(ctx, &pl, "SELECT * FROM price_lists WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting price list", err)
+	}
+	return &pl, nil
+}
+
+// ListPriceLists lists the price lists of a customer group, or every list
+// when groupID is 0.
+func (ms *MySQLStorer) ListPriceLists(ctx context.Context, groupID int64) ([]PriceList, error) {
+	var (
+		lists []PriceList
+		err   error
+	)
+	if groupID == 0 {
+		err = ms.db.SelectContext(ctx, &lists, "SELECT * FROM price_lists ORDER BY customer_group_id, id")
+	} else {
+		err = ms.db.SelectContext(ctx, &lists, "SELECT * FROM price_lists WHERE customer_group_id=? ORDER BY id", groupID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing price lists: %w", err)
+	}
+	return lists, nil
+}
+
+func (ms *MySQLStorer) UpdatePriceList(ctx context.Context, pl *PriceList) (*PriceList, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE price_lists SET customer_group_id=:customer_group_id, name=:name, active=:active, entries=:entries, updated_at=:updated_at WHERE id=:id", pl)
+	if err != nil {
+		return nil, wrapErr("error updating price list", err)
+	}
+	return pl, nil
+}
+
+func (ms *MySQLStorer) DeletePriceList(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM price_lists WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting price list", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePriceList(t *testing.T) {
+	productID, categoryID := int64(9), int64(4)
+	price := money.Amount(850)
+	pl := &PriceList{
+		CustomerGroupID: 2,
+		Name:            "Wholesale",
+		Active:          true,
+		Entries: PriceListEntries{
+			{ProductID: &productID, MinQuantity: 10, Price: &price},
+			{CategoryID: &categoryID, MinQuantity: 1, PercentOff: 15},
+		},
+		CreatedAt: time.Now(),
+	}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectExec("INSERT INTO price_lists (customer_group_id, name, active, entries, created_at) VALUES (?, ?, ?, ?, ?)").WithArgs(int64(2), "Wholesale", true, `[{"product_id":9,"min_quantity":10,"price":"8.50"},{"category_id":4,"min_quantity":1,"percent_off":15}]`, pl.CreatedAt).WillReturnResult(sqlmock.NewResult(5, 1))
+		created, err := st.CreatePriceList(context.Background(), pl)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), created.ID)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestListPriceLists(t *testing.T) {
+	columns := []string{"id", "customer_group_id", "name", "active", "entries", "created_at", "updated_at"}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		rows := sqlmock.NewRows(columns).
+			AddRow(5, 2, "Wholesale", true, `[{"product_id":9,"min_quantity":10,"price":"8.50"}]`, time.Now(), nil)
+		mock.ExpectQuery("SELECT * FROM price_lists WHERE customer_group_id=? ORDER BY id").WithArgs(2).WillReturnRows(rows)
+		lists, err := st.ListPriceLists(context.Background(), 2)
+		require.NoError(t, err)
+		require.Len(t, lists, 1)
+		require.Equal(t, money.Amount(850), *lists[0].Entries[0].Price)
+		require.Equal(t, int64(10), lists[0].Entries[0].MinQuantity)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestSetUserCustomerGroup(t *testing.T) {
+	groupID := int64(2)
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectExec("UPDATE users SET customer_group_id=? WHERE id=?").WithArgs(&groupID, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+		err := st.SetUserCustomerGroup(context.Background(), 7, &groupID)
+		require.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
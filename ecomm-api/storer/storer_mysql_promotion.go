@@ -0,0 +1,132 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (ms *MySQLStorer) CreatePromotion(ctx context.Context, p *Promotion) (*Promotion, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO promotions (code, name, kind, percent, amount, buy_quantity, get_quantity, min_order_value, scope, usage_limit, per_user_limit, stackable, active, starts_at, ends_at, created_at) VALUES (:code, :name, :kind, :percent, :amount, :buy_quantity, :get_quantity, :min_order_value, :scope, :usage_limit, :per_user_limit, :stackable, :active, :starts_at, :ends_at, :created_at)", p)
+	if err != nil {
+		return nil, wrapErr("error inserting promotion", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	p.ID = id
+	return p, nil
+}
+
+func (ms *MySQLStorer) GetPromotion(ctx context.Context, id int64) (*Promotion, error) {
+	var p Promotion
+	err := ms.db.GetContext(ctx, &p, "SELECT * FROM promotions WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting promotion", err)
+	}
+	return &p, nil
+}
+
+func (ms *MySQLStorer) GetPromotionByCode(ctx context.Context, code string) (*Promotion, error) {
+	var p Promotion
+	err := ms.db.GetContext(ctx, &p, "SELECT * FROM promotions WHERE code=?", code)
+	if err != nil {
+		return nil, wrapErr("error getting promotion", err)
+	}
+	return &p, nil
+}
+
+func (ms *MySQLStorer) ListPromotions(ctx context.Context) ([]Promotion, error) {
+	var ps []Promotion
+	err := ms.db.SelectContext(ctx, &ps, "SELECT * FROM promotions ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error listing promotions: %w", err)
+	}
+	return ps, nil
+}
+
+// ListAutomaticPromotions returns the active code-less promotions whose
+// validity window contains t.
+func (ms *MySQLStorer) ListAutomaticPromotions(ctx context.Context, t time.Time) ([]Promotion, error) {
+	var ps []Promotion
+	err := ms.db.SelectContext(ctx, &ps, "SELECT * FROM promotions WHERE code IS NULL AND active AND (starts_at IS NULL OR starts_at<=?) AND (ends_at IS NULL OR ends_at>?) ORDER BY id", t, t)
+	if err != nil {
+		return nil, fmt.Errorf("error listing automatic promotions: %w", err)
+	}
+	return ps, nil
+}
+
+func (ms *MySQLStorer) UpdatePromotion(ctx context.Context, p *Promotion) (*Promotion, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE promotions SET code=:code, name=:name, kind=:kind, percent=:percent, amount=:amount, buy_quantity=:buy_quantity, get_quantity=:get_quantity, min_order_value=:min_order_value, scope=:scope, usage_limit=:usage_limit, per_user_limit=:per_user_limit, stackable=:stackable, active=:active, starts_at=:starts_at, ends_at=:ends_at, updated_at=:updated_at WHERE id=:id", p)
+	if err != nil {
+		return nil, wrapErr("error updating promotion", err)
+	}
+	return p, nil
+}
+
+func (ms *MySQLStorer) DeletePromotion(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM promotions WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting promotion", err)
+	}
+	return nil
+}
+
+// CountPromotionRedemptions reports how many orders of the user redeemed
+// the promotion.
+func (ms *MySQLStorer) CountPromotionRedemptions(ctx context.Context, promotionID, userID int64) (int64, error) {
+	var n int64
+	err := ms.db.GetContext(ctx, &n, "SELECT COUNT(*) FROM promotion_redemptions WHERE promotion_id=? AND user_id=?", promotionID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("error counting promotion redemptions: %w", err)
+	}
+	return n, nil
+}
+
+// redeemPromotion records the order's use of a promotion. The conditional
+// update enforces the global usage limit even under concurrent orders, and
+// the promotion row it locks makes the user's redemptions count check hold
+// for the per-user limit too.
+func (ms *MySQLStorer) redeemPromotion(ctx context.Context, tx *sqlx.Tx, promotionID int64, o *Order) error {
+	res, err := tx.ExecContext(ctx, "UPDATE promotions SET times_used=times_used+1 WHERE id=? AND (usage_limit IS NULL OR times_used<usage_limit)", promotionID)
+	if err != nil {
+		return fmt.Errorf("error updating promotion usage: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("promotion %d: %w", promotionID, ErrUsageLimitReached)
+	}
+	var used bool
+	err = tx.GetContext(ctx, &used, "SELECT p.per_user_limit IS NOT NULL AND (SELECT COUNT(*) FROM promotion_redemptions r WHERE r.promotion_id=p.id AND r.user_id=?)>=p.per_user_limit FROM promotions p WHERE p.id=?", o.UserID, promotionID)
+	if err != nil {
+		return fmt.Errorf("error counting promotion redemptions: %w", err)
+	}
+	if used {
+		return fmt.Errorf("promotion %d for user %d: %w", promotionID, o.UserID, ErrUsageLimitReached)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO promotion_redemptions (promotion_id, user_id, order_id, created_at) VALUES (?, ?, ?, ?)", promotionID, o.UserID, o.ID, o.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting promotion redemption: %w", err)
+	}
+	return nil
+}
+
+// unredeemPromotions gives back the uses of promotions a cancelled order
+// made, so they count against neither the global nor the per-user limit.
+func unredeemPromotions(ctx context.Context, tx *sqlx.Tx, orderID int64) error {
+	_, err := tx.ExecContext(ctx, "UPDATE promotions p JOIN promotion_redemptions r ON r.promotion_id=p.id SET p.times_used=p.times_used-1 WHERE r.order_id=?", orderID)
+	if err != nil {
+		return fmt.Errorf("error updating promotion usage: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM promotion_redemptions WHERE order_id=?", orderID)
+	if err != nil {
+		return fmt.Errorf("error deleting promotion redemptions: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrderRedeemsPromotion(t *testing.T) {
+	promotionID := int64(3)
+	code := "SAVE10"
+	now := time.Now()
+	newOrder := func() *Order {
+		return &Order{
+			UserID:        4,
+			Status:        OrderStatusPending,
+			DiscountPrice: 200,
+			TotalPrice:    1800,
+			CreatedAt:     now,
+			Items:         []OrderItem{{Name: "product 9", Quantity: 2, Price: 1000, ProductID: 9, Allocations: []OrderItemAllocation{{WarehouseID: 1, Quantity: 2}}}},
+			Discounts:     []OrderDiscount{{PromotionID: &promotionID, Code: &code, Description: "10% off", Amount: 200}},
+		}
+	}
+	expectOrder := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(11, 1))
+		mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(reserveStockSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(allocationSQL).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO order_discounts (order_id, promotion_id, code, description, amount, shipping) VALUES (?, ?, ?, ?, ?, ?)").WithArgs(int64(11), &promotionID, &code, "10% off", money.Amount(200), false).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	redeem := "UPDATE promotions SET times_used=times_used+1 WHERE id=? AND (usage_limit IS NULL OR times_used<usage_limit)"
+	perUser := "SELECT p.per_user_limit IS NOT NULL AND (SELECT COUNT(*) FROM promotion_redemptions r WHERE r.promotion_id=p.id AND r.user_id=?)>=p.per_user_limit FROM promotions p WHERE p.id=?"
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				expectOrder(mock)
+				mock.ExpectExec(redeem).WithArgs(promotionID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(perUser).WithArgs(int64(4), promotionID).WillReturnRows(sqlmock.NewRows([]string{"used"}).AddRow(false))
+				mock.ExpectExec("INSERT INTO promotion_redemptions (promotion_id, user_id, order_id, created_at) VALUES (?, ?, ?, ?)").WithArgs(promotionID, int64(4), int64(11), now).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+				o, err := st.CreateOrder(context.Background(), newOrder())
+				require.NoError(t, err)
+				require.Equal(t, int64(11), o.Discounts[0].OrderID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "usage limit reached",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				expectOrder(mock)
+				mock.ExpectExec(redeem).WithArgs(promotionID).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				_, err := st.CreateOrder(context.Background(), newOrder())
+				require.ErrorIs(t, err, ErrUsageLimitReached)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "per-user limit reached",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				expectOrder(mock)
+				mock.ExpectExec(redeem).WithArgs(promotionID).WillReturnResult(sqlmock.NewResult(0, 1))
+				// another checkout by the same user got there first
+				mock.ExpectQuery(perUser).WithArgs(int64(4), promotionID).WillReturnRows(sqlmock.NewRows([]string{"used"}).AddRow(true))
+				mock.ExpectRollback()
+				_, err := st.CreateOrder(context.Background(), newOrder())
+				require.ErrorIs(t, err, ErrUsageLimitReached)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestListAutomaticPromotions(t *testing.T) {
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		now := time.Now()
+		rows := sqlmock.NewRows([]string{"id", "code", "name", "kind", "percent", "scope", "active", "created_at"}).
+			AddRow(1, nil, "winter sale", PromotionPercentage, 15.0, `{"category_ids":[2]}`, true, now)
+		mock.ExpectQuery("SELECT * FROM promotions WHERE code IS NULL AND active AND (starts_at IS NULL OR starts_at<=?) AND (ends_at IS NULL OR ends_at>?) ORDER BY id").WithArgs(now, now).WillReturnRows(rows)
+		ps, err := st.ListAutomaticPromotions(context.Background(), now)
+		require.NoError(t, err)
+		require.Len(t, ps, 1)
+		require.Equal(t, []int64{2}, ps[0].Scope.CategoryIDs)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,184 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/m21power/ecomm/ecomm-api/money"
+)
+
+// CreateRefund records a pending refund and its items. The payment row is
+// locked while the refund is checked against what was captured and what
+// earlier refunds, other than failed ones, already gave back, so concurrent
+// refunds cannot together exceed the payment.
+func (ms *MySQLStorer) CreateRefund(ctx context.Context, r *Refund) (*Refund, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		var captured money.Amount
+		err := tx.GetContext(ctx, &captured, "SELECT amount FROM payments WHERE id=? AND status=? FOR UPDATE", r.PaymentID, PaymentCaptured)
+		if err != nil {
+			return wrapErr("error getting captured payment", err)
+		}
+		refunded, err := refundedAmount(ctx, tx, r.PaymentID)
+		if err != nil {
+			return err
+		}
+		if refunded+r.Amount > captured {
+			return fmt.Errorf("%w: %s of the %s captured is already refunded", ErrOverRefund, refunded, captured)
+		}
+		for _, ri := range r.Items {
+			var ordered, returned int64
+			err := tx.GetContext(ctx, &ordered, "SELECT quantity FROM order_items WHERE id=? AND order_id=?", ri.OrderItemID, r.OrderID)
+			if err != nil {
+				return wrapErr("error getting order item", err)
+			}
+			err = tx.GetContext(ctx, &returned, "SELECT COALESCE(SUM(ri.quantity), 0) FROM refund_items ri JOIN refunds r ON r.id=ri.refund_id WHERE ri.order_item_id=? AND r.status<>?", ri.OrderItemID, RefundFailed)
+			if err != nil {
+				return fmt.Errorf("error getting refunded quantity: %w", err)
+			}
+			if returned+ri.Quantity > ordered {
+				return fmt.Errorf("%w: %d of the %d units of order item %d are already refunded", ErrOverRefund, returned, ordered, ri.OrderItemID)
+			}
+		}
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO refunds (order_id, payment_id, amount, reason, restock, status, created_at) VALUES (:order_id, :payment_id, :amount, :reason, :restock, :status, :created_at)", r)
+		if err != nil {
+			return wrapErr("error inserting refund", err)
+		}
+		if r.ID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		for i := range r.Items {
+			ri := &r.Items[i]
+			ri.RefundID = r.ID
+			res, err := tx.NamedExecContext(ctx, "INSERT INTO refund_items (refund_id, order_item_id, product_id, variant_id, quantity) VALUES (:refund_id, :order_item_id, :product_id, :variant_id, :quantity)", ri)
+			if err != nil {
+				return wrapErr("error inserting refund item", err)
+			}
+			if ri.ID, err = res.LastInsertId(); err != nil {
+				return fmt.Errorf("error getting last insert ID: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ListRefunds lists the order's refunds with their items, oldest first.
+func (ms *MySQLStorer) ListRefunds(ctx context.Context, orderID int64) ([]Refund, error) {
+	var rs []Refund
+	err := ms.db.SelectContext(ctx, &rs, "SELECT * FROM refunds WHERE order_id=? ORDER BY id", orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing refunds: %w", err)
+	}
+	var items []RefundItem
+	err = ms.db.SelectContext(ctx, &items, "SELECT ri.* FROM refund_items ri JOIN refunds r ON r.id=ri.refund_id WHERE r.order_id=? ORDER BY ri.id", orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing refund items: %w", err)
+	}
+	for _, ri := range items {
+		for i := range rs {
+			if rs[i].ID == ri.RefundID {
+				rs[i].Items = append(rs[i].Items, ri)
+			}
+		}
+	}
+	return rs, nil
+}
+
+// UpdateRefund records the refund's outcome. Once it has succeeded its
+// items are restocked if asked for and the order becomes refunded or
+// partially refunded, in the same transaction. Only units that were taken
+// out of stock are restocked; backordered ones never were.
+func (ms *MySQLStorer) UpdateRefund(ctx context.Context, r *Refund) (*Refund, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, "UPDATE refunds SET status=:status, provider_ref=:provider_ref, failure_reason=:failure_reason, updated_at=:updated_at WHERE id=:id", r)
+		if err != nil {
+			return wrapErr("error updating refund", err)
+		}
+		if r.Status != RefundSucceeded {
+			return nil
+		}
+		if r.Restock {
+			at := time.Now()
+			if r.UpdatedAt != nil {
+				at = *r.UpdatedAt
+			}
+			for _, ri := range r.Items {
+				restockable, err := restockableUnits(ctx, tx, r.ID, ri.OrderItemID)
+				if err != nil {
+					return err
+				}
+				quantity := min(ri.Quantity, restockable)
+				if quantity <= 0 {
+					continue
+				}
+				warehouseID, err := restockWarehouse(ctx, tx, ri.OrderItemID)
+				if err != nil {
+					return err
+				}
+				err = addStock(ctx, tx, &InventoryMovement{
+					WarehouseID: warehouseID,
+					ProductID:   ri.ProductID,
+					VariantID:   ri.VariantID,
+					Quantity:    quantity,
+					Kind:        MovementReturn,
+					Note:        fmt.Sprintf("refund %d", r.ID),
+					OrderID:     &r.OrderID,
+					CreatedAt:   at,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		var captured, refunded money.Amount
+		err = tx.GetContext(ctx, &captured, "SELECT amount FROM payments WHERE id=?", r.PaymentID)
+		if err != nil {
+			return wrapErr("error getting payment", err)
+		}
+		err = tx.GetContext(ctx, &refunded, "SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id=? AND status=?", r.PaymentID, RefundSucceeded)
+		if err != nil {
+			return fmt.Errorf("error getting refunded amount: %w", err)
+		}
+		status := OrderStatusPartiallyRefunded
+		if refunded >= captured {
+			status = OrderStatusRefunded
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE orders SET status=?, updated_at=? WHERE id=?", status, r.UpdatedAt, r.OrderID)
+		if err != nil {
+			return fmt.Errorf("error updating order status: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// refundedAmount sums the payment's refunds that have not failed.
+func refundedAmount(ctx context.Context, tx *sqlx.Tx, paymentID int64) (money.Amount, error) {
+	var refunded money.Amount
+	err := tx.GetContext(ctx, &refunded, "SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id=? AND status<>?", paymentID, RefundFailed)
+	if err != nil {
+		return 0, fmt.Errorf("error getting refunded amount: %w", err)
+	}
+	return refunded, nil
+}
+
+// restockableUnits is how many units of the order item can still go back to
+// stock: those that were taken out of it, less what other succeeded
+// restocking refunds than refundID put back already. Pass a zero refundID
+// to count every such refund.
+func restockableUnits(ctx context.Context, tx *sqlx.Tx, refundID, orderItemID int64) (int64, error) {
+	var n int64
+	err := tx.GetContext(ctx, &n, "SELECT oi.quantity-oi.backordered-COALESCE((SELECT SUM(ri.quantity) FROM refund_items ri JOIN refunds r ON r.id=ri.refund_id WHERE ri.order_item_id=oi.id AND r.id<>? AND r.restock AND r.status=?), 0) FROM order_items oi WHERE oi.id=?", refundID, RefundSucceeded, orderItemID)
+	if err != nil {
+		return 0, wrapErr("error getting restockable units", err)
+	}
+	return n, nil
+}
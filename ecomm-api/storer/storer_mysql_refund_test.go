@@ -0,0 +1,173 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRefund(t *testing.T) {
+	newRefund := func() *Refund {
+		return &Refund{
+			OrderID:   9,
+			PaymentID: 4,
+			Amount:    3000,
+			Restock:   true,
+			Status:    RefundPending,
+			CreatedAt: time.Now(),
+			Items:     []RefundItem{{OrderItemID: 11, ProductID: 1, Quantity: 1}},
+		}
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				r := newRefund()
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT amount FROM payments WHERE id=? AND status=? FOR UPDATE").WithArgs(4, PaymentCaptured).WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow("100.00"))
+				mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id=? AND status<>?").WithArgs(4, RefundFailed).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("70.00"))
+				mock.ExpectQuery("SELECT quantity FROM order_items WHERE id=? AND order_id=?").WithArgs(11, 9).WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(2))
+				mock.ExpectQuery("SELECT COALESCE(SUM(ri.quantity), 0) FROM refund_items ri JOIN refunds r ON r.id=ri.refund_id WHERE ri.order_item_id=? AND r.status<>?").WithArgs(11, RefundFailed).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(1))
+				mock.ExpectExec("INSERT INTO refunds (order_id, payment_id, amount, reason, restock, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)").WithArgs(9, 4, money.Amount(3000), "", true, RefundPending, r.CreatedAt).WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec("INSERT INTO refund_items (refund_id, order_item_id, product_id, variant_id, quantity) VALUES (?, ?, ?, ?, ?)").WithArgs(2, 11, 1, nil, 1).WillReturnResult(sqlmock.NewResult(3, 1))
+				mock.ExpectCommit()
+				created, err := st.CreateRefund(context.Background(), r)
+				require.NoError(t, err)
+				require.Equal(t, int64(2), created.ID)
+				require.Equal(t, int64(3), created.Items[0].ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "exceeds captured amount",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT amount FROM payments WHERE id=? AND status=? FOR UPDATE").WithArgs(4, PaymentCaptured).WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow("100.00"))
+				mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id=? AND status<>?").WithArgs(4, RefundFailed).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("80.00"))
+				mock.ExpectRollback()
+				_, err := st.CreateRefund(context.Background(), newRefund())
+				require.True(t, errors.Is(err, ErrOverRefund))
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "exceeds ordered quantity",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT amount FROM payments WHERE id=? AND status=? FOR UPDATE").WithArgs(4, PaymentCaptured).WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow("100.00"))
+				mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id=? AND status<>?").WithArgs(4, RefundFailed).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(0))
+				mock.ExpectQuery("SELECT quantity FROM order_items WHERE id=? AND order_id=?").WithArgs(11, 9).WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(2))
+				mock.ExpectQuery("SELECT COALESCE(SUM(ri.quantity), 0) FROM refund_items ri JOIN refunds r ON r.id=ri.refund_id WHERE ri.order_item_id=? AND r.status<>?").WithArgs(11, RefundFailed).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(2))
+				mock.ExpectRollback()
+				_, err := st.CreateRefund(context.Background(), newRefund())
+				require.True(t, errors.Is(err, ErrOverRefund))
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+const restockableSQL = "SELECT oi.quantity-oi.backordered-COALESCE((SELECT SUM(ri.quantity) FROM refund_items ri JOIN refunds r ON r.id=ri.refund_id WHERE ri.order_item_id=oi.id AND r.id<>? AND r.restock AND r.status=?), 0) FROM order_items oi WHERE oi.id=?"
+
+func TestUpdateRefund(t *testing.T) {
+	variantID := int64(6)
+	now := time.Now()
+	r := &Refund{
+		ID:        2,
+		OrderID:   9,
+		PaymentID: 4,
+		Amount:    3000,
+		Restock:   true,
+		Status:    RefundSucceeded,
+		UpdatedAt: &now,
+		Items: []RefundItem{
+			{OrderItemID: 11, ProductID: 1, Quantity: 1},
+			{OrderItemID: 12, ProductID: 2, VariantID: &variantID, Quantity: 2},
+		},
+	}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE refunds SET status=?, provider_ref=?, failure_reason=?, updated_at=? WHERE id=?").WithArgs(RefundSucceeded, "", "", r.UpdatedAt, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		// the first item goes back to the warehouse that shipped it
+		mock.ExpectQuery(restockableSQL).WithArgs(2, RefundSucceeded, 11).WillReturnRows(sqlmock.NewRows([]string{"restockable"}).AddRow(1))
+		mock.ExpectQuery("SELECT warehouse_id FROM order_item_allocations WHERE order_item_id=? ORDER BY id LIMIT 1").WithArgs(11).WillReturnRows(sqlmock.NewRows([]string{"warehouse_id"}).AddRow(3))
+		mock.ExpectExec("INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE quantity=quantity+VALUES(quantity), updated_at=VALUES(updated_at)").WithArgs(3, 1, nil, 1, now).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE products SET count_in_stock=count_in_stock+? WHERE id=?").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(movementSQL).WithArgs(3, 1, nil, 1, MovementReturn, "", "refund 2", 9, nil, now).WillReturnResult(sqlmock.NewResult(1, 1))
+		// the second predates warehouses and goes to the first one
+		mock.ExpectQuery(restockableSQL).WithArgs(2, RefundSucceeded, 12).WillReturnRows(sqlmock.NewRows([]string{"restockable"}).AddRow(2))
+		mock.ExpectQuery("SELECT warehouse_id FROM order_item_allocations WHERE order_item_id=? ORDER BY id LIMIT 1").WithArgs(12).WillReturnRows(sqlmock.NewRows([]string{"warehouse_id"}))
+		mock.ExpectQuery("SELECT id FROM warehouses ORDER BY priority, id LIMIT 1").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec("INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE quantity=quantity+VALUES(quantity), updated_at=VALUES(updated_at)").WithArgs(1, 2, variantID, 2, now).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE product_variants SET count_in_stock=count_in_stock+? WHERE id=?").WithArgs(2, 6).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(movementSQL).WithArgs(1, 2, variantID, 2, MovementReturn, "", "refund 2", 9, nil, now).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectQuery("SELECT amount FROM payments WHERE id=?").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow("100.00"))
+		mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id=? AND status=?").WithArgs(4, RefundSucceeded).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("30.00"))
+		mock.ExpectExec("UPDATE orders SET status=?, updated_at=? WHERE id=?").WithArgs(OrderStatusPartiallyRefunded, r.UpdatedAt, 9).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		_, err := st.UpdateRefund(context.Background(), r)
+		require.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestUpdateRefundSkipsBackorderedUnits(t *testing.T) {
+	now := time.Now()
+	// item 11 was ordered as three units, two of them backordered; item 12
+	// was entirely backordered
+	r := &Refund{
+		ID:        2,
+		OrderID:   9,
+		PaymentID: 4,
+		Amount:    5000,
+		Restock:   true,
+		Status:    RefundSucceeded,
+		UpdatedAt: &now,
+		Items: []RefundItem{
+			{OrderItemID: 11, ProductID: 1, Quantity: 3},
+			{OrderItemID: 12, ProductID: 2, Quantity: 2},
+		},
+	}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE refunds SET status=?, provider_ref=?, failure_reason=?, updated_at=? WHERE id=?").WithArgs(RefundSucceeded, "", "", r.UpdatedAt, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		// only the unit taken from stock goes back
+		mock.ExpectQuery(restockableSQL).WithArgs(2, RefundSucceeded, 11).WillReturnRows(sqlmock.NewRows([]string{"restockable"}).AddRow(1))
+		mock.ExpectQuery("SELECT warehouse_id FROM order_item_allocations WHERE order_item_id=? ORDER BY id LIMIT 1").WithArgs(11).WillReturnRows(sqlmock.NewRows([]string{"warehouse_id"}).AddRow(3))
+		mock.ExpectExec(putStockSQL).WithArgs(3, 1, nil, 1, now).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE products SET count_in_stock=count_in_stock+? WHERE id=?").WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(movementSQL).WithArgs(3, 1, nil, 1, MovementReturn, "", "refund 2", 9, nil, now).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(restockableSQL).WithArgs(2, RefundSucceeded, 12).WillReturnRows(sqlmock.NewRows([]string{"restockable"}).AddRow(0))
+		mock.ExpectQuery("SELECT amount FROM payments WHERE id=?").WithArgs(4).WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow("100.00"))
+		mock.ExpectQuery("SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id=? AND status=?").WithArgs(4, RefundSucceeded).WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("50.00"))
+		mock.ExpectExec("UPDATE orders SET status=?, updated_at=? WHERE id=?").WithArgs(OrderStatusPartiallyRefunded, r.UpdatedAt, 9).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		_, err := st.UpdateRefund(context.Background(), r)
+		require.NoError(t, err)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
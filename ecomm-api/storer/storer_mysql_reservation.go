@@ -0,0 +1,176 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// reservation is an order item allocation with the stock it holds.
+type reservation struct {
+	ID          int64  `db:"id"`
+	OrderID     int64  `db:"order_id"`
+	UserID      int64  `db:"user_id"`
+	WarehouseID int64  `db:"warehouse_id"`
+	ProductID   int64  `db:"product_id"`
+	VariantID   *int64 `db:"variant_id"`
+	Quantity    int64  `db:"quantity"`
+	Status      string `db:"status"`
+}
+
+// lockReservations selects the allocations matching where for update, so
+// that the sweeper, payments and cancellations never release or take the
+// same units twice.
+func lockReservations(ctx context.Context, tx *sqlx.Tx, where string, args ...interface{}) ([]reservation, error) {
+	var rs []reservation
+	err := tx.SelectContext(ctx, &rs, "SELECT a.id, a.order_id, o.user_id, a.warehouse_id, oi.product_id, oi.variant_id, a.quantity, a.status FROM order_item_allocations a JOIN order_items oi ON oi.id=a.order_item_id JOIN orders o ON o.id=a.order_id WHERE "+where+" ORDER BY a.id FOR UPDATE", args...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reservations: %w", err)
+	}
+	return rs, nil
+}
+
+// RenewReservations holds the order's allocated units until expiresAt,
+// reserving again those whose reservation was released. When they are no
+// longer available it fails with ErrInsufficientStock. Units already taken
+// are left alone.
+func (ms *MySQLStorer) RenewReservations(ctx context.Context, orderID int64, expiresAt time.Time) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		rs, err := lockReservations(ctx, tx, "a.order_id=? AND a.status=?", orderID, AllocationReleased)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, r := range rs {
+			if err := reserveStock(ctx, tx, &r, now); err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE order_item_allocations SET status=?, expires_at=? WHERE order_id=? AND status<>?", AllocationReserved, expiresAt, orderID, AllocationTaken)
+		if err != nil {
+			return fmt.Errorf("error renewing reservations: %w", err)
+		}
+		return nil
+	})
+}
+
+// ReleaseReservations gives the order's reserved units back to the
+// warehouses they were held at.
+func (ms *MySQLStorer) ReleaseReservations(ctx context.Context, orderID int64, t time.Time) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		rs, err := lockReservations(ctx, tx, "a.order_id=? AND a.status=?", orderID, AllocationReserved)
+		if err != nil {
+			return err
+		}
+		return releaseReservations(ctx, tx, rs, t)
+	})
+}
+
+// ReleaseExpiredReservations releases the reservations that expired before
+// t and returns how many it released. Orders with a payment under way keep
+// theirs until the payment succeeds or fails.
+func (ms *MySQLStorer) ReleaseExpiredReservations(ctx context.Context, t time.Time) (int64, error) {
+	var n int64
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		rs, err := lockReservations(ctx, tx, "a.status=? AND a.expires_at<? AND NOT EXISTS (SELECT 1 FROM payments p WHERE p.order_id=a.order_id AND p.status IN (?, ?))", AllocationReserved, t, PaymentPending, PaymentAuthorized)
+		if err != nil {
+			return err
+		}
+		n = int64(len(rs))
+		return releaseReservations(ctx, tx, rs, t)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func releaseReservations(ctx context.Context, tx *sqlx.Tx, rs []reservation, t time.Time) error {
+	for _, r := range rs {
+		if err := releaseStock(ctx, tx, &r, t); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE order_item_allocations SET status=? WHERE id=?", AllocationReleased, r.ID)
+		if err != nil {
+			return fmt.Errorf("error releasing reservation: %w", err)
+		}
+	}
+	return nil
+}
+
+// takeReservations turns the order's reservations into order movements
+// that take the units out of stock, once the order is paid.
+func takeReservations(ctx context.Context, tx *sqlx.Tx, orderID int64, t time.Time) error {
+	rs, err := lockReservations(ctx, tx, "a.order_id=? AND a.status=?", orderID, AllocationReserved)
+	if err != nil {
+		return err
+	}
+	for _, r := range rs {
+		if err := releaseStock(ctx, tx, &r, t); err != nil {
+			return err
+		}
+		err := addStock(ctx, tx, &InventoryMovement{
+			WarehouseID: r.WarehouseID,
+			ProductID:   r.ProductID,
+			VariantID:   r.VariantID,
+			Quantity:    -r.Quantity,
+			Kind:        MovementOrder,
+			OrderID:     &r.OrderID,
+			UserID:      &r.UserID,
+			CreatedAt:   t,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE order_item_allocations SET status=? WHERE id=?", AllocationTaken, r.ID)
+		if err != nil {
+			return fmt.Errorf("error taking reservation: %w", err)
+		}
+	}
+	return nil
+}
+
+// reserveStock holds r's units at its warehouse. The conditional update
+// makes the check and the hold atomic, so concurrent checkouts cannot
+// reserve the same units; when fewer are available it fails with
+// ErrInsufficientStock.
+func reserveStock(ctx context.Context, tx *sqlx.Tx, r *reservation, t time.Time) error {
+	res, err := tx.ExecContext(ctx, "UPDATE stock_levels SET reserved=reserved+?, updated_at=? WHERE warehouse_id=? AND product_id=? AND variant_id<=>? AND quantity-reserved>=?", r.Quantity, t, r.WarehouseID, r.ProductID, r.VariantID, r.Quantity)
+	if err != nil {
+		return fmt.Errorf("error reserving stock: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w at warehouse %d", ErrInsufficientStock, r.WarehouseID)
+	}
+	return addReserved(ctx, tx, r, r.Quantity)
+}
+
+// releaseStock gives up the hold on r's units.
+func releaseStock(ctx context.Context, tx *sqlx.Tx, r *reservation, t time.Time) error {
+	_, err := tx.ExecContext(ctx, "UPDATE stock_levels SET reserved=reserved-?, updated_at=? WHERE warehouse_id=? AND product_id=? AND variant_id<=>?", r.Quantity, t, r.WarehouseID, r.ProductID, r.VariantID)
+	if err != nil {
+		return fmt.Errorf("error releasing stock: %w", err)
+	}
+	return addReserved(ctx, tx, r, -r.Quantity)
+}
+
+// addReserved keeps the product's or variant's reserved total in step with
+// its stock levels, as addStock does for count_in_stock.
+func addReserved(ctx context.Context, tx *sqlx.Tx, r *reservation, quantity int64) error {
+	var err error
+	if r.VariantID != nil {
+		_, err = tx.ExecContext(ctx, "UPDATE product_variants SET reserved=reserved+? WHERE id=?", quantity, *r.VariantID)
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE products SET reserved=reserved+? WHERE id=?", quantity, r.ProductID)
+	}
+	if err != nil {
+		return fmt.Errorf("error updating reserved stock: %w", err)
+	}
+	return nil
+}
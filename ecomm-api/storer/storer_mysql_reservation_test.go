@@ -0,0 +1,95 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	reservationsSQL = "SELECT a.id, a.order_id, o.user_id, a.warehouse_id, oi.product_id, oi.variant_id, a.quantity, a.status FROM order_item_allocations a JOIN order_items oi ON oi.id=a.order_item_id JOIN orders o ON o.id=a.order_id"
+	releaseStockSQL = "UPDATE stock_levels SET reserved=reserved-?, updated_at=? WHERE warehouse_id=? AND product_id=? AND variant_id<=>?"
+	// the product's or variant's reserved total follows its stock levels
+	productReservedSQL = "UPDATE products SET reserved=reserved+? WHERE id=?"
+	variantReservedSQL = "UPDATE product_variants SET reserved=reserved+? WHERE id=?"
+)
+
+var reservationColumns = []string{"id", "order_id", "user_id", "warehouse_id", "product_id", "variant_id", "quantity", "status"}
+
+func TestRenewReservations(t *testing.T) {
+	expiresAt := time.Now().Add(15 * time.Minute)
+	renewSQL := "UPDATE order_item_allocations SET status=?, expires_at=? WHERE order_id=? AND status<>?"
+	released := func() *sqlmock.Rows {
+		return sqlmock.NewRows(reservationColumns).AddRow(7, 9, 3, 1, 2, nil, 2, AllocationReleased)
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "reserves released units again",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(reservationsSQL+" WHERE a.order_id=? AND a.status=? ORDER BY a.id FOR UPDATE").WithArgs(9, AllocationReleased).WillReturnRows(released())
+				mock.ExpectExec(reserveStockSQL).WithArgs(2, sqlmock.AnyArg(), 1, 2, nil, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(productReservedSQL).WithArgs(2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(renewSQL).WithArgs(AllocationReserved, expiresAt, 9, AllocationTaken).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				err := st.RenewReservations(context.Background(), 9, expiresAt)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "sold in the meantime",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(reservationsSQL+" WHERE a.order_id=? AND a.status=? ORDER BY a.id FOR UPDATE").WithArgs(9, AllocationReleased).WillReturnRows(released())
+				mock.ExpectExec(reserveStockSQL).WithArgs(2, sqlmock.AnyArg(), 1, 2, nil, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				err := st.RenewReservations(context.Background(), 9, expiresAt)
+				require.ErrorIs(t, err, ErrInsufficientStock)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestReleaseExpiredReservations(t *testing.T) {
+	now := time.Now()
+	variantID := int64(6)
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectBegin()
+		rows := sqlmock.NewRows(reservationColumns).
+			AddRow(7, 9, 3, 1, 2, nil, 2, AllocationReserved).
+			AddRow(8, 10, 4, 2, 5, variantID, 1, AllocationReserved)
+		mock.ExpectQuery(reservationsSQL+" WHERE a.status=? AND a.expires_at<? AND NOT EXISTS (SELECT 1 FROM payments p WHERE p.order_id=a.order_id AND p.status IN (?, ?)) ORDER BY a.id FOR UPDATE").WithArgs(AllocationReserved, now, PaymentPending, PaymentAuthorized).WillReturnRows(rows)
+		mock.ExpectExec(releaseStockSQL).WithArgs(2, now, 1, 2, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(productReservedSQL).WithArgs(-2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE order_item_allocations SET status=? WHERE id=?").WithArgs(AllocationReleased, 7).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(releaseStockSQL).WithArgs(1, now, 2, 5, variantID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(variantReservedSQL).WithArgs(-1, variantID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE order_item_allocations SET status=? WHERE id=?").WithArgs(AllocationReleased, 8).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		n, err := st.ReleaseExpiredReservations(context.Background(), now)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), n)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,123 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (ms *MySQLStorer) CreateReturn(ctx context.Context, r *Return) (*Return, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO returns (order_id, user_id, status, note, admin_note, restock, created_at) VALUES (:order_id, :user_id, :status, :note, :admin_note, :restock, :created_at)", r)
+		if err != nil {
+			return wrapErr("error inserting return", err)
+		}
+		if r.ID, err = res.LastInsertId(); err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		for i := range r.Items {
+			ri := &r.Items[i]
+			ri.ReturnID = r.ID
+			res, err := tx.NamedExecContext(ctx, "INSERT INTO return_items (return_id, order_item_id, quantity, reason_code) VALUES (:return_id, :order_item_id, :quantity, :reason_code)", ri)
+			if err != nil {
+				return wrapErr("error inserting return item", err)
+			}
+			if ri.ID, err = res.LastInsertId(); err != nil {
+				return fmt.Errorf("error getting last insert ID: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (ms *MySQLStorer) GetReturn(ctx context.Context, id int64) (*Return, error) {
+	var r Return
+	err := ms.db.GetContext(ctx, &r, "SELECT * FROM returns WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting return", err)
+	}
+	err = ms.db.SelectContext(ctx, &r.Items, "SELECT * FROM return_items WHERE return_id=? ORDER BY id", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting return items: %w", err)
+	}
+	return &r, nil
+}
+
+// ListReturns lists the matching returns with their items, newest first.
+func (ms *MySQLStorer) ListReturns(ctx context.Context, f ReturnFilter) ([]Return, error) {
+	query, args := listReturnsQuery(f)
+	var rs []Return
+	err := ms.db.SelectContext(ctx, &rs, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing returns: %w", err)
+	}
+	if len(rs) == 0 {
+		return rs, nil
+	}
+	ids := make([]interface{}, len(rs))
+	for i := range rs {
+		ids[i] = rs[i].ID
+	}
+	var items []ReturnItem
+	err = ms.db.SelectContext(ctx, &items, "SELECT * FROM return_items WHERE return_id IN (?"+strings.Repeat(", ?", len(ids)-1)+") ORDER BY id", ids...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing return items: %w", err)
+	}
+	for _, ri := range items {
+		for i := range rs {
+			if rs[i].ID == ri.ReturnID {
+				rs[i].Items = append(rs[i].Items, ri)
+			}
+		}
+	}
+	return rs, nil
+}
+
+func listReturnsQuery(f ReturnFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.OrderID != 0 {
+		conds = append(conds, "order_id=?")
+		args = append(args, f.OrderID)
+	}
+	if f.UserID != 0 {
+		conds = append(conds, "user_id=?")
+		args = append(args, f.UserID)
+	}
+	if len(f.Statuses) > 0 {
+		conds = append(conds, "status IN (?"+strings.Repeat(", ?", len(f.Statuses)-1)+")")
+		for _, st := range f.Statuses {
+			args = append(args, st)
+		}
+	}
+	query := "SELECT * FROM returns"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return query + " ORDER BY id DESC", args
+}
+
+// UpdateReturn records an admin's decision on the return if it is still in
+// status from, and fails with ErrConflict otherwise, so that two admins
+// cannot both make the same move. Its items do not change after it was
+// opened.
+func (ms *MySQLStorer) UpdateReturn(ctx context.Context, r *Return, from string) (*Return, error) {
+	res, err := ms.db.ExecContext(ctx, "UPDATE returns SET status=?, admin_note=?, restock=?, refund_id=?, updated_at=? WHERE id=? AND status=?", r.Status, r.AdminNote, r.Restock, r.RefundID, r.UpdatedAt, r.ID, from)
+	if err != nil {
+		return nil, wrapErr("error updating return", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("error getting rows affected: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%w: return %d is no longer %s", ErrConflict, r.ID, from)
+	}
+	return r, nil
+}
@@ -0,0 +1,125 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateReturn(t *testing.T) {
+	r := &Return{
+		OrderID:   9,
+		UserID:    1,
+		Status:    ReturnRequested,
+		Note:      "arrived broken",
+		Restock:   true,
+		CreatedAt: time.Now(),
+		Items:     []ReturnItem{{OrderItemID: 11, Quantity: 1, ReasonCode: ReturnReasonDamaged}},
+	}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO returns (order_id, user_id, status, note, admin_note, restock, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)").WithArgs(9, 1, ReturnRequested, "arrived broken", "", true, r.CreatedAt).WillReturnResult(sqlmock.NewResult(4, 1))
+		mock.ExpectExec("INSERT INTO return_items (return_id, order_item_id, quantity, reason_code) VALUES (?, ?, ?, ?)").WithArgs(4, 11, 1, ReturnReasonDamaged).WillReturnResult(sqlmock.NewResult(7, 1))
+		mock.ExpectCommit()
+		created, err := st.CreateReturn(context.Background(), r)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), created.ID)
+		require.Equal(t, int64(4), created.Items[0].ReturnID)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestListReturns(t *testing.T) {
+	columns := []string{"id", "order_id", "user_id", "status", "note", "admin_note", "restock", "refund_id", "created_at", "updated_at"}
+	itemColumns := []string{"id", "return_id", "order_item_id", "quantity", "reason_code"}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "by user and status",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(5, 9, 1, ReturnApproved, "", "", true, nil, time.Now(), nil).
+					AddRow(4, 8, 1, ReturnRequested, "", "", true, nil, time.Now(), nil)
+				mock.ExpectQuery("SELECT * FROM returns WHERE user_id=? AND status IN (?, ?) ORDER BY id DESC").WithArgs(1, ReturnRequested, ReturnApproved).WillReturnRows(rows)
+				items := sqlmock.NewRows(itemColumns).
+					AddRow(1, 4, 11, 1, ReturnReasonDamaged).
+					AddRow(2, 5, 12, 2, ReturnReasonWrongItem)
+				mock.ExpectQuery("SELECT * FROM return_items WHERE return_id IN (?, ?) ORDER BY id").WithArgs(5, 4).WillReturnRows(items)
+				rs, err := st.ListReturns(context.Background(), ReturnFilter{UserID: 1, Statuses: []string{ReturnRequested, ReturnApproved}})
+				require.NoError(t, err)
+				require.Len(t, rs, 2)
+				require.Equal(t, int64(12), rs[0].Items[0].OrderItemID)
+				require.Equal(t, int64(11), rs[1].Items[0].OrderItemID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "none",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM returns WHERE order_id=? ORDER BY id DESC").WithArgs(9).WillReturnRows(sqlmock.NewRows(columns))
+				rs, err := st.ListReturns(context.Background(), ReturnFilter{OrderID: 9})
+				require.NoError(t, err)
+				require.Empty(t, rs)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestUpdateReturn(t *testing.T) {
+	now := time.Now()
+	updateSQL := "UPDATE returns SET status=?, admin_note=?, restock=?, refund_id=?, updated_at=? WHERE id=? AND status=?"
+	r := &Return{ID: 4, OrderID: 9, UserID: 1, Status: ReturnAccepted, Restock: true, UpdatedAt: &now}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec(updateSQL).WithArgs(ReturnAccepted, "", true, nil, &now, 4, ReturnReceived).WillReturnResult(sqlmock.NewResult(0, 1))
+				_, err := st.UpdateReturn(context.Background(), r, ReturnReceived)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "already moved",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				// another admin accepted the return first
+				mock.ExpectExec(updateSQL).WithArgs(ReturnAccepted, "", true, nil, &now, 4, ReturnReceived).WillReturnResult(sqlmock.NewResult(0, 0))
+				_, err := st.UpdateReturn(context.Background(), r, ReturnReceived)
+				require.ErrorIs(t, err, ErrConflict)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
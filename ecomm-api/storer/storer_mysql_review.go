@@ -0,0 +1,171 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreateReview stores the review and recomputes the product's rating in the
+// same transaction.
+func (ms *MySQLStorer) CreateReview(ctx context.Context, r *Review) (*Review, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO reviews (product_id, user_id, stars, title, body, verified_purchase, status, created_at) VALUES (:product_id, :user_id, :stars, :title, :body, :verified_purchase, :status, :created_at)", r)
+		if err != nil {
+			return wrapErr("error inserting review", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		r.ID = id
+		return ms.updateProductRating(ctx, tx, r.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (ms *MySQLStorer) GetReview(ctx context.Context, id int64) (*Review, error) {
+	var r Review
+	err := ms.db.GetContext(ctx, &r, "SELECT * FROM reviews WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting review", err)
+	}
+	return &r, nil
+}
+
+func (ms *MySQLStorer) ListReviews(ctx context.Context, f ReviewFilter) ([]Review, error) {
+	query, args := listReviewsQuery(f)
+	var reviews []Review
+	err := ms.db.SelectContext(ctx, &reviews, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+func listReviewsQuery(f ReviewFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.ProductID != 0 {
+		conds = append(conds, "product_id=?")
+		args = append(args, f.ProductID)
+	}
+	if len(f.Statuses) > 0 {
+		conds = append(conds, "status IN (?"+strings.Repeat(", ?", len(f.Statuses)-1)+")")
+		for _, st := range f.Statuses {
+			args = append(args, st)
+		}
+	}
+	query := "SELECT * FROM reviews"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return query + " ORDER BY created_at DESC", args
+}
+
+func (ms *MySQLStorer) UpdateReview(ctx context.Context, r *Review) (*Review, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, "UPDATE reviews SET stars=:stars, title=:title, body=:body, verified_purchase=:verified_purchase, status=:status, updated_at=:updated_at WHERE id=:id", r)
+		if err != nil {
+			return wrapErr("error updating review", err)
+		}
+		return ms.updateProductRating(ctx, tx, r.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (ms *MySQLStorer) DeleteReview(ctx context.Context, r *Review) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM reviews WHERE id=?", r.ID)
+		if err != nil {
+			return wrapErr("error deleting review", err)
+		}
+		return ms.updateProductRating(ctx, tx, r.ProductID)
+	})
+}
+
+// UpdateReviewStatus records a moderation decision along with the review's
+// report count. The product's rating is recomputed since the review may have
+// entered or left the approved set.
+func (ms *MySQLStorer) UpdateReviewStatus(ctx context.Context, r *Review) (*Review, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, "UPDATE reviews SET status=:status, report_count=:report_count, moderated_by=:moderated_by, moderated_at=:moderated_at WHERE id=:id", r)
+		if err != nil {
+			return wrapErr("error updating review status", err)
+		}
+		return ms.updateProductRating(ctx, tx, r.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CreateReviewReport stores a customer's report against r and bumps its
+// report count. Once the count reaches threshold an approved review is
+// flagged, and r's status updated, in the same transaction; the count is
+// bumped first so that the row stays locked and concurrent reports cannot
+// all miss the threshold.
+func (ms *MySQLStorer) CreateReviewReport(ctx context.Context, r *Review, rep *ReviewReport, threshold int) (*ReviewReport, error) {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO review_reports (review_id, user_id, reason, created_at) VALUES (:review_id, :user_id, :reason, :created_at)", rep)
+		if err != nil {
+			return wrapErr("error inserting review report", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		rep.ID = id
+		_, err = tx.ExecContext(ctx, "UPDATE reviews SET report_count=report_count+1 WHERE id=?", rep.ReviewID)
+		if err != nil {
+			return fmt.Errorf("error updating report count: %w", err)
+		}
+		res, err = tx.ExecContext(ctx, "UPDATE reviews SET status=? WHERE id=? AND status=? AND report_count>=?", ReviewStatusFlagged, rep.ReviewID, ReviewStatusApproved, threshold)
+		if err != nil {
+			return fmt.Errorf("error flagging review: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error getting rows affected: %w", err)
+		}
+		if n == 0 {
+			return nil
+		}
+		r.Status = ReviewStatusFlagged
+		return ms.updateProductRating(ctx, tx, r.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// HasPaidOrderItem reports whether the user has paid for the product in one
+// of their orders that was not refunded in full.
+func (ms *MySQLStorer) HasPaidOrderItem(ctx context.Context, userID, productID int64) (bool, error) {
+	var ok bool
+	err := ms.db.GetContext(ctx, &ok, "SELECT EXISTS(SELECT 1 FROM orders o JOIN order_items oi ON oi.order_id=o.id WHERE o.user_id=? AND oi.product_id=? AND o.status IN (?, ?, ?, ?))", userID, productID, OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusPartiallyRefunded)
+	if err != nil {
+		return false, fmt.Errorf("error checking paid orders: %w", err)
+	}
+	return ok, nil
+}
+
+// updateProductRating recomputes the product's rating and review count from
+// its approved reviews.
+func (ms *MySQLStorer) updateProductRating(ctx context.Context, tx *sqlx.Tx, productID int64) error {
+	_, err := tx.ExecContext(ctx, "UPDATE products SET rating=(SELECT COALESCE(AVG(stars), 0) FROM reviews WHERE product_id=? AND status=?), num_reviews=(SELECT COUNT(*) FROM reviews WHERE product_id=? AND status=?) WHERE id=?", productID, ReviewStatusApproved, productID, ReviewStatusApproved, productID)
+	if err != nil {
+		return fmt.Errorf("error updating product rating: %w", err)
+	}
+	return nil
+}
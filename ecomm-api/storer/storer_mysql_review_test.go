@@ -0,0 +1,217 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+const updateProductRatingQuery = "UPDATE products SET rating=(SELECT COALESCE(AVG(stars), 0) FROM reviews WHERE product_id=? AND status=?), num_reviews=(SELECT COUNT(*) FROM reviews WHERE product_id=? AND status=?) WHERE id=?"
+
+func TestCreateReview(t *testing.T) {
+	r := &Review{
+		ProductID: 1,
+		UserID:    2,
+		Stars:     4,
+		Title:     "good",
+		Body:      "does the job",
+		Status:    ReviewStatusPending,
+		CreatedAt: time.Now(),
+	}
+	insert := "INSERT INTO reviews (product_id, user_id, stars, title, body, verified_purchase, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(insert).WithArgs(r.ProductID, r.UserID, r.Stars, r.Title, r.Body, r.VerifiedPurchase, r.Status, r.CreatedAt).WillReturnResult(sqlmock.NewResult(5, 1))
+				mock.ExpectExec(updateProductRatingQuery).WithArgs(r.ProductID, ReviewStatusApproved, r.ProductID, ReviewStatusApproved, r.ProductID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				cr, err := st.CreateReview(context.Background(), r)
+				require.NoError(t, err)
+				require.Equal(t, int64(5), cr.ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "error updating rating",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(5, 1))
+				mock.ExpectExec(updateProductRatingQuery).WillReturnError(fmt.Errorf("db error"))
+				mock.ExpectRollback()
+				_, err := st.CreateReview(context.Background(), r)
+				require.Error(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestDeleteReview(t *testing.T) {
+	r := &Review{ID: 5, ProductID: 1}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM reviews WHERE id=?").WithArgs(r.ID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(updateProductRatingQuery).WithArgs(r.ProductID, ReviewStatusApproved, r.ProductID, ReviewStatusApproved, r.ProductID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				err := st.DeleteReview(context.Background(), r)
+				require.NoError(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "error deleting review",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM reviews WHERE id=?").WillReturnError(fmt.Errorf("db error"))
+				mock.ExpectRollback()
+				err := st.DeleteReview(context.Background(), r)
+				require.Error(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestListReviews(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "approved reviews of a product",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "product_id", "stars", "status", "created_at"}).AddRow(1, 2, 5, ReviewStatusApproved, time.Now())
+				mock.ExpectQuery("SELECT * FROM reviews WHERE product_id=? AND status IN (?) ORDER BY created_at DESC").WithArgs(2, ReviewStatusApproved).WillReturnRows(rows)
+				reviews, err := st.ListReviews(context.Background(), ReviewFilter{ProductID: 2, Statuses: []string{ReviewStatusApproved}})
+				require.NoError(t, err)
+				require.Len(t, reviews, 1)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "moderation queue",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "status", "created_at"}).AddRow(1, ReviewStatusFlagged, time.Now()).AddRow(2, ReviewStatusPending, time.Now())
+				mock.ExpectQuery("SELECT * FROM reviews WHERE status IN (?, ?) ORDER BY created_at DESC").WithArgs(ReviewStatusPending, ReviewStatusFlagged).WillReturnRows(rows)
+				reviews, err := st.ListReviews(context.Background(), ReviewFilter{Statuses: []string{ReviewStatusPending, ReviewStatusFlagged}})
+				require.NoError(t, err)
+				require.Len(t, reviews, 2)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestCreateReviewReport(t *testing.T) {
+	rep := &ReviewReport{ReviewID: 5, UserID: 3, Reason: "spam", CreatedAt: time.Now()}
+	insert := "INSERT INTO review_reports (review_id, user_id, reason, created_at) VALUES (?, ?, ?, ?)"
+	flagSQL := "UPDATE reviews SET status=? WHERE id=? AND status=? AND report_count>=?"
+	review := func() *Review { return &Review{ID: 5, ProductID: 2, Status: ReviewStatusApproved} }
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(insert).WithArgs(rep.ReviewID, rep.UserID, rep.Reason, rep.CreatedAt).WillReturnResult(sqlmock.NewResult(7, 1))
+				mock.ExpectExec("UPDATE reviews SET report_count=report_count+1 WHERE id=?").WithArgs(rep.ReviewID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(flagSQL).WithArgs(ReviewStatusFlagged, rep.ReviewID, ReviewStatusApproved, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+				r := review()
+				cr, err := st.CreateReviewReport(context.Background(), r, rep, 3)
+				require.NoError(t, err)
+				require.Equal(t, int64(7), cr.ID)
+				require.Equal(t, ReviewStatusApproved, r.Status)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "reaches threshold",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(insert).WithArgs(rep.ReviewID, rep.UserID, rep.Reason, rep.CreatedAt).WillReturnResult(sqlmock.NewResult(7, 1))
+				mock.ExpectExec("UPDATE reviews SET report_count=report_count+1 WHERE id=?").WithArgs(rep.ReviewID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(flagSQL).WithArgs(ReviewStatusFlagged, rep.ReviewID, ReviewStatusApproved, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+				// the flagged review leaves the product's rating
+				mock.ExpectExec(updateProductRatingQuery).WithArgs(2, ReviewStatusApproved, 2, ReviewStatusApproved, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				r := review()
+				_, err := st.CreateReviewReport(context.Background(), r, rep, 3)
+				require.NoError(t, err)
+				require.Equal(t, ReviewStatusFlagged, r.Status)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "already reported",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(insert).WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+				mock.ExpectRollback()
+				_, err := st.CreateReviewReport(context.Background(), review(), rep, 3)
+				require.ErrorIs(t, err, ErrConflict)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
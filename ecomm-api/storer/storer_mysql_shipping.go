@@ -0,0 +1,110 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+)
+
+func (ms *MySQLStorer) CreateShippingZone(ctx context.Context, z *ShippingZone) (*ShippingZone, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO shipping_zones (name, locations, created_at) VALUES (:name, :locations, :created_at)", z)
+	if err != nil {
+		return nil, wrapErr("error inserting shipping zone", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	z.ID = id
+	return z, nil
+}
+
+func (ms *MySQLStorer) GetShippingZone(ctx context.Context, id int64) (*ShippingZone, error) {
+	var z ShippingZone
+	err := ms.db.GetContext(ctx, &z, "SELECT * FROM shipping_zones WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting shipping zone", err)
+	}
+	return &z, nil
+}
+
+func (ms *MySQLStorer) ListShippingZones(ctx context.Context) ([]ShippingZone, error) {
+	var zones []ShippingZone
+	err := ms.db.SelectContext(ctx, &zones, "SELECT * FROM shipping_zones ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error listing shipping zones: %w", err)
+	}
+	return zones, nil
+}
+
+func (ms *MySQLStorer) UpdateShippingZone(ctx context.Context, z *ShippingZone) (*ShippingZone, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE shipping_zones SET name=:name, locations=:locations, updated_at=:updated_at WHERE id=:id", z)
+	if err != nil {
+		return nil, wrapErr("error updating shipping zone", err)
+	}
+	return z, nil
+}
+
+// DeleteShippingZone also deletes the zone's methods.
+func (ms *MySQLStorer) DeleteShippingZone(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM shipping_zones WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting shipping zone", err)
+	}
+	return nil
+}
+
+func (ms *MySQLStorer) CreateShippingMethod(ctx context.Context, m *ShippingMethod) (*ShippingMethod, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO shipping_methods (zone_id, name, kind, price, free_over, tiers, active, created_at) VALUES (:zone_id, :name, :kind, :price, :free_over, :tiers, :active, :created_at)", m)
+	if err != nil {
+		return nil, wrapErr("error inserting shipping method", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	m.ID = id
+	return m, nil
+}
+
+func (ms *MySQLStorer) GetShippingMethod(ctx context.Context, id int64) (*ShippingMethod, error) {
+	var m ShippingMethod
+	err := ms.db.GetContext(ctx, &m, "SELECT * FROM shipping_methods WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting shipping method", err)
+	}
+	return &m, nil
+}
+
+// ListShippingMethods lists the methods of a zone, or every method when
+// zoneID is 0.
+func (ms *MySQLStorer) ListShippingMethods(ctx context.Context, zoneID int64) ([]ShippingMethod, error) {
+	var (
+		methods []ShippingMethod
+		err     error
+	)
+	if zoneID == 0 {
+		err = ms.db.SelectContext(ctx, &methods, "SELECT * FROM shipping_methods ORDER BY zone_id, id")
+	} else {
+		err = ms.db.SelectContext(ctx, &methods, "SELECT * FROM shipping_methods WHERE zone_id=? ORDER BY id", zoneID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing shipping methods: %w", err)
+	}
+	return methods, nil
+}
+
+func (ms *MySQLStorer) UpdateShippingMethod(ctx context.Context, m *ShippingMethod) (*ShippingMethod, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE shipping_methods SET zone_id=:zone_id, name=:name, kind=:kind, price=:price, free_over=:free_over, tiers=:tiers, active=:active, updated_at=:updated_at WHERE id=:id", m)
+	if err != nil {
+		return nil, wrapErr("error updating shipping method", err)
+	}
+	return m, nil
+}
+
+func (ms *MySQLStorer) DeleteShippingMethod(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM shipping_methods WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting shipping method", err)
+	}
+	return nil
+}
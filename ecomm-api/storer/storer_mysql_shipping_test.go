@@ -0,0 +1,70 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateShippingZone(t *testing.T) {
+	z := &ShippingZone{
+		Name:      "Mainland US",
+		Locations: ZoneLocations{{Country: "US"}, {Country: "CA", Region: "QC"}},
+		CreatedAt: time.Now(),
+	}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectExec("INSERT INTO shipping_zones (name, locations, created_at) VALUES (?, ?, ?)").WithArgs(z.Name, `[{"country":"US"},{"country":"CA","region":"QC"}]`, z.CreatedAt).WillReturnResult(sqlmock.NewResult(3, 1))
+		created, err := st.CreateShippingZone(context.Background(), z)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), created.ID)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestListShippingMethods(t *testing.T) {
+	columns := []string{"id", "zone_id", "name", "kind", "price", "free_over", "tiers", "active", "created_at", "updated_at"}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "by zone",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, 2, "Standard", ShippingWeight, 0, 0, `[{"price":5},{"from_weight":2,"price":9}]`, true, time.Now(), nil)
+				mock.ExpectQuery("SELECT * FROM shipping_methods WHERE zone_id=? ORDER BY id").WithArgs(2).WillReturnRows(rows)
+				methods, err := st.ListShippingMethods(context.Background(), 2)
+				require.NoError(t, err)
+				require.Len(t, methods, 1)
+				require.Equal(t, ShippingTiers{{FromWeight: 0, Price: 500}, {FromWeight: 2, Price: 900}}, methods[0].Tiers)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "all",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM shipping_methods ORDER BY zone_id, id").WillReturnRows(sqlmock.NewRows(columns))
+				methods, err := st.ListShippingMethods(context.Background(), 0)
+				require.NoError(t, err)
+				require.Empty(t, methods)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+)
+
+func (ms *MySQLStorer) CreateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO tax_rates (name, country, region, postal_prefix, tax_class, rate, priority, created_at) VALUES (:name, :country, :region, :postal_prefix, :tax_class, :rate, :priority, :created_at)", tr)
+	if err != nil {
+		return nil, wrapErr("error inserting tax rate", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	tr.ID = id
+	return tr, nil
+}
+
+func (ms *MySQLStorer) GetTaxRate(ctx context.Context, id int64) (*TaxRate, error) {
+	var tr TaxRate
+	err := ms.db.GetContext(ctx, &tr, "SELECT * FROM tax_rates WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting tax rate", err)
+	}
+	return &tr, nil
+}
+
+// ListTaxRates lists the rates of a country, or every rate when country is
+// empty.
+func (ms *MySQLStorer) ListTaxRates(ctx context.Context, country string) ([]TaxRate, error) {
+	var (
+		rates []TaxRate
+		err   error
+	)
+	if country == "" {
+		err = ms.db.SelectContext(ctx, &rates, "SELECT * FROM tax_rates ORDER BY country, priority, id")
+	} else {
+		err = ms.db.SelectContext(ctx, &rates, "SELECT * FROM tax_rates WHERE country=? ORDER BY priority, id", country)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing tax rates: %w", err)
+	}
+	return rates, nil
+}
+
+func (ms *MySQLStorer) UpdateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE tax_rates SET name=:name, country=:country, region=:region, postal_prefix=:postal_prefix, tax_class=:tax_class, rate=:rate, priority=:priority, updated_at=:updated_at WHERE id=:id", tr)
+	if err != nil {
+		return nil, wrapErr("error updating tax rate", err)
+	}
+	return tr, nil
+}
+
+func (ms *MySQLStorer) DeleteTaxRate(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM tax_rates WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting tax rate", err)
+	}
+	return nil
+}
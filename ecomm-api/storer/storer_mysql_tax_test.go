@@ -0,0 +1,86 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateOrderStoresItemTaxes(t *testing.T) {
+	rateID := int64(2)
+	o := &Order{
+		UserID:     4,
+		Status:     OrderStatusPending,
+		TaxPrice:   400,
+		TotalPrice: 2400,
+		CreatedAt:  time.Now(),
+		Items: []OrderItem{{
+			Name: "product 9", Quantity: 2, Price: 1000, ProductID: 9,
+			Allocations: []OrderItemAllocation{{WarehouseID: 1, Quantity: 2}},
+			Taxes:       []OrderItemTax{{TaxRateID: &rateID, Name: "VAT", Rate: 0.2, Amount: 400}},
+		}},
+	}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(11, 1))
+		mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(5, 1))
+		mock.ExpectExec(reserveStockSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(allocationSQL).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO order_item_taxes (order_id, order_item_id, tax_rate_id, name, rate, amount, included) VALUES (?, ?, ?, ?, ?, ?, ?)").WithArgs(int64(11), int64(5), &rateID, "VAT", 0.2, money.Amount(400), false).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		created, err := st.CreateOrder(context.Background(), o)
+		require.NoError(t, err)
+		require.Equal(t, int64(5), created.Items[0].Taxes[0].OrderItemID)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
+
+func TestListTaxRates(t *testing.T) {
+	columns := []string{"id", "name", "country", "region", "postal_prefix", "tax_class", "rate", "priority", "created_at", "updated_at"}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "by country",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(1, "US sales tax", "US", "CA", "", TaxClassStandard, 0.0725, 0, time.Now(), nil)
+				mock.ExpectQuery("SELECT * FROM tax_rates WHERE country=? ORDER BY priority, id").WithArgs("US").WillReturnRows(rows)
+				rates, err := st.ListTaxRates(context.Background(), "US")
+				require.NoError(t, err)
+				require.Len(t, rates, 1)
+				require.Equal(t, "CA", rates[0].Region)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "all",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM tax_rates ORDER BY country, priority, id").WillReturnRows(sqlmock.NewRows(columns))
+				rates, err := st.ListTaxRates(context.Background(), "")
+				require.NoError(t, err)
+				require.Empty(t, rates)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
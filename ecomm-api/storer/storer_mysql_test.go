@@ -7,6 +7,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
+	"github.com/m21power/ecomm/ecomm-api/money"
 	"github.com/stretchr/testify/require"
 )
 
@@ -27,7 +28,7 @@ func TestCreateProduct(t *testing.T) {
 		Description:  "test description",
 		Rating:       4,
 		NumReviews:   100,
-		Price:        100.00,
+		Price:        10000,
 		CountInStock: 10,
 	}
 	tcs := []struct {
@@ -40,7 +41,7 @@ func TestCreateProduct(t *testing.T) {
 
 				// we tell the fake database to expect an INSERT action. This means we’re telling the database:
 				// "You should be expecting us to add this product into the store’s database."
-				mock.ExpectExec("INSERT INTO products (name, image, category, description, rating, num_reviews, price, count_in_stock) VALUES (?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO products (name, image, category, category_id, description, rating, num_reviews, price, count_in_stock, weight, length, width, height, inventory_policy, backorder_limit, release_date, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(1, 1))
 				cp, err := st.CreateProduct(context.Background(), p)
 				require.NoError(t, err)
 				require.Equal(t, int64(1), cp.ID)
@@ -51,7 +52,7 @@ func TestCreateProduct(t *testing.T) {
 		{
 			name: "error occured creating product",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
-				mock.ExpectExec("INSERT INTO products (name, image, category, description, rating, num_reviews, price, count_in_stock) VALUES (?, ?, ?, ?, ?, ?, ?, ?)").WillReturnError(fmt.Errorf("error inserting product"))
+				mock.ExpectExec("INSERT INTO products (name, image, category, category_id, description, rating, num_reviews, price, count_in_stock, weight, length, width, height, inventory_policy, backorder_limit, release_date, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnError(fmt.Errorf("error inserting product"))
 				_, err := st.CreateProduct(context.Background(), p)
 				require.Error(t, err)
 				err = mock.ExpectationsWereMet()
@@ -61,7 +62,7 @@ func TestCreateProduct(t *testing.T) {
 		{
 			name: "error occured getting last insert ID",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
-				mock.ExpectExec("INSERT INTO products (name, image, category, description, rating, num_reviews, price, count_in_stock) VALUES (?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("error getting last insert ID")))
+				mock.ExpectExec("INSERT INTO products (name, image, category, category_id, description, rating, num_reviews, price, count_in_stock, weight, length, width, height, inventory_policy, backorder_limit, release_date, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("error getting last insert ID")))
 				_, err := st.CreateProduct(context.Background(), p)
 				require.Error(t, err)
 				err = mock.ExpectationsWereMet()
@@ -87,7 +88,7 @@ func TestGetProduct(t *testing.T) {
 		Description:  "test description",
 		Rating:       4,
 		NumReviews:   100,
-		Price:        100.00,
+		Price:        10000,
 		CountInStock: 10,
 	}
 
@@ -141,7 +142,7 @@ func TestListProducts(t *testing.T) {
 		Description:  "test description",
 		Rating:       4,
 		NumReviews:   100,
-		Price:        100.00,
+		Price:        10000,
 		CountInStock: 10,
 	}
 
@@ -155,7 +156,7 @@ func TestListProducts(t *testing.T) {
 				rows := sqlmock.NewRows([]string{"id", "name", "image", "category", "description", "rating", "num_reviews", "price", "count_in_stock", "created_at", "updated_at"}).
 					AddRow(1, p.Name, p.Image, p.Category, p.Description, p.Rating, p.NumReviews, p.Price, p.CountInStock, p.CreatedAt, p.UpdatedAt)
 				mock.ExpectQuery("SELECT * FROM products").WillReturnRows(rows)
-				lp, err := st.ListProducts(context.Background())
+				lp, err := st.ListProducts(context.Background(), ProductFilter{})
 				require.NoError(t, err)
 				require.Len(t, lp, 1)
 				require.Equal(t, int64(1), lp[0].ID)
@@ -163,11 +164,24 @@ func TestListProducts(t *testing.T) {
 				require.NoError(t, err)
 			},
 		},
+		{
+			name: "filtered",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "name", "image", "category", "description", "rating", "num_reviews", "price", "count_in_stock", "created_at", "updated_at"}).
+					AddRow(1, p.Name, p.Image, p.Category, p.Description, p.Rating, p.NumReviews, p.Price, p.CountInStock, p.CreatedAt, p.UpdatedAt)
+				mock.ExpectQuery("SELECT * FROM products WHERE category_id IN (?, ?) AND price>=? AND price<=? LIMIT ? OFFSET ?").WithArgs(1, 2, money.Amount(5000), money.Amount(15000), 10, 20).WillReturnRows(rows)
+				lp, err := st.ListProducts(context.Background(), ProductFilter{CategoryIDs: []int64{1, 2}, MinPrice: 5000, MaxPrice: 15000, Limit: 10, Offset: 20})
+				require.NoError(t, err)
+				require.Len(t, lp, 1)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
 		{
 			name: "error listing products",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				mock.ExpectQuery("SELECT * FROM products").WillReturnError(fmt.Errorf("error listing products"))
-				_, err := st.ListProducts(context.Background())
+				_, err := st.ListProducts(context.Background(), ProductFilter{})
 				require.Error(t, err)
 				err = mock.ExpectationsWereMet()
 				require.NoError(t, err)
@@ -192,7 +206,7 @@ func TestUpdateProduct(t *testing.T) {
 		Description:  "test description",
 		Rating:       4,
 		NumReviews:   100,
-		Price:        100.00,
+		Price:        10000,
 		CountInStock: 10,
 	}
 	np := &Product{
@@ -203,7 +217,7 @@ func TestUpdateProduct(t *testing.T) {
 		Description:  "new test description",
 		Rating:       5,
 		NumReviews:   200,
-		Price:        200.00,
+		Price:        20000,
 		CountInStock: 20,
 	}
 	tcs := []struct {
@@ -213,14 +227,14 @@ func TestUpdateProduct(t *testing.T) {
 		{
 			name: "success",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
-				mock.ExpectExec("INSERT INTO products (name, image, category, description, rating, num_reviews, price, count_in_stock) VALUES (?, ?, ?, ?, ?, ?, ?, ?)").WithArgs(p.Name, p.Image, p.Category, p.Description, p.Rating, p.NumReviews, p.Price, p.CountInStock).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO products (name, image, category, category_id, description, rating, num_reviews, price, count_in_stock, weight, length, width, height, inventory_policy, backorder_limit, release_date, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WithArgs(p.Name, p.Image, p.Category, p.CategoryID, p.Description, p.Rating, p.NumReviews, p.Price, p.CountInStock, p.Weight, p.Length, p.Width, p.Height, p.InventoryPolicy, p.BackorderLimit, p.ReleaseDate, p.CreatedAt).WillReturnResult(sqlmock.NewResult(1, 1))
 				cp, err := st.CreateProduct(context.Background(), p)
 				require.NoError(t, err)
 				require.Equal(t, int64(1), cp.ID)
 				err = mock.ExpectationsWereMet()
 				require.NoError(t, err)
 
-				mock.ExpectExec("UPDATE products SET name=?, image=?, category=?, description=?, rating=?, num_reviews=?, price=?, count_in_stock=?, updated_at=? WHERE id=?").WithArgs(np.Name, np.Image, np.Category, np.Description, np.Rating, np.NumReviews, np.Price, np.CountInStock, np.UpdatedAt, np.ID).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("UPDATE products SET name=?, image=?, category=?, category_id=?, description=?, price=?, weight=?, length=?, width=?, height=?, inventory_policy=?, backorder_limit=?, release_date=?, updated_at=? WHERE id=?").WithArgs(np.Name, np.Image, np.Category, np.CategoryID, np.Description, np.Price, np.Weight, np.Length, np.Width, np.Height, np.InventoryPolicy, np.BackorderLimit, np.ReleaseDate, np.UpdatedAt, np.ID).WillReturnResult(sqlmock.NewResult(1, 1))
 				up, err := st.UpdateProduct(context.Background(), np)
 				require.NoError(t, err)
 				require.Equal(t, int64(1), up.ID)
@@ -231,7 +245,7 @@ func TestUpdateProduct(t *testing.T) {
 		{
 			name: "error updating product",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
-				mock.ExpectExec("UPDATE products SET name=?, image=?, category=?, description=?, rating=?, num_reviews=?, price=?, count_in_stock=?, updated_at=? WHERE id=?").WithArgs(np.Name, np.Image, np.Category, np.Description, np.Rating, np.NumReviews, np.Price, np.CountInStock, np.UpdatedAt, np.ID).WillReturnError(fmt.Errorf("error updating product"))
+				mock.ExpectExec("UPDATE products SET name=?, image=?, category=?, category_id=?, description=?, price=?, weight=?, length=?, width=?, height=?, inventory_policy=?, backorder_limit=?, release_date=?, updated_at=? WHERE id=?").WithArgs(np.Name, np.Image, np.Category, np.CategoryID, np.Description, np.Price, np.Weight, np.Length, np.Width, np.Height, np.InventoryPolicy, np.BackorderLimit, np.ReleaseDate, np.UpdatedAt, np.ID).WillReturnError(fmt.Errorf("error updating product"))
 				_, err := st.UpdateProduct(context.Background(), np)
 				require.Error(t, err)
 				err = mock.ExpectationsWereMet()
@@ -257,7 +271,7 @@ func TestDeleteProduct(t *testing.T) {
 		Description:  "test description",
 		Rating:       4,
 		NumReviews:   100,
-		Price:        100.00,
+		Price:        10000,
 		CountInStock: 10,
 	}
 	tcs := []struct {
@@ -267,7 +281,7 @@ func TestDeleteProduct(t *testing.T) {
 		{
 			name: "success",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
-				mock.ExpectExec("INSERT INTO products (name, image, category, description, rating, num_reviews, price, count_in_stock) VALUES (?, ?, ?, ?, ?, ?, ?, ?)").WithArgs(p.Name, p.Image, p.Category, p.Description, p.Rating, p.NumReviews, p.Price, p.CountInStock).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO products (name, image, category, category_id, description, rating, num_reviews, price, count_in_stock, weight, length, width, height, inventory_policy, backorder_limit, release_date, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WithArgs(p.Name, p.Image, p.Category, p.CategoryID, p.Description, p.Rating, p.NumReviews, p.Price, p.CountInStock, p.Weight, p.Length, p.Width, p.Height, p.InventoryPolicy, p.BackorderLimit, p.ReleaseDate, p.CreatedAt).WillReturnResult(sqlmock.NewResult(1, 1))
 				cp, err := st.CreateProduct(context.Background(), p)
 				require.NoError(t, err)
 				require.Equal(t, int64(1), cp.ID)
@@ -301,27 +315,35 @@ func TestDeleteProduct(t *testing.T) {
 }
 
 func TestCreateOrder(t *testing.T) {
+	variantID := int64(7)
 	ois := []OrderItem{
 		{
-			Name:      "product 1",
-			Quantity:  1,
-			Image:     "test.jpg",
-			Price:     99.99,
-			ProductID: 1,
+			Name:        "product 1",
+			Quantity:    1,
+			Image:       "test.jpg",
+			Price:       9999,
+			ProductID:   1,
+			Allocations: []OrderItemAllocation{{WarehouseID: 1, Quantity: 1}},
 		},
 		{
-			Name:      "product 2",
-			Quantity:  2,
-			Image:     "test2.jpg",
-			Price:     99.99,
-			ProductID: 2,
+			Name:        "product 2",
+			Quantity:    2,
+			Image:       "test2.jpg",
+			Price:       9999,
+			ProductID:   2,
+			VariantID:   &variantID,
+			Allocations: []OrderItemAllocation{{WarehouseID: 1, Quantity: 2}},
 		},
 	}
 	o := &Order{
+		UserID:        1,
+		Status:        OrderStatusPending,
 		PaymentMethod: "test payment method",
 		TaxPrice:      34,
 		ShippingPrice: 123,
 		TotalPrice:    1235,
+		Currency:      "EUR",
+		ExchangeRate:  0.92,
 		Items:         ois,
 	}
 
@@ -336,13 +358,19 @@ func TestCreateOrder(t *testing.T) {
 				mock.ExpectBegin()
 
 				// Mock order insertion
-				mock.ExpectExec("INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)").WithArgs(o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WithArgs(o.UserID, o.Status, o.PaymentMethod, o.ShippingMethodID, o.ShippingMethod, o.TaxPrice, o.ShippingPrice, o.DiscountPrice, o.TotalPrice, o.Currency, o.ExchangeRate, o.CreatedAt).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				// Mock first order item insertion (order_id = 1)
-				mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,order_id) VALUES (?,?,?,?,?,?)").WithArgs(ois[0].Name, ois[0].Quantity, ois[0].Image, ois[0].Price, ois[0].ProductID, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+				// Mock first order item insertion (order_id = 1) and its product stock decrement
+				mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WithArgs(ois[0].Name, ois[0].Quantity, ois[0].Image, ois[0].Price, ois[0].ProductID, ois[0].VariantID, 1, ois[0].Backordered).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(reserveStockSQL).WithArgs(ois[0].Quantity, o.CreatedAt, 1, ois[0].ProductID, nil, ois[0].Quantity).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(allocationSQL).WithArgs(1, 1, 1, ois[0].Quantity, AllocationReserved, nil).WillReturnResult(sqlmock.NewResult(1, 1))
 
-				// Mock second order item insertion (order_id = 1)
-				mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,order_id) VALUES (?,?,?,?,?,?)").WithArgs(ois[1].Name, ois[1].Quantity, ois[1].Image, ois[1].Price, ois[1].ProductID, 1).WillReturnResult(sqlmock.NewResult(2, 1))
+				// Mock second order item insertion (order_id = 1) and its variant stock decrement
+				mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WithArgs(ois[1].Name, ois[1].Quantity, ois[1].Image, ois[1].Price, ois[1].ProductID, ois[1].VariantID, 1, ois[1].Backordered).WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec(reserveStockSQL).WithArgs(ois[1].Quantity, o.CreatedAt, 1, ois[1].ProductID, variantID, ois[1].Quantity).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(variantReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(allocationSQL).WithArgs(1, 2, 1, ois[1].Quantity, AllocationReserved, nil).WillReturnResult(sqlmock.NewResult(2, 1))
 
 				// Commit the transaction
 				mock.ExpectCommit()
@@ -351,12 +379,30 @@ func TestCreateOrder(t *testing.T) {
 				cp, err := st.CreateOrder(context.Background(), o)
 				require.NoError(t, err)
 				require.Equal(t, int64(1), cp.ID)
+				require.Equal(t, int64(2), cp.Items[1].ID)
 
 				// Verify all expectations were met
 				er := mock.ExpectationsWereMet()
 				require.NoError(t, er)
 			},
 		},
+		{
+			name: "oversell_rollback",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(1, 1))
+				// no row matched: the warehouse holds fewer than Quantity units
+				mock.ExpectExec(reserveStockSQL).WithArgs(ois[0].Quantity, o.CreatedAt, 1, ois[0].ProductID, nil, ois[0].Quantity).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+
+				_, err := st.CreateOrder(context.Background(), o)
+				require.ErrorIs(t, err, ErrInsufficientStock)
+
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
 		{
 			name: "failure_rollback",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
@@ -364,8 +410,8 @@ func TestCreateOrder(t *testing.T) {
 				mock.ExpectBegin()
 
 				// Mock order insertion failure
-				mock.ExpectExec("INSERT INTO orders (payment_method, tax_price, shipping_price, total_price) VALUES (?, ?, ?, ?)").WithArgs(
-					o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice,
+				mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WithArgs(
+					o.UserID, o.Status, o.PaymentMethod, o.ShippingMethodID, o.ShippingMethod, o.TaxPrice, o.ShippingPrice, o.DiscountPrice, o.TotalPrice, o.Currency, o.ExchangeRate, o.CreatedAt,
 				).WillReturnError(fmt.Errorf("db error"))
 
 				// Expect rollback
@@ -402,7 +448,7 @@ func TestGetOrder(t *testing.T) {
 			Name:      "product 1",
 			Quantity:  1,
 			Image:     "test.jpg",
-			Price:     99.99,
+			Price:     9999,
 			ProductID: 1,
 			OrderID:   1,
 		},
@@ -411,7 +457,7 @@ func TestGetOrder(t *testing.T) {
 			Name:      "product 2",
 			Quantity:  2,
 			Image:     "test2.jpg",
-			Price:     99.99,
+			Price:     9999,
 			ProductID: 2,
 			OrderID:   1,
 		},
@@ -432,17 +478,43 @@ func TestGetOrder(t *testing.T) {
 			name: "success",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				// Mock the order query
-				rows := sqlmock.NewRows([]string{"id", "payment_method", "tax_price", "shipping_price", "total_price", "created_at", "updated_at"}).
-					AddRow(o.ID, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice, o.CreatedAt, o.UpdatedAt)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "status", "payment_method", "tax_price", "shipping_price", "total_price", "created_at", "updated_at"}).
+					AddRow(o.ID, o.UserID, o.Status, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice, o.CreatedAt, o.UpdatedAt)
 				mock.ExpectQuery("SELECT * FROM orders WHERE id=?").WithArgs(1).WillReturnRows(rows)
 
 				// Mock the order items query
 				rows = sqlmock.NewRows([]string{"id", "name", "quantity", "image", "price", "product_id", "order_id"}).
 					AddRow(ois[0].ID, ois[0].Name, ois[0].Quantity, ois[0].Image, ois[0].Price, ois[0].ProductID, ois[0].OrderID)
 				mock.ExpectQuery("SELECT * FROM order_items WHERE order_id=?").WithArgs(1).WillReturnRows(rows)
+
+				// Mock the order item taxes query
+				rows = sqlmock.NewRows([]string{"id", "order_id", "order_item_id", "tax_rate_id", "name", "rate", "amount", "included"}).
+					AddRow(1, 1, ois[0].ID, 2, "VAT", 0.2, "20.00", false)
+				mock.ExpectQuery("SELECT * FROM order_item_taxes WHERE order_id=? ORDER BY id").WithArgs(1).WillReturnRows(rows)
+
+				// Mock the order item allocations query
+				rows = sqlmock.NewRows([]string{"id", "order_id", "order_item_id", "warehouse_id", "quantity"}).
+					AddRow(1, 1, ois[0].ID, 2, 1)
+				mock.ExpectQuery("SELECT * FROM order_item_allocations WHERE order_id=? ORDER BY id").WithArgs(1).WillReturnRows(rows)
+
+				// Mock the order discounts query
+				rows = sqlmock.NewRows([]string{"id", "order_id", "promotion_id", "code", "description", "amount"}).
+					AddRow(1, 1, 3, "SAVE10", "10% off", "10.00")
+				mock.ExpectQuery("SELECT * FROM order_discounts WHERE order_id=?").WithArgs(1).WillReturnRows(rows)
+
+				// Mock the order addresses query
+				rows = sqlmock.NewRows([]string{"id", "order_id", "kind", "name", "line1", "line2", "city", "region", "postal_code", "country", "phone"}).
+					AddRow(1, 1, AddressShipping, "Ada Lovelace", "12 Example St", "", "London", "", "N1 9GU", "GB", "")
+				mock.ExpectQuery("SELECT * FROM order_addresses WHERE order_id=?").WithArgs(1).WillReturnRows(rows)
 				gp, err := st.GetOrder(context.Background(), 1)
 				require.NoError(t, err)
 				require.Equal(t, int64(1), gp.ID)
+				require.Len(t, gp.Discounts, 1)
+				require.Len(t, gp.Items[0].Taxes, 1)
+				require.Equal(t, money.Amount(2000), gp.Items[0].Taxes[0].Amount)
+				require.Equal(t, int64(2), gp.Items[0].Allocations[0].WarehouseID)
+				require.Equal(t, "London", gp.ShippingAddress.City)
+				require.Nil(t, gp.BillingAddress)
 			}},
 		{
 			name: "error getting order",
@@ -471,7 +543,7 @@ func TestListOrders(t *testing.T) {
 			Name:      "product 1",
 			Quantity:  1,
 			Image:     "test.jpg",
-			Price:     99.99,
+			Price:     9999,
 			ProductID: 1,
 			OrderID:   1,
 		},
@@ -480,7 +552,7 @@ func TestListOrders(t *testing.T) {
 			Name:      "product 2",
 			Quantity:  2,
 			Image:     "test2.jpg",
-			Price:     99.99,
+			Price:     9999,
 			ProductID: 2,
 			OrderID:   1,
 		},
@@ -501,8 +573,8 @@ func TestListOrders(t *testing.T) {
 			name: "success",
 			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
 				// Mock the orders query
-				rows := sqlmock.NewRows([]string{"id", "payment_method", "tax_price", "shipping_price", "total_price", "created_at", "updated_at"}).
-					AddRow(o.ID, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice, o.CreatedAt, o.UpdatedAt)
+				rows := sqlmock.NewRows([]string{"id", "user_id", "status", "payment_method", "tax_price", "shipping_price", "total_price", "created_at", "updated_at"}).
+					AddRow(o.ID, o.UserID, o.Status, o.PaymentMethod, o.TaxPrice, o.ShippingPrice, o.TotalPrice, o.CreatedAt, o.UpdatedAt)
 				mock.ExpectQuery("SELECT * FROM orders").WillReturnRows(rows)
 
 				// Mock the order items query
@@ -511,6 +583,10 @@ func TestListOrders(t *testing.T) {
 					AddRow(ois[1].ID, ois[1].Name, ois[1].Quantity, ois[1].Image, ois[1].Price, ois[1].ProductID, ois[1].OrderID)
 
 				mock.ExpectQuery("SELECT * FROM order_items WHERE order_id=?").WithArgs(1).WillReturnRows(rows)
+				mock.ExpectQuery("SELECT * FROM order_item_taxes WHERE order_id=? ORDER BY id").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "order_item_id", "tax_rate_id", "name", "rate", "amount", "included"}))
+				mock.ExpectQuery("SELECT * FROM order_item_allocations WHERE order_id=? ORDER BY id").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "order_item_id", "warehouse_id", "quantity"}))
+				mock.ExpectQuery("SELECT * FROM order_discounts WHERE order_id=?").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "promotion_id", "code", "description", "amount"}))
+				mock.ExpectQuery("SELECT * FROM order_addresses WHERE order_id=?").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "kind"}))
 				lo, err := st.ListOrders(context.Background())
 				require.NoError(t, err)
 				require.Len(t, lo, 1)
@@ -0,0 +1,47 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+)
+
+func (ms *MySQLStorer) CreateUser(ctx context.Context, u *User) (*User, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO users (name, email, password, is_admin) VALUES (:name, :email, :password, :is_admin)", u)
+	if err != nil {
+		return nil, wrapErr("error inserting user", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	u.ID = id
+	return u, nil
+}
+
+func (ms *MySQLStorer) GetUser(ctx context.Context, id int64) (*User, error) {
+	var u User
+	err := ms.db.GetContext(ctx, &u, "SELECT * FROM users WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting user", err)
+	}
+	return &u, nil
+}
+
+func (ms *MySQLStorer) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	var u User
+	err := ms.db.GetContext(ctx, &u, "SELECT * FROM users WHERE email=?", email)
+	if err != nil {
+		return nil, wrapErr("error getting user", err)
+	}
+	return &u, nil
+}
+
+// SetUserCustomerGroup moves the user into a customer group, or out of any
+// when groupID is nil.
+func (ms *MySQLStorer) SetUserCustomerGroup(ctx context.Context, userID int64, groupID *int64) error {
+	_, err := ms.db.ExecContext(ctx, "UPDATE users SET customer_group_id=? WHERE id=?", groupID, userID)
+	if err != nil {
+		return wrapErr("error setting customer group", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+)
+
+func (ms *MySQLStorer) CreateVariant(ctx context.Context, v *Variant) (*Variant, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO product_variants (product_id, sku, options, price, count_in_stock, created_at) VALUES (:product_id, :sku, :options, :price, :count_in_stock, :created_at)", v)
+	if err != nil {
+		return nil, wrapErr("error inserting variant", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	v.ID = id
+	return v, nil
+}
+
+func (ms *MySQLStorer) GetVariant(ctx context.Context, id int64) (*Variant, error) {
+	var v Variant
+	err := ms.db.GetContext(ctx, &v, "SELECT * FROM product_variants WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting variant", err)
+	}
+	return &v, nil
+}
+
+func (ms *MySQLStorer) ListVariants(ctx context.Context, productID int64) ([]Variant, error) {
+	var variants []Variant
+	err := ms.db.SelectContext(ctx, &variants, "SELECT * FROM product_variants WHERE product_id=? ORDER BY id", productID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing variants: %w", err)
+	}
+	return variants, nil
+}
+
+func (ms *MySQLStorer) UpdateVariant(ctx context.Context, v *Variant) (*Variant, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE product_variants SET sku=:sku, options=:options, price=:price, updated_at=:updated_at WHERE id=:id", v)
+	if err != nil {
+		return nil, wrapErr("error updating variant", err)
+	}
+	return v, nil
+}
+
+func (ms *MySQLStorer) DeleteVariant(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM product_variants WHERE id=?", id)
+	if err != nil {
+		return wrapErr("error deleting variant", err)
+	}
+	return nil
+}
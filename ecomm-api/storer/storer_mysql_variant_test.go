@@ -0,0 +1,91 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/m21power/ecomm/ecomm-api/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateVariant(t *testing.T) {
+	price := money.Amount(2550)
+	v := &Variant{
+		ProductID:    1,
+		SKU:          "TSHIRT-M-RED",
+		Options:      VariantOptions{"size": "M", "colour": "red"},
+		Price:        &price,
+		CountInStock: 5,
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO product_variants (product_id, sku, options, price, count_in_stock, created_at) VALUES (?, ?, ?, ?, ?, ?)").WithArgs(v.ProductID, v.SKU, `{"colour":"red","size":"M"}`, v.Price, v.CountInStock, v.CreatedAt).WillReturnResult(sqlmock.NewResult(3, 1))
+				cv, err := st.CreateVariant(context.Background(), v)
+				require.NoError(t, err)
+				require.Equal(t, int64(3), cv.ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "error inserting variant",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO product_variants (product_id, sku, options, price, count_in_stock, created_at) VALUES (?, ?, ?, ?, ?, ?)").WillReturnError(fmt.Errorf("db error"))
+				_, err := st.CreateVariant(context.Background(), v)
+				require.Error(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestListVariants(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "product_id", "sku", "options", "price", "count_in_stock", "created_at", "updated_at"}).
+					AddRow(1, 1, "TSHIRT-S", []byte(`{"size":"S"}`), nil, 3, time.Now(), nil).
+					AddRow(2, 1, "TSHIRT-M", []byte(`{"size":"M"}`), "30.00", 0, time.Now(), nil)
+				mock.ExpectQuery("SELECT * FROM product_variants WHERE product_id=? ORDER BY id").WithArgs(1).WillReturnRows(rows)
+				vs, err := st.ListVariants(context.Background(), 1)
+				require.NoError(t, err)
+				require.Len(t, vs, 2)
+				require.Equal(t, "S", vs[0].Options["size"])
+				require.Nil(t, vs[0].Price)
+				require.Equal(t, money.Amount(3000), *vs[1].Price)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (ms *MySQLStorer) CreateWarehouse(ctx context.Context, w *Warehouse) (*Warehouse, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO warehouses (code, name, country, region, priority, active, created_at) VALUES (:code, :name, :country, :region, :priority, :active, :created_at)", w)
+	if err != nil {
+		return nil, wrapErr("error inserting warehouse", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	w.ID = id
+	return w, nil
+}
+
+func (ms *MySQLStorer) GetWarehouse(ctx context.Context, id int64) (*Warehouse, error) {
+	var w Warehouse
+	err := ms.db.GetContext(ctx, &w, "SELECT * FROM warehouses WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting warehouse", err)
+	}
+	return &w, nil
+}
+
+// ListWarehouses lists the warehouses in the order they fulfil orders.
+func (ms *MySQLStorer) ListWarehouses(ctx context.Context) ([]Warehouse, error) {
+	var warehouses []Warehouse
+	err := ms.db.SelectContext(ctx, &warehouses, "SELECT * FROM warehouses ORDER BY priority, id")
+	if err != nil {
+		return nil, fmt.Errorf("error listing warehouses: %w", err)
+	}
+	return warehouses, nil
+}
+
+func (ms *MySQLStorer) UpdateWarehouse(ctx context.Context, w *Warehouse) (*Warehouse, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE warehouses SET code=:code, name=:name, country=:country, region=:region, priority=:priority, active=:active, updated_at=:updated_at WHERE id=:id", w)
+	if err != nil {
+		return nil, wrapErr("error updating warehouse", err)
+	}
+	return w, nil
+}
+
+// DeleteWarehouse deletes a warehouse that holds no stock and never took
+// part in an order or adjustment; otherwise it returns ErrConflict and the
+// warehouse should be deactivated instead.
+func (ms *MySQLStorer) DeleteWarehouse(ctx context.Context, id int64) error {
+	return ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM stock_levels WHERE warehouse_id=? AND quantity=0", id)
+		if err != nil {
+			return fmt.Errorf("error deleting empty stock levels: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM warehouses WHERE id=?", id)
+		if err != nil {
+			return wrapErr("error deleting warehouse", err)
+		}
+		return nil
+	})
+}
+
+// ListStockLevels lists what each warehouse holds of the product and its
+// variants.
+func (ms *MySQLStorer) ListStockLevels(ctx context.Context, productID int64) ([]StockLevel, error) {
+	var levels []StockLevel
+	err := ms.db.SelectContext(ctx, &levels, "SELECT warehouse_id, product_id, variant_id, quantity, reserved, updated_at FROM stock_levels WHERE product_id=? ORDER BY warehouse_id, id", productID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing stock levels: %w", err)
+	}
+	return levels, nil
+}
+
+// restockWarehouse picks where returned units of an order item go back to:
+// the warehouse that fulfilled it or, for items placed before warehouses,
+// the first one.
+func restockWarehouse(ctx context.Context, tx *sqlx.Tx, orderItemID int64) (int64, error) {
+	var ids []int64
+	err := tx.SelectContext(ctx, &ids, "SELECT warehouse_id FROM order_item_allocations WHERE order_item_id=? ORDER BY id LIMIT 1", orderItemID)
+	if err != nil {
+		return 0, fmt.Errorf("error getting order item allocation: %w", err)
+	}
+	if len(ids) > 0 {
+		return ids[0], nil
+	}
+	var id int64
+	err = tx.GetContext(ctx, &id, "SELECT id FROM warehouses ORDER BY priority, id LIMIT 1")
+	if err != nil {
+		return 0, wrapErr("error getting warehouse to restock", err)
+	}
+	return id, nil
+}
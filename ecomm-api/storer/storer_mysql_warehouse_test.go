@@ -0,0 +1,113 @@
+package storer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	takeStockSQL = "UPDATE stock_levels SET quantity=quantity+?, updated_at=? WHERE warehouse_id=? AND product_id=? AND variant_id<=>? AND quantity-reserved>=?"
+	putStockSQL  = "INSERT INTO stock_levels (warehouse_id, product_id, variant_id, quantity, updated_at) VALUES (?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE quantity=quantity+VALUES(quantity), updated_at=VALUES(updated_at)"
+	movementSQL  = "INSERT INTO inventory_movements (warehouse_id, product_id, variant_id, quantity, kind, reason, note, order_id, user_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	// reserveStockSQL holds units for an unpaid order
+	reserveStockSQL = "UPDATE stock_levels SET reserved=reserved+?, updated_at=? WHERE warehouse_id=? AND product_id=? AND variant_id<=>? AND quantity-reserved>=?"
+	allocationSQL   = "INSERT INTO order_item_allocations (order_id, order_item_id, warehouse_id, quantity, status, expires_at) VALUES (?, ?, ?, ?, ?, ?)"
+)
+
+func TestMoveStock(t *testing.T) {
+	now := time.Now()
+	variantID := int64(6)
+	userID := int64(1)
+	// a transfer of three units from warehouse 1 to warehouse 2
+	transfer := func() []InventoryMovement {
+		return []InventoryMovement{
+			{WarehouseID: 1, ProductID: 2, VariantID: &variantID, Quantity: -3, Kind: MovementTransfer, UserID: &userID, CreatedAt: now},
+			{WarehouseID: 2, ProductID: 2, VariantID: &variantID, Quantity: 3, Kind: MovementTransfer, UserID: &userID, CreatedAt: now},
+		}
+	}
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "transfer",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(takeStockSQL).WithArgs(-3, now, 1, 2, variantID, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE product_variants SET count_in_stock=count_in_stock+? WHERE id=?").WithArgs(-3, variantID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(movementSQL).WithArgs(1, 2, variantID, -3, MovementTransfer, "", "", nil, userID, now).WillReturnResult(sqlmock.NewResult(10, 1))
+				mock.ExpectExec(putStockSQL).WithArgs(2, 2, variantID, 3, now).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE product_variants SET count_in_stock=count_in_stock+? WHERE id=?").WithArgs(3, variantID).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(movementSQL).WithArgs(2, 2, variantID, 3, MovementTransfer, "", "", nil, userID, now).WillReturnResult(sqlmock.NewResult(11, 1))
+				mock.ExpectCommit()
+				movements, err := st.MoveStock(context.Background(), transfer())
+				require.NoError(t, err)
+				require.Equal(t, int64(11), movements[1].ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "insufficient_stock_rollback",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				// no row matched: the warehouse holds fewer than three units
+				mock.ExpectExec(takeStockSQL).WithArgs(-3, now, 1, 2, variantID, 3).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+				_, err := st.MoveStock(context.Background(), transfer())
+				require.ErrorIs(t, err, ErrInsufficientStock)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestCreateOrderAllocatesStock(t *testing.T) {
+	expiresAt := time.Now().Add(15 * time.Minute)
+	o := &Order{
+		UserID:    1,
+		Status:    OrderStatusPending,
+		CreatedAt: time.Now(),
+		Items: []OrderItem{{
+			Name:      "product 1",
+			Quantity:  5,
+			Price:     9999,
+			ProductID: 1,
+			// split between two warehouses
+			Allocations: []OrderItemAllocation{{WarehouseID: 2, Quantity: 3, ExpiresAt: &expiresAt}, {WarehouseID: 1, Quantity: 2, ExpiresAt: &expiresAt}},
+		}},
+	}
+	withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+		st := NewMySQLStorer(db)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO orders (user_id, status, payment_method, shipping_method_id, shipping_method, tax_price, shipping_price, discount_price, total_price, currency, exchange_rate, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("INSERT INTO order_items (name,quantity,image,price,product_id,variant_id,order_id,backordered) VALUES (?,?,?,?,?,?,?,?)").WillReturnResult(sqlmock.NewResult(4, 1))
+		for i, w := range []int64{2, 1} {
+			q := o.Items[0].Allocations[i].Quantity
+			mock.ExpectExec(reserveStockSQL).WithArgs(q, o.CreatedAt, w, 1, nil, q).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(productReservedSQL).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(allocationSQL).WithArgs(1, 4, w, q, AllocationReserved, &expiresAt).WillReturnResult(sqlmock.NewResult(int64(i+1), 1))
+		}
+		mock.ExpectCommit()
+		created, err := st.CreateOrder(context.Background(), o)
+		require.NoError(t, err)
+		require.Equal(t, int64(4), created.Items[0].Allocations[0].OrderItemID)
+		require.Equal(t, AllocationReserved, created.Items[0].Allocations[1].Status)
+		err = mock.ExpectationsWereMet()
+		require.NoError(t, err)
+	})
+}
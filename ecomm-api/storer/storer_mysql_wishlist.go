@@ -0,0 +1,142 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func (ms *MySQLStorer) CreateWishlist(ctx context.Context, wl *Wishlist) (*Wishlist, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO wishlists (user_id, name, created_at) VALUES (:user_id, :name, :created_at)", wl)
+	if err != nil {
+		return nil, wrapErr("error inserting wishlist", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	wl.ID = id
+	return wl, nil
+}
+
+// GetWishlist returns the wishlist with its items.
+func (ms *MySQLStorer) GetWishlist(ctx context.Context, id int64) (*Wishlist, error) {
+	var wl Wishlist
+	err := ms.db.GetContext(ctx, &wl, "SELECT * FROM wishlists WHERE id=?", id)
+	if err != nil {
+		return nil, wrapErr("error getting wishlist", err)
+	}
+	return ms.withWishlistItems(ctx, &wl)
+}
+
+// GetWishlistByShareToken returns the shared wishlist whose link token
+// hashes to token.
+func (ms *MySQLStorer) GetWishlistByShareToken(ctx context.Context, token string) (*Wishlist, error) {
+	var wl Wishlist
+	err := ms.db.GetContext(ctx, &wl, "SELECT * FROM wishlists WHERE share_token=?", token)
+	if err != nil {
+		return nil, wrapErr("error getting wishlist", err)
+	}
+	return ms.withWishlistItems(ctx, &wl)
+}
+
+func (ms *MySQLStorer) ListWishlists(ctx context.Context, userID int64) ([]Wishlist, error) {
+	var wls []Wishlist
+	err := ms.db.SelectContext(ctx, &wls, "SELECT * FROM wishlists WHERE user_id=? ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing wishlists: %w", err)
+	}
+	for i := range wls {
+		if _, err := ms.withWishlistItems(ctx, &wls[i]); err != nil {
+			return nil, err
+		}
+	}
+	return wls, nil
+}
+
+func (ms *MySQLStorer) withWishlistItems(ctx context.Context, wl *Wishlist) (*Wishlist, error) {
+	err := ms.db.SelectContext(ctx, &wl.Items, "SELECT * FROM wishlist_items WHERE wishlist_id=? ORDER BY id", wl.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting wishlist items: %w", err)
+	}
+	return wl, nil
+}
+
+func (ms *MySQLStorer) UpdateWishlist(ctx context.Context, wl *Wishlist) (*Wishlist, error) {
+	_, err := ms.db.NamedExecContext(ctx, "UPDATE wishlists SET name=:name, share_token=:share_token, updated_at=:updated_at WHERE id=:id", wl)
+	if err != nil {
+		return nil, wrapErr("error updating wishlist", err)
+	}
+	return wl, nil
+}
+
+func (ms *MySQLStorer) DeleteWishlist(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM wishlists WHERE id=?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting wishlist: %w", err)
+	}
+	return nil
+}
+
+func (ms *MySQLStorer) CreateWishlistItem(ctx context.Context, wi *WishlistItem) (*WishlistItem, error) {
+	res, err := ms.db.NamedExecContext(ctx, "INSERT INTO wishlist_items (wishlist_id, product_id, variant_id, saved_price, saved_in_stock, created_at) VALUES (:wishlist_id, :product_id, :variant_id, :saved_price, :saved_in_stock, :created_at)", wi)
+	if err != nil {
+		return nil, wrapErr("error inserting wishlist item", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	wi.ID = id
+	return wi, nil
+}
+
+func (ms *MySQLStorer) DeleteWishlistItem(ctx context.Context, id int64) error {
+	_, err := ms.db.ExecContext(ctx, "DELETE FROM wishlist_items WHERE id=?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting wishlist item: %w", err)
+	}
+	return nil
+}
+
+// MoveWishlistItemToCart removes the wishlist item and saves ci in the same
+// transaction. ci is updated if it has an ID and inserted otherwise.
+func (ms *MySQLStorer) MoveWishlistItemToCart(ctx context.Context, wishlistItemID int64, ci *CartItem) error {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM wishlist_items WHERE id=?", wishlistItemID)
+		if err != nil {
+			return fmt.Errorf("error deleting wishlist item: %w", err)
+		}
+		return ms.saveCartItem(ctx, tx, ci)
+	})
+	if err != nil {
+		return fmt.Errorf("error moving wishlist item to cart: %w", err)
+	}
+	return nil
+}
+
+// MoveCartItemToWishlist removes the cart item and inserts wi in the same
+// transaction.
+func (ms *MySQLStorer) MoveCartItemToWishlist(ctx context.Context, cartItemID int64, wi *WishlistItem) error {
+	err := ms.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE id=?", cartItemID)
+		if err != nil {
+			return fmt.Errorf("error deleting cart item: %w", err)
+		}
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO wishlist_items (wishlist_id, product_id, variant_id, saved_price, saved_in_stock, created_at) VALUES (:wishlist_id, :product_id, :variant_id, :saved_price, :saved_in_stock, :created_at)", wi)
+		if err != nil {
+			return fmt.Errorf("error inserting wishlist item: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		wi.ID = id
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error moving cart item to wishlist: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWishlistByShareToken(t *testing.T) {
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "success",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM wishlists WHERE share_token=?").WithArgs("hash").WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "name", "share_token", "created_at"}).AddRow(3, 1, "birthday", "hash", time.Now()))
+				mock.ExpectQuery("SELECT * FROM wishlist_items WHERE wishlist_id=? ORDER BY id").WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id", "wishlist_id", "product_id", "variant_id", "saved_price", "saved_in_stock", "created_at"}).AddRow(1, 3, 9, nil, 19.99, true, time.Now()))
+				wl, err := st.GetWishlistByShareToken(context.Background(), "hash")
+				require.NoError(t, err)
+				require.Equal(t, "birthday", wl.Name)
+				require.Len(t, wl.Items, 1)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "not shared",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM wishlists WHERE share_token=?").WithArgs("hash").WillReturnError(sql.ErrNoRows)
+				_, err := st.GetWishlistByShareToken(context.Background(), "hash")
+				require.ErrorIs(t, err, ErrNotFound)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
+
+func TestMoveWishlistItemToCart(t *testing.T) {
+	now := time.Now()
+	tcs := []struct {
+		name string
+		test func(*testing.T, *MySQLStorer, sqlmock.Sqlmock)
+	}{
+		{
+			name: "new cart item",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				ci := &CartItem{CartID: 2, ProductID: 9, Quantity: 1, CreatedAt: now}
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM wishlist_items WHERE id=?").WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO cart_items (cart_id, product_id, variant_id, quantity, created_at) VALUES (?, ?, ?, ?, ?)").WithArgs(int64(2), int64(9), nil, int64(1), now).WillReturnResult(sqlmock.NewResult(8, 1))
+				mock.ExpectCommit()
+				err := st.MoveWishlistItemToCart(context.Background(), 5, ci)
+				require.NoError(t, err)
+				require.Equal(t, int64(8), ci.ID)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "error keeps the wishlist item",
+			test: func(t *testing.T, st *MySQLStorer, mock sqlmock.Sqlmock) {
+				ci := &CartItem{ID: 4, Quantity: 3, UpdatedAt: &now}
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM wishlist_items WHERE id=?").WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE cart_items SET quantity=?, updated_at=? WHERE id=?").WillReturnError(fmt.Errorf("db error"))
+				mock.ExpectRollback()
+				err := st.MoveWishlistItemToCart(context.Background(), 5, ci)
+				require.Error(t, err)
+				err = mock.ExpectationsWereMet()
+				require.NoError(t, err)
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestDB(t, func(db *sqlx.DB, mock sqlmock.Sqlmock) {
+				st := NewMySQLStorer(db)
+				tc.test(t, st, mock)
+			})
+		})
+	}
+}
@@ -1,37 +1,841 @@
 package storer
 
-import "time"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/m21power/ecomm/ecomm-api/money"
+)
 
 type Product struct {
+	ID           int64        `db:"id"`
+	Name         string       `db:"name"`
+	Image        string       `db:"image"`
+	Category     string       `db:"category"`
+	CategoryID   *int64       `db:"category_id"`
+	Description  string       `db:"description"`
+	Rating       float64      `db:"rating"`
+	NumReviews   int          `db:"num_reviews"`
+	Price        money.Amount `db:"price"`
+	CountInStock int64        `db:"count_in_stock"`
+	// Reserved is how many of the units in stock are held for unpaid
+	// orders.
+	Reserved int64 `db:"reserved"`
+	// Weight is in kilograms and the dimensions in centimetres.
+	Weight float64 `db:"weight"`
+	Length float64 `db:"length"`
+	Width  float64 `db:"width"`
+	Height float64 `db:"height"`
+	// InventoryPolicy says whether the product can still be ordered once
+	// it is out of stock. BackorderLimit caps how many units may be on
+	// backorder at once, with no cap when nil; ReleaseDate is when a
+	// pre-order product starts shipping from stock.
+	InventoryPolicy string     `db:"inventory_policy"`
+	BackorderLimit  *int64     `db:"backorder_limit"`
+	ReleaseDate     *time.Time `db:"release_date"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       *time.Time `db:"updated_at"`
+}
+
+// Available is how many units can still be ordered from stock.
+func (p *Product) Available() int64 {
+	return p.CountInStock - p.Reserved
+}
+
+const (
+	// InventoryDeny products cannot be ordered beyond their stock.
+	InventoryDeny = "deny"
+	// InventoryBackorder products take orders beyond their stock, up to
+	// the backorder limit.
+	InventoryBackorder = "backorder"
+	// InventoryPreorder products take orders beyond their stock until
+	// their release date, then behave like InventoryDeny ones.
+	InventoryPreorder = "preorder"
+)
+
+// AllowsBackorder reports whether units of p ordered at t may be
+// backordered.
+func (p *Product) AllowsBackorder(t time.Time) bool {
+	switch p.InventoryPolicy {
+	case InventoryBackorder:
+		return true
+	case InventoryPreorder:
+		return p.ReleaseDate == nil || t.Before(*p.ReleaseDate)
+	}
+	return false
+}
+
+type Order struct {
+	ID            int64  `db:"id"`
+	UserID        int64  `db:"user_id"`
+	Status        string `db:"status"`
+	PaymentMethod string `db:"payment_method"`
+	// ShippingMethod keeps the name of the method the order shipped with.
+	ShippingMethodID *int64       `db:"shipping_method_id"`
+	ShippingMethod   string       `db:"shipping_method"`
+	TaxPrice         money.Amount `db:"tax_price"`
+	ShippingPrice    money.Amount `db:"shipping_price"`
+	DiscountPrice    money.Amount `db:"discount_price"`
+	TotalPrice       money.Amount `db:"total_price"`
+	// Currency is what the order's amounts are in and ExchangeRate the
+	// units of it one unit of the base currency bought at checkout.
+	Currency     string     `db:"currency"`
+	ExchangeRate float64    `db:"exchange_rate"`
+	CreatedAt    time.Time  `db:"created_at"`
+	UpdatedAt    *time.Time `db:"updated_at"`
+	Items        []OrderItem
+	Discounts    []OrderDiscount
+	// ShippingAddress and BillingAddress are copies taken when the order
+	// was placed.
+	ShippingAddress *OrderAddress
+	BillingAddress  *OrderAddress
+}
+
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+	// OrderStatusPartiallyRefunded and OrderStatusRefunded follow a paid
+	// order once some or all of its payment was given back.
+	OrderStatusPartiallyRefunded = "partially_refunded"
+	OrderStatusRefunded          = "refunded"
+)
+
+type OrderItem struct {
+	ID        int64        `db:"id"`
+	Name      string       `db:"name"`
+	Quantity  int64        `db:"quantity"`
+	Image     string       `db:"image"`
+	Price     money.Amount `db:"price"`
+	ProductID int64        `db:"product_id"`
+	VariantID *int64       `db:"variant_id"`
+	OrderID   int64        `db:"order_id"`
+	// Backordered is how many of the units were out of stock when the
+	// item was ordered; they ship once the product is restocked.
+	Backordered int64 `db:"backordered"`
+	Taxes       []OrderItemTax
+	// Allocations say which warehouses fulfil the item.
+	Allocations []OrderItemAllocation
+}
+
+// OrderItemAllocation takes Quantity units of an order item out of a
+// warehouse's stock. Until the order is paid the units are only reserved,
+// up to ExpiresAt; they are taken out when the payment is captured.
+type OrderItemAllocation struct {
+	ID          int64      `db:"id"`
+	OrderID     int64      `db:"order_id"`
+	OrderItemID int64      `db:"order_item_id"`
+	WarehouseID int64      `db:"warehouse_id"`
+	Quantity    int64      `db:"quantity"`
+	Status      string     `db:"status"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+}
+
+const (
+	AllocationReserved = "reserved"
+	// AllocationReleased allocations gave their units back, because the
+	// reservation expired, the payment failed or the order was cancelled.
+	AllocationReleased = "released"
+	AllocationTaken    = "taken"
+)
+
+// ProductFilter narrows ListProducts. Zero-valued fields are ignored and a
+// zero Limit returns every matching product.
+type ProductFilter struct {
+	CategoryIDs []int64
+	MinPrice    money.Amount
+	MaxPrice    money.Amount
+	Limit       int
+	Offset      int
+}
+
+type Category struct {
+	ID       int64  `db:"id"`
+	Name     string `db:"name"`
+	Slug     string `db:"slug"`
+	ParentID *int64 `db:"parent_id"`
+	// TaxClass selects the tax rates that apply to the category's products.
+	TaxClass  string     `db:"tax_class"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+// Variant is a purchasable version of a product, e.g. a size and colour of a
+// T-shirt. Price overrides the product's price when set, and the variant
+// keeps its own stock.
+type Variant struct {
+	ID           int64          `db:"id"`
+	ProductID    int64          `db:"product_id"`
+	SKU          string         `db:"sku"`
+	Options      VariantOptions `db:"options"`
+	Price        *money.Amount  `db:"price"`
+	CountInStock int64          `db:"count_in_stock"`
+	Reserved     int64          `db:"reserved"`
+	CreatedAt    time.Time      `db:"created_at"`
+	UpdatedAt    *time.Time     `db:"updated_at"`
+}
+
+// Available is how many units can still be ordered from stock.
+func (v *Variant) Available() int64 {
+	return v.CountInStock - v.Reserved
+}
+
+// VariantOptions maps option names to values, e.g. {"size": "M"}. It is
+// stored as a JSON column.
+type VariantOptions map[string]string
+
+func (o VariantOptions) Value() (driver.Value, error) {
+	if o == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (o *VariantOptions) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, o)
+	case string:
+		return json.Unmarshal([]byte(v), o)
+	case nil:
+		*o = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into VariantOptions", src)
+}
+
+type User struct {
+	ID       int64  `db:"id"`
+	Name     string `db:"name"`
+	Email    string `db:"email"`
+	Password string `db:"password"`
+	IsAdmin  bool   `db:"is_admin"`
+	// CustomerGroupID picks the price lists that apply to the user.
+	CustomerGroupID *int64 `db:"customer_group_id"`
+}
+
+type Review struct {
+	ID               int64      `db:"id"`
+	ProductID        int64      `db:"product_id"`
+	UserID           int64      `db:"user_id"`
+	Stars            int        `db:"stars"`
+	Title            string     `db:"title"`
+	Body             string     `db:"body"`
+	VerifiedPurchase bool       `db:"verified_purchase"`
+	Status           string     `db:"status"`
+	ReportCount      int        `db:"report_count"`
+	ModeratedBy      *int64     `db:"moderated_by"`
+	ModeratedAt      *time.Time `db:"moderated_at"`
+	CreatedAt        time.Time  `db:"created_at"`
+	UpdatedAt        *time.Time `db:"updated_at"`
+}
+
+// Only approved reviews are shown to customers and count toward a product's
+// rating.
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+	ReviewStatusFlagged  = "flagged"
+)
+
+type ReviewFilter struct {
+	ProductID int64
+	Statuses  []string
+}
+
+type ReviewReport struct {
+	ID        int64     `db:"id"`
+	ReviewID  int64     `db:"review_id"`
+	UserID    int64     `db:"user_id"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Cart belongs to a user, or to a guest identified by GuestToken, the hash
+// of the token held by the guest's browser. Guest carts expire.
+type Cart struct {
+	ID         int64      `db:"id"`
+	UserID     *int64     `db:"user_id"`
+	GuestToken *string    `db:"guest_token"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  *time.Time `db:"updated_at"`
+	Items      []CartItem
+}
+
+type CartItem struct {
+	ID        int64      `db:"id"`
+	CartID    int64      `db:"cart_id"`
+	ProductID int64      `db:"product_id"`
+	VariantID *int64     `db:"variant_id"`
+	Quantity  int64      `db:"quantity"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+// Wishlist is a user's named list of saved products. ShareToken is the hash
+// of the token in the list's public link, nil while the list is private.
+type Wishlist struct {
+	ID         int64      `db:"id"`
+	UserID     int64      `db:"user_id"`
+	Name       string     `db:"name"`
+	ShareToken *string    `db:"share_token"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  *time.Time `db:"updated_at"`
+	Items      []WishlistItem
+}
+
+// WishlistItem remembers the price and availability of the product when it
+// was saved.
+type WishlistItem struct {
+	ID           int64        `db:"id"`
+	WishlistID   int64        `db:"wishlist_id"`
+	ProductID    int64        `db:"product_id"`
+	VariantID    *int64       `db:"variant_id"`
+	SavedPrice   money.Amount `db:"saved_price"`
+	SavedInStock bool         `db:"saved_in_stock"`
+	CreatedAt    time.Time    `db:"created_at"`
+}
+
+const (
+	PromotionPercentage   = "percentage"
+	PromotionFixedAmount  = "fixed_amount"
+	PromotionFreeShipping = "free_shipping"
+	PromotionBuyXGetY     = "buy_x_get_y"
+)
+
+// Promotion is a discount rule. Promotions without a Code apply to every
+// qualifying order; the others only when the customer enters the code.
+// Percent is the percentage off for percentage promotions and Amount the
+// money off for fixed-amount ones. A buy-X-get-Y promotion gives
+// GetQuantity units free for every BuyQuantity units paid for.
+type Promotion struct {
+	ID            int64          `db:"id"`
+	Code          *string        `db:"code"`
+	Name          string         `db:"name"`
+	Kind          string         `db:"kind"`
+	Percent       float64        `db:"percent"`
+	Amount        money.Amount   `db:"amount"`
+	BuyQuantity   int64          `db:"buy_quantity"`
+	GetQuantity   int64          `db:"get_quantity"`
+	MinOrderValue money.Amount   `db:"min_order_value"`
+	Scope         PromotionScope `db:"scope"`
+	UsageLimit    *int64         `db:"usage_limit"`
+	PerUserLimit  *int64         `db:"per_user_limit"`
+	TimesUsed     int64          `db:"times_used"`
+	// Stackable promotions combine with each other; any other promotion is
+	// applied on its own.
+	Stackable bool       `db:"stackable"`
+	Active    bool       `db:"active"`
+	StartsAt  *time.Time `db:"starts_at"`
+	EndsAt    *time.Time `db:"ends_at"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+// PromotionScope limits a promotion to the listed products and categories.
+// An empty scope covers the whole catalog.
+type PromotionScope struct {
+	ProductIDs  []int64 `json:"product_ids,omitempty"`
+	CategoryIDs []int64 `json:"category_ids,omitempty"`
+}
+
+func (sc PromotionScope) Empty() bool {
+	return len(sc.ProductIDs) == 0 && len(sc.CategoryIDs) == 0
+}
+
+func (sc PromotionScope) Value() (driver.Value, error) {
+	b, err := json.Marshal(sc)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (sc *PromotionScope) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, sc)
+	case string:
+		return json.Unmarshal([]byte(v), sc)
+	case nil:
+		*sc = PromotionScope{}
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into PromotionScope", src)
+}
+
+// OrderDiscount is one line of an order's discount breakdown.
+type OrderDiscount struct {
+	ID          int64        `db:"id"`
+	OrderID     int64        `db:"order_id"`
+	PromotionID *int64       `db:"promotion_id"`
+	Code        *string      `db:"code"`
+	Description string       `db:"description"`
+	Amount      money.Amount `db:"amount"`
+	// Shipping is set on discounts taken off the shipping price rather
+	// than the items.
+	Shipping bool `db:"shipping"`
+}
+
+// TaxClassStandard is the tax class of products without a category.
+const TaxClassStandard = "standard"
+
+// TaxRate is a row of the tax table. It applies to items of TaxClass
+// delivered to Country and, when set, Region and a postal code starting with
+// PostalPrefix. Rate is a fraction, e.g. 0.2 for 20%.
+type TaxRate struct {
 	ID           int64      `db:"id"`
 	Name         string     `db:"name"`
-	Image        string     `db:"image"`
-	Category     string     `db:"category"`
-	Description  string     `db:"description"`
-	Rating       int        `db:"rating"`
-	NumReviews   int        `db:"num_reviews"`
-	Price        float64    `db:"price"`
-	CountInStock int64      `db:"count_in_stock"`
+	Country      string     `db:"country"`
+	Region       string     `db:"region"`
+	PostalPrefix string     `db:"postal_prefix"`
+	TaxClass     string     `db:"tax_class"`
+	Rate         float64    `db:"rate"`
+	Priority     int        `db:"priority"`
 	CreatedAt    time.Time  `db:"created_at"`
 	UpdatedAt    *time.Time `db:"updated_at"`
 }
 
-type Order struct {
-	ID            int64      `db:"id"`
-	PaymentMethod string     `db:"payment_method"`
-	TaxPrice      int64      `db:"tax_price"`
-	ShippingPrice int64      `db:"shipping_price"`
-	TotalPrice    int64      `db:"total_price"`
-	CreatedAt     time.Time  `db:"created_at"`
-	UpdatedAt     *time.Time `db:"updated_at"`
-	Items         []OrderItem
+// OrderItemTax is one tax charged on an order item. Included is set when the
+// item's price already contained the tax.
+type OrderItemTax struct {
+	ID          int64        `db:"id"`
+	OrderID     int64        `db:"order_id"`
+	OrderItemID int64        `db:"order_item_id"`
+	TaxRateID   *int64       `db:"tax_rate_id"`
+	Name        string       `db:"name"`
+	Rate        float64      `db:"rate"`
+	Amount      money.Amount `db:"amount"`
+	Included    bool         `db:"included"`
 }
-type OrderItem struct {
-	ID        int64   `db:"id"`
-	Name      string  `db:"name"`
-	Quantity  int64   `db:"quantity"`
-	Image     string  `db:"image"`
-	Price     float64 `db:"price"`
-	ProductID int64   `db:"product_id"`
-	OrderID   int64   `db:"order_id"`
+
+// ShippingZone is a group of destinations that share shipping methods.
+type ShippingZone struct {
+	ID        int64         `db:"id"`
+	Name      string        `db:"name"`
+	Locations ZoneLocations `db:"locations"`
+	CreatedAt time.Time     `db:"created_at"`
+	UpdatedAt *time.Time    `db:"updated_at"`
+}
+
+// ZoneLocation covers a country or, when Region or PostalPrefix is set, part
+// of one.
+type ZoneLocation struct {
+	Country      string `json:"country"`
+	Region       string `json:"region,omitempty"`
+	PostalPrefix string `json:"postal_prefix,omitempty"`
+}
+
+// ZoneLocations is stored as a JSON column.
+type ZoneLocations []ZoneLocation
+
+func (zl ZoneLocations) Value() (driver.Value, error) {
+	if zl == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(zl)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (zl *ZoneLocations) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, zl)
+	case string:
+		return json.Unmarshal([]byte(v), zl)
+	case nil:
+		*zl = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into ZoneLocations", src)
+}
+
+const (
+	ShippingFlat        = "flat"
+	ShippingWeight      = "weight"
+	ShippingPriceTiered = "price_tiered"
+	ShippingFreeOver    = "free_over"
+)
+
+// ShippingMethod is a way of shipping to a zone. Flat methods cost Price;
+// free-over methods cost Price unless the order is worth at least FreeOver.
+// Weight and price-tiered methods cost the price of the last tier the
+// order's weight in kilograms or value reaches.
+type ShippingMethod struct {
+	ID        int64         `db:"id"`
+	ZoneID    int64         `db:"zone_id"`
+	Name      string        `db:"name"`
+	Kind      string        `db:"kind"`
+	Price     money.Amount  `db:"price"`
+	FreeOver  money.Amount  `db:"free_over"`
+	Tiers     ShippingTiers `db:"tiers"`
+	Active    bool          `db:"active"`
+	CreatedAt time.Time     `db:"created_at"`
+	UpdatedAt *time.Time    `db:"updated_at"`
+}
+
+// ShippingTier starts at FromWeight kilograms for weight methods or at an
+// order value of FromValue for price-tiered ones.
+type ShippingTier struct {
+	FromWeight float64      `json:"from_weight,omitempty"`
+	FromValue  money.Amount `json:"from_value,omitempty"`
+	Price      money.Amount `json:"price"`
+}
+
+// ShippingTiers is stored as a JSON column.
+type ShippingTiers []ShippingTier
+
+func (st ShippingTiers) Value() (driver.Value, error) {
+	if st == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (st *ShippingTiers) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, st)
+	case string:
+		return json.Unmarshal([]byte(v), st)
+	case nil:
+		*st = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into ShippingTiers", src)
+}
+
+// PostalAddress is the part of an address a parcel is sent to.
+type PostalAddress struct {
+	Name       string `db:"name"`
+	Line1      string `db:"line1"`
+	Line2      string `db:"line2"`
+	City       string `db:"city"`
+	Region     string `db:"region"`
+	PostalCode string `db:"postal_code"`
+	Country    string `db:"country"`
+	Phone      string `db:"phone"`
+}
+
+// Address is an entry of a user's address book. At most one address of a
+// user is their default.
+type Address struct {
+	ID     int64 `db:"id"`
+	UserID int64 `db:"user_id"`
+	PostalAddress
+	IsDefault bool       `db:"is_default"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+const (
+	AddressShipping = "shipping"
+	AddressBilling  = "billing"
+)
+
+// OrderAddress is the copy of an address an order was placed with.
+type OrderAddress struct {
+	ID      int64  `db:"id"`
+	OrderID int64  `db:"order_id"`
+	Kind    string `db:"kind"`
+	PostalAddress
+}
+
+// Payment is one attempt to pay for an order through a payment provider.
+// ProviderRef identifies the payment at the provider.
+type Payment struct {
+	ID            int64        `db:"id"`
+	OrderID       int64        `db:"order_id"`
+	Provider      string       `db:"provider"`
+	ProviderRef   string       `db:"provider_ref"`
+	Amount        money.Amount `db:"amount"`
+	Currency      string       `db:"currency"`
+	Status        string       `db:"status"`
+	FailureReason string       `db:"failure_reason"`
+	CreatedAt     time.Time    `db:"created_at"`
+	UpdatedAt     *time.Time   `db:"updated_at"`
+}
+
+const (
+	PaymentPending    = "pending"
+	PaymentAuthorized = "authorized"
+	PaymentCaptured   = "captured"
+	PaymentVoided     = "voided"
+	PaymentFailed     = "failed"
+)
+
+// PaymentEvent is a webhook delivery from a payment provider. Payload is
+// kept as received so the event can be replayed.
+type PaymentEvent struct {
+	ID          int64      `db:"id"`
+	Provider    string     `db:"provider"`
+	EventID     string     `db:"event_id"`
+	Type        string     `db:"type"`
+	PaymentRef  string     `db:"payment_ref"`
+	Payload     []byte     `db:"payload"`
+	Error       string     `db:"error"`
+	ReceivedAt  time.Time  `db:"received_at"`
+	ProcessedAt *time.Time `db:"processed_at"`
+}
+
+// Refund gives back part or all of a captured payment. Items lists the
+// order items refunded, if any; with Restock they go back into stock once
+// the refund succeeds.
+type Refund struct {
+	ID            int64        `db:"id"`
+	OrderID       int64        `db:"order_id"`
+	PaymentID     int64        `db:"payment_id"`
+	Amount        money.Amount `db:"amount"`
+	Reason        string       `db:"reason"`
+	Restock       bool         `db:"restock"`
+	Status        string       `db:"status"`
+	ProviderRef   string       `db:"provider_ref"`
+	FailureReason string       `db:"failure_reason"`
+	CreatedAt     time.Time    `db:"created_at"`
+	UpdatedAt     *time.Time   `db:"updated_at"`
+	Items         []RefundItem
+}
+
+const (
+	RefundPending   = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+type RefundItem struct {
+	ID          int64  `db:"id"`
+	RefundID    int64  `db:"refund_id"`
+	OrderItemID int64  `db:"order_item_id"`
+	ProductID   int64  `db:"product_id"`
+	VariantID   *int64 `db:"variant_id"`
+	Quantity    int64  `db:"quantity"`
+}
+
+// Return is a customer's request to send order items back. Accepting it
+// refunds the items, restocking them when Restock is set.
+type Return struct {
+	ID        int64      `db:"id"`
+	OrderID   int64      `db:"order_id"`
+	UserID    int64      `db:"user_id"`
+	Status    string     `db:"status"`
+	Note      string     `db:"note"`
+	AdminNote string     `db:"admin_note"`
+	Restock   bool       `db:"restock"`
+	RefundID  *int64     `db:"refund_id"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+	Items     []ReturnItem
+}
+
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+	ReturnInspected = "inspected"
+	ReturnAccepted  = "accepted"
+)
+
+type ReturnItem struct {
+	ID          int64  `db:"id"`
+	ReturnID    int64  `db:"return_id"`
+	OrderItemID int64  `db:"order_item_id"`
+	Quantity    int64  `db:"quantity"`
+	ReasonCode  string `db:"reason_code"`
+}
+
+// Reasons a customer can give for returning an item.
+const (
+	ReturnReasonDamaged        = "damaged"
+	ReturnReasonDefective      = "defective"
+	ReturnReasonWrongItem      = "wrong_item"
+	ReturnReasonNotAsDescribed = "not_as_described"
+	ReturnReasonNoLongerNeeded = "no_longer_needed"
+	ReturnReasonOther          = "other"
+)
+
+// ReturnFilter narrows ListReturns. Zero-valued fields are ignored.
+type ReturnFilter struct {
+	OrderID  int64
+	UserID   int64
+	Statuses []string
+}
+
+// IdempotencyKey remembers the response to a request a user sent with an
+// idempotency key. StatusCode is 0 until the request has been handled.
+type IdempotencyKey struct {
+	ID           int64     `db:"id"`
+	UserID       int64     `db:"user_id"`
+	Key          string    `db:"idempotency_key"`
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   int       `db:"status_code"`
+	ContentType  string    `db:"content_type"`
+	ResponseBody []byte    `db:"response_body"`
+	CreatedAt    time.Time `db:"created_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
+
+// ExchangeRate is how many units of Currency one unit of the base currency
+// buys.
+type ExchangeRate struct {
+	Currency  string    `db:"currency"`
+	Rate      float64   `db:"rate"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// CustomerGroup is a set of customers, e.g. wholesale buyers, that share
+// price lists.
+type CustomerGroup struct {
+	ID        int64      `db:"id"`
+	Name      string     `db:"name"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+// PriceList overrides catalog prices for the customers of a group.
+type PriceList struct {
+	ID              int64            `db:"id"`
+	CustomerGroupID int64            `db:"customer_group_id"`
+	Name            string           `db:"name"`
+	Active          bool             `db:"active"`
+	Entries         PriceListEntries `db:"entries"`
+	CreatedAt       time.Time        `db:"created_at"`
+	UpdatedAt       *time.Time       `db:"updated_at"`
+}
+
+// PriceListEntry prices a product, or takes a percentage off the products
+// of a category and its subcategories, once at least MinQuantity units are
+// ordered. Entries of the same product or category with different
+// MinQuantity form quantity tiers.
+type PriceListEntry struct {
+	ProductID   *int64        `json:"product_id,omitempty"`
+	CategoryID  *int64        `json:"category_id,omitempty"`
+	MinQuantity int64         `json:"min_quantity"`
+	Price       *money.Amount `json:"price,omitempty"`
+	PercentOff  float64       `json:"percent_off,omitempty"`
+}
+
+// PriceListEntries is stored as a JSON column.
+type PriceListEntries []PriceListEntry
+
+func (pe PriceListEntries) Value() (driver.Value, error) {
+	if pe == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(pe)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (pe *PriceListEntries) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, pe)
+	case string:
+		return json.Unmarshal([]byte(v), pe)
+	case nil:
+		*pe = nil
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into PriceListEntries", src)
+}
+
+// Warehouse is a location that holds stock and fulfils orders. Orders are
+// fulfilled from lower Priority numbers first.
+type Warehouse struct {
+	ID        int64      `db:"id"`
+	Code      string     `db:"code"`
+	Name      string     `db:"name"`
+	Country   string     `db:"country"`
+	Region    string     `db:"region"`
+	Priority  int        `db:"priority"`
+	Active    bool       `db:"active"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+// StockLevel is how many units of a product, or of one of its variants, a
+// warehouse holds. The product's or variant's CountInStock is the sum over
+// all warehouses. Reserved of the units are held for unpaid orders and
+// cannot be allocated again.
+type StockLevel struct {
+	WarehouseID int64      `db:"warehouse_id"`
+	ProductID   int64      `db:"product_id"`
+	VariantID   *int64     `db:"variant_id"`
+	Quantity    int64      `db:"quantity"`
+	Reserved    int64      `db:"reserved"`
+	UpdatedAt   *time.Time `db:"updated_at"`
+}
+
+// Available is how many units can still be allocated.
+func (l StockLevel) Available() int64 {
+	return l.Quantity - l.Reserved
+}
+
+// InventoryMovement is an entry in the append-only inventory ledger:
+// Quantity units of a product or variant going into a warehouse's stock,
+// or out of it when negative. Every stock change is recorded as one, so a
+// warehouse's stock level is the sum of its movements. UserID is who made
+// the change, when known.
+type InventoryMovement struct {
+	ID          int64  `db:"id"`
+	WarehouseID int64  `db:"warehouse_id"`
+	ProductID   int64  `db:"product_id"`
+	VariantID   *int64 `db:"variant_id"`
+	Quantity    int64  `db:"quantity"`
+	Kind        string `db:"kind"`
+	// Reason is one of the Adjustment reasons for adjustments.
+	Reason    string    `db:"reason"`
+	Note      string    `db:"note"`
+	OrderID   *int64    `db:"order_id"`
+	UserID    *int64    `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+const (
+	MovementOrder        = "order"
+	MovementCancellation = "cancellation"
+	MovementReturn       = "return"
+	MovementAdjustment   = "adjustment"
+	// MovementTransfer marks both sides of a move between warehouses.
+	MovementTransfer = "transfer"
+	// MovementImport records stock brought in from outside the ledger, such
+	// as the opening balances.
+	MovementImport = "import"
+)
+
+const (
+	AdjustmentReceived   = "received"
+	AdjustmentDamaged    = "damaged"
+	AdjustmentLost       = "lost"
+	AdjustmentFound      = "found"
+	AdjustmentCorrection = "correction"
+)
+
+// MovementFilter narrows ListInventoryMovements. Zero-valued fields are
+// ignored and a zero Limit returns every matching movement.
+type MovementFilter struct {
+	ProductID   int64
+	WarehouseID int64
+	Limit       int
+	Offset      int
 }